@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+)
+
+// HandleListAudit returns the most recent audit entries, or none when auditing is not configured. Admin only.
+func (h *Handlers) HandleListAudit(c *gin.Context) {
+	if !h.authorize(c, auth.RoleAdmin, "list", "audit", "", "") {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+
+	if h.audit == nil {
+		c.JSON(http.StatusOK, gin.H{"entries": []audit.Entry{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": h.audit.Recent(limit)})
+}
+
+// authorize checks that the current user holds the required role. Denied requests are
+// audited and answered with 403, in which case false is returned.
+func (h *Handlers) authorize(c *gin.Context, required auth.Role, action, resource, namespace, name string) bool {
+	if auth.RoleFromContext(c).Allows(required) {
+		return true
+	}
+
+	h.recordAudit(c, audit.Entry{
+		Action:    action,
+		Resource:  resource,
+		Namespace: namespace,
+		Name:      name,
+		Result:    audit.ResultDenied,
+		Error:     "requires role " + string(required),
+	})
+	c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+	return false
+}
+
+// recordAudit fills in the user and role of the current request and records the entry.
+func (h *Handlers) recordAudit(c *gin.Context, entry audit.Entry) {
+	if h.audit == nil {
+		return
+	}
+	entry.User = currentUser(c)
+	entry.Role = string(auth.RoleFromContext(c))
+	h.audit.Record(entry)
+}
+
+// currentUser returns the authenticated username from the request context.
+func currentUser(c *gin.Context) string {
+	if user, exists := c.Get("user"); exists {
+		if name, ok := user.(string); ok {
+			return name
+		}
+	}
+	return "anonymous"
+}
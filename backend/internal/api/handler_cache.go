@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HandleReady reports whether the backend is ready to serve traffic, including informer cache sync status.
+func (h *Handlers) HandleReady(c *gin.Context) {
+	if h.cache == nil {
+		c.JSON(http.StatusOK, gin.H{"status": "ready", "caches": gin.H{}})
+		return
+	}
+
+	status := h.cache.SyncStatus()
+	if !h.cache.HasSynced() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "syncing", "caches": status})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "caches": status})
+}
+
+// listPods lists pods from the informer cache, falling back to the API server until the cache has synced.
+func (h *Handlers) listPods(ctx context.Context, namespace, labelSelector, fieldSelector string) ([]*v1.Pod, error) {
+	if h.cache != nil && h.cache.IsSynced("pods") {
+		return h.cache.ListPods(namespace, labelSelector, fieldSelector)
+	}
+
+	list, err := h.podManager.GetClient().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]*v1.Pod, 0, len(list.Items))
+	for i := range list.Items {
+		pods = append(pods, &list.Items[i])
+	}
+	return pods, nil
+}
+
+// listNodes lists nodes from the informer cache, falling back to the API server until the cache has synced.
+func (h *Handlers) listNodes(ctx context.Context, labelSelector, fieldSelector string) ([]*v1.Node, error) {
+	if h.cache != nil && h.cache.IsSynced("nodes") {
+		return h.cache.ListNodes(labelSelector, fieldSelector)
+	}
+
+	list, err := h.podManager.GetClient().CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*v1.Node, 0, len(list.Items))
+	for i := range list.Items {
+		nodes = append(nodes, &list.Items[i])
+	}
+	return nodes, nil
+}
+
+// listNamespaces lists namespaces from the informer cache, falling back to the API server until the cache has synced.
+func (h *Handlers) listNamespaces(ctx context.Context, labelSelector string) ([]*v1.Namespace, error) {
+	if h.cache != nil && h.cache.IsSynced("namespaces") {
+		return h.cache.ListNamespaces(labelSelector)
+	}
+
+	list, err := h.podManager.GetClient().CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]*v1.Namespace, 0, len(list.Items))
+	for i := range list.Items {
+		namespaces = append(namespaces, &list.Items[i])
+	}
+	return namespaces, nil
+}
@@ -0,0 +1,390 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+)
+
+const (
+	// secretRevealRoleAnnotation lets a Secret require a role other than admin to reveal its values,
+	// within the limits of the backend's SecretRevealPolicy.
+	secretRevealRoleAnnotation = "kubrowser.io/reveal-role"
+
+	// defaultSecretRevealRole is the role required to reveal secret values without the annotation.
+	defaultSecretRevealRole = auth.RoleAdmin
+
+	kindConfigMap = "configmap"
+	kindSecret    = "secret"
+)
+
+// SecretRevealPolicy limits how far the reveal-role annotation may lower the role required to reveal
+// Secret values. Anyone able to edit a Secret can set the annotation, so it is only honoured in
+// namespaces the backend trusts, and never below MinRole. The zero value ignores the annotation.
+type SecretRevealPolicy struct {
+	// Namespaces lists the namespaces whose Secrets may lower the required role.
+	Namespaces []string
+	// MinRole is the lowest role the annotation may name.
+	MinRole auth.Role
+}
+
+// SetSecretRevealPolicy sets the policy applied to the reveal-role annotation of Secrets.
+func (h *Handlers) SetSecretRevealPolicy(policy SecretRevealPolicy) {
+	h.secretReveal = policy
+}
+
+// roleFor returns the role required to reveal a Secret's values.
+func (p SecretRevealPolicy) roleFor(secret *v1.Secret) auth.Role {
+	role, ok := auth.ParseRole(secret.Annotations[secretRevealRoleAnnotation])
+	if !ok || role.Allows(defaultSecretRevealRole) || p.MinRole == "" {
+		return defaultSecretRevealRole
+	}
+	trusted := false
+	for _, namespace := range p.Namespaces {
+		if namespace == secret.Namespace {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return defaultSecretRevealRole
+	}
+	if !role.Allows(p.MinRole) {
+		return p.MinRole
+	}
+	return role
+}
+
+// HandleListConfigMaps lists ConfigMaps with their keys and the pods that use each one.
+func (h *Handlers) HandleListConfigMaps(c *gin.Context) {
+	namespace := configNamespace(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	list, err := h.podManager.GetClient().CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.logger.WithError(err).WithField("namespace", namespace).Error("Failed to list configmaps")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list configmaps"})
+		return
+	}
+	usage := h.configUsage(ctx, namespace, kindConfigMap)
+
+	items := make([]gin.H, 0, len(list.Items))
+	for i := range list.Items {
+		cm := &list.Items[i]
+		keys := make([]gin.H, 0, len(cm.Data)+len(cm.BinaryData))
+		for key, value := range cm.Data {
+			keys = append(keys, gin.H{"key": key, "size": len(value), "binary": false})
+		}
+		for key, value := range cm.BinaryData {
+			keys = append(keys, gin.H{"key": key, "size": len(value), "binary": true})
+		}
+		sortByKey(keys)
+
+		items = append(items, gin.H{
+			"name":      cm.Name,
+			"namespace": cm.Namespace,
+			"keys":      keys,
+			"immutable": cm.Immutable != nil && *cm.Immutable,
+			"created":   cm.CreationTimestamp.Time,
+			"usedBy":    usageFor(usage, cm.Namespace, cm.Name),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"configmaps": items})
+}
+
+// HandleGetConfigMap returns a ConfigMap's values. Binary data is decoded with its content type
+// sniffed, and returned as text when it is valid UTF-8.
+func (h *Handlers) HandleGetConfigMap(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	cm, err := h.podManager.GetClient().CoreV1().ConfigMaps(namespace).Get(ctx, c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ConfigMap not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get configmap")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get configmap"})
+		return
+	}
+
+	values := make([]gin.H, 0, len(cm.Data)+len(cm.BinaryData))
+	for key, value := range cm.Data {
+		entry := decodedValue([]byte(value))
+		entry["key"] = key
+		values = append(values, entry)
+	}
+	for key, value := range cm.BinaryData {
+		entry := decodedValue(value)
+		entry["key"] = key
+		values = append(values, entry)
+	}
+	sortByKey(values)
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":      cm.Name,
+		"namespace": cm.Namespace,
+		"labels":    cm.Labels,
+		"immutable": cm.Immutable != nil && *cm.Immutable,
+		"created":   cm.CreationTimestamp.Time,
+		"data":      values,
+		"usedBy":    usageFor(h.configUsage(ctx, namespace, kindConfigMap), cm.Namespace, cm.Name),
+	})
+}
+
+// HandleListSecrets lists Secrets with their type and key names. Values are never included.
+func (h *Handlers) HandleListSecrets(c *gin.Context) {
+	namespace := configNamespace(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	list, err := h.podManager.GetClient().CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.logger.WithError(err).WithField("namespace", namespace).Error("Failed to list secrets")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list secrets"})
+		return
+	}
+	usage := h.configUsage(ctx, namespace, kindSecret)
+
+	items := make([]gin.H, 0, len(list.Items))
+	for i := range list.Items {
+		items = append(items, secretSummary(&list.Items[i], usage))
+	}
+	c.JSON(http.StatusOK, gin.H{"secrets": items})
+}
+
+// HandleGetSecret returns a Secret with every value masked; see HandleRevealSecret.
+func (h *Handlers) HandleGetSecret(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	secret, err := h.podManager.GetClient().CoreV1().Secrets(namespace).Get(ctx, c.Param("name"), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Secret not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get secret")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get secret"})
+		return
+	}
+
+	summary := secretSummary(secret, h.configUsage(ctx, namespace, kindSecret))
+	summary["labels"] = secret.Labels
+	revealRole := h.secretReveal.roleFor(secret)
+	summary["revealRole"] = string(revealRole)
+	summary["canReveal"] = auth.RoleFromContext(c).Allows(revealRole)
+	c.JSON(http.StatusOK, summary)
+}
+
+// HandleRevealSecret returns the decoded value of a single Secret key. It requires the admin role,
+// or the role named by the kubrowser.io/reveal-role annotation where the SecretRevealPolicy allows
+// it, and every attempt is audited.
+func (h *Handlers) HandleRevealSecret(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+	key := c.Query("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	secret, err := h.podManager.GetClient().CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Secret not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get secret")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get secret"})
+		return
+	}
+
+	if !h.authorize(c, h.secretReveal.roleFor(secret), "reveal", "secrets", namespace, name) {
+		return
+	}
+
+	value, ok := secret.Data[key]
+	entry := audit.Entry{
+		Action:    "reveal",
+		Resource:  "secrets",
+		Namespace: namespace,
+		Name:      name,
+		Details:   map[string]interface{}{"key": key},
+		Result:    audit.ResultSuccess,
+	}
+	if !ok {
+		entry.Result = audit.ResultFailure
+		entry.Error = "key not found"
+	}
+	h.recordAudit(c, entry)
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Key not found"})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user":      currentUser(c),
+		"namespace": namespace,
+		"secret":    name,
+		"key":       key,
+	}).Info("Secret value revealed")
+
+	result := decodedValue(value)
+	result["key"] = key
+	c.JSON(http.StatusOK, result)
+}
+
+func secretSummary(secret *v1.Secret, usage map[string][]gin.H) gin.H {
+	keys := make([]gin.H, 0, len(secret.Data))
+	for key, value := range secret.Data {
+		keys = append(keys, gin.H{"key": key, "size": len(value), "value": "********"})
+	}
+	sortByKey(keys)
+
+	return gin.H{
+		"name":      secret.Name,
+		"namespace": secret.Namespace,
+		"type":      string(secret.Type),
+		"keys":      keys,
+		"immutable": secret.Immutable != nil && *secret.Immutable,
+		"created":   secret.CreationTimestamp.Time,
+		"usedBy":    usageFor(usage, secret.Namespace, secret.Name),
+	}
+}
+
+// decodedValue describes a value with its sniffed content type. Valid UTF-8 is returned as text,
+// anything else base64 encoded.
+func decodedValue(data []byte) gin.H {
+	result := gin.H{
+		"size":        len(data),
+		"contentType": http.DetectContentType(data),
+	}
+	if utf8.Valid(data) {
+		result["encoding"] = "text"
+		result["value"] = string(data)
+	} else {
+		result["encoding"] = "base64"
+		result["value"] = base64.StdEncoding.EncodeToString(data)
+	}
+	return result
+}
+
+// configUsage indexes which pods in namespace reference ConfigMaps or Secrets (kind), keyed by
+// "namespace/name". Failures are logged and yield an empty index, since usage is informational.
+func (h *Handlers) configUsage(ctx context.Context, namespace, kind string) map[string][]gin.H {
+	usage := make(map[string][]gin.H)
+	pods, err := h.listPods(ctx, namespace, "", "")
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to list pods for config usage")
+		return usage
+	}
+
+	for _, pod := range pods {
+		add := func(name, via, container string) {
+			if name == "" {
+				return
+			}
+			ref := gin.H{"pod": pod.Name, "via": via}
+			if container != "" {
+				ref["container"] = container
+			}
+			key := pod.Namespace + "/" + name
+			usage[key] = append(usage[key], ref)
+		}
+
+		for i := range pod.Spec.Volumes {
+			vol := &pod.Spec.Volumes[i]
+			switch {
+			case kind == kindConfigMap && vol.ConfigMap != nil:
+				add(vol.ConfigMap.Name, "volume:"+vol.Name, "")
+			case kind == kindSecret && vol.Secret != nil:
+				add(vol.Secret.SecretName, "volume:"+vol.Name, "")
+			case vol.Projected != nil:
+				for _, source := range vol.Projected.Sources {
+					if kind == kindConfigMap && source.ConfigMap != nil {
+						add(source.ConfigMap.Name, "volume:"+vol.Name, "")
+					}
+					if kind == kindSecret && source.Secret != nil {
+						add(source.Secret.Name, "volume:"+vol.Name, "")
+					}
+				}
+			}
+		}
+
+		if kind == kindSecret {
+			for _, ref := range pod.Spec.ImagePullSecrets {
+				add(ref.Name, "imagePullSecret", "")
+			}
+		}
+
+		containers := append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for i := range containers {
+			container := &containers[i]
+			for _, from := range container.EnvFrom {
+				if kind == kindConfigMap && from.ConfigMapRef != nil {
+					add(from.ConfigMapRef.Name, "envFrom", container.Name)
+				}
+				if kind == kindSecret && from.SecretRef != nil {
+					add(from.SecretRef.Name, "envFrom", container.Name)
+				}
+			}
+			for _, env := range container.Env {
+				if env.ValueFrom == nil {
+					continue
+				}
+				if kind == kindConfigMap && env.ValueFrom.ConfigMapKeyRef != nil {
+					add(env.ValueFrom.ConfigMapKeyRef.Name, "env:"+env.Name, container.Name)
+				}
+				if kind == kindSecret && env.ValueFrom.SecretKeyRef != nil {
+					add(env.ValueFrom.SecretKeyRef.Name, "env:"+env.Name, container.Name)
+				}
+			}
+		}
+	}
+	return usage
+}
+
+func usageFor(usage map[string][]gin.H, namespace, name string) []gin.H {
+	if refs, ok := usage[namespace+"/"+name]; ok {
+		return refs
+	}
+	return []gin.H{}
+}
+
+func configNamespace(c *gin.Context) string {
+	namespace := c.DefaultQuery("namespace", "default")
+	if namespace == "*" || namespace == "all" {
+		return metav1.NamespaceAll
+	}
+	return namespace
+}
+
+func sortByKey(items []gin.H) {
+	sort.Slice(items, func(i, j int) bool {
+		a, _ := items[i]["key"].(string)
+		b, _ := items[j]["key"].(string)
+		return strings.Compare(a, b) < 0
+	})
+}
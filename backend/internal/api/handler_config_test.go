@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+)
+
+func TestSecretRevealPolicyRoleFor(t *testing.T) {
+	policy := SecretRevealPolicy{Namespaces: []string{"team-a"}, MinRole: auth.RoleOperator}
+	tests := []struct {
+		name       string
+		policy     SecretRevealPolicy
+		namespace  string
+		annotation string
+		want       auth.Role
+	}{
+		{"no annotation", policy, "team-a", "", auth.RoleAdmin},
+		{"invalid annotation", policy, "team-a", "root", auth.RoleAdmin},
+		{"lowered in trusted namespace", policy, "team-a", "operator", auth.RoleOperator},
+		{"clamped to the minimum role", policy, "team-a", "viewer", auth.RoleOperator},
+		{"ignored in untrusted namespace", policy, "team-b", "viewer", auth.RoleAdmin},
+		{"ignored by the zero policy", SecretRevealPolicy{}, "team-a", "viewer", auth.RoleAdmin},
+		{"admin always allowed", SecretRevealPolicy{}, "team-b", "admin", auth.RoleAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: tt.namespace}}
+			if tt.annotation != "" {
+				secret.Annotations = map[string]string{secretRevealRoleAnnotation: tt.annotation}
+			}
+			if got := tt.policy.roleFor(secret); got != tt.want {
+				t.Errorf("roleFor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretSummaryMasksValues(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Type:       v1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"password": []byte("hunter2"),
+			"cert":     {0xff, 0x00, 0xfe},
+		},
+	}
+
+	summary := secretSummary(secret, nil)
+	keys, ok := summary["keys"].([]gin.H)
+	if !ok {
+		t.Fatalf("keys has type %T", summary["keys"])
+	}
+	if len(keys) != 2 || keys[0]["key"] != "cert" || keys[1]["key"] != "password" {
+		t.Fatalf("keys = %v, want cert and password sorted", keys)
+	}
+	for _, key := range keys {
+		if key["value"] != "********" {
+			t.Errorf("key %v value = %v, want it masked", key["key"], key["value"])
+		}
+		if size := key["size"]; size != len(secret.Data[key["key"].(string)]) {
+			t.Errorf("key %v size = %v", key["key"], size)
+		}
+	}
+	if encoded, _ := json.Marshal(summary); strings.Contains(string(encoded), "hunter2") {
+		t.Errorf("summary leaks the secret value: %s", encoded)
+	}
+}
+
+func TestDecodedValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding string
+		value    string
+	}{
+		{"text", []byte("hunter2"), "text", "hunter2"},
+		{"empty", []byte{}, "text", ""},
+		{"binary", []byte{0xff, 0x00, 0xfe}, "base64", "/wD+"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decodedValue(tt.data)
+			if got["encoding"] != tt.encoding || got["value"] != tt.value || got["size"] != len(tt.data) {
+				t.Errorf("decodedValue() = %v, want encoding %s value %q", got, tt.encoding, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// HandleListCronJobs lists CronJobs with their schedule, last schedule time and active jobs.
+func (h *Handlers) HandleListCronJobs(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	if namespace == "*" || namespace == "all" {
+		namespace = metav1.NamespaceAll
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	list, err := h.podManager.GetClient().BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.logger.WithError(err).WithField("namespace", namespace).Error("Failed to list cronjobs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list cronjobs"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(list.Items))
+	for i := range list.Items {
+		items = append(items, cronJobSummary(&list.Items[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"cronjobs": items})
+}
+
+// HandleTriggerCronJob runs a CronJob immediately by creating a Job from its jobTemplate.
+func (h *Handlers) HandleTriggerCronJob(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+	if !h.authorize(c, auth.RoleOperator, "trigger", "cronjobs", namespace, name) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	job, err := k8s.TriggerCronJob(ctx, h.podManager.GetClient(), namespace, name)
+	entry := audit.Entry{Action: "trigger", Resource: "cronjobs", Namespace: namespace, Name: name, Result: audit.ResultSuccess}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	} else {
+		entry.Details = map[string]interface{}{"job": job.Name}
+	}
+	h.recordAudit(c, entry)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "CronJob not found"})
+			return
+		}
+		h.logger.WithError(err).WithField("cronjob", name).Error("Failed to trigger cronjob")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to trigger cronjob"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, jobSummary(job))
+}
+
+// HandleSuspendCronJob stops a CronJob from scheduling new jobs.
+func (h *Handlers) HandleSuspendCronJob(c *gin.Context) {
+	h.setCronJobSuspended(c, "suspend", true)
+}
+
+// HandleResumeCronJob lets a suspended CronJob schedule jobs again.
+func (h *Handlers) HandleResumeCronJob(c *gin.Context) {
+	h.setCronJobSuspended(c, "resume", false)
+}
+
+func (h *Handlers) setCronJobSuspended(c *gin.Context, action string, suspend bool) {
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+	if !h.authorize(c, auth.RoleOperator, action, "cronjobs", namespace, name) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err := k8s.SetCronJobSuspended(ctx, h.podManager.GetClient(), namespace, name, suspend)
+	entry := audit.Entry{Action: action, Resource: "cronjobs", Namespace: namespace, Name: name, Result: audit.ResultSuccess}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	}
+	h.recordAudit(c, entry)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "CronJob not found"})
+			return
+		}
+		h.logger.WithError(err).WithField("cronjob", name).Errorf("Failed to %s cronjob", action)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " cronjob"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "name": name, "namespace": namespace, "suspended": suspend})
+}
+
+// HandleCronJobHistory returns the Jobs a CronJob created, newest first, with their outcome,
+// duration and pods, each with a link to its logs.
+func (h *Handlers) HandleCronJobHistory(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+	client := h.podManager.GetClient()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	cronJob, err := client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "CronJob not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get cronjob")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cronjob"})
+		return
+	}
+
+	jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list jobs")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list jobs"})
+		return
+	}
+	pods, err := h.listPods(ctx, namespace, "", "")
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to list pods for job history")
+	}
+
+	owned := make([]*batchv1.Job, 0)
+	for i := range jobs.Items {
+		if controllerUID(jobs.Items[i].OwnerReferences) == cronJob.UID {
+			owned = append(owned, &jobs.Items[i])
+		}
+	}
+	sort.Slice(owned, func(i, j int) bool {
+		return owned[j].CreationTimestamp.Before(&owned[i].CreationTimestamp)
+	})
+
+	succeeded, failed := 0, 0
+	history := make([]gin.H, 0, len(owned))
+	for _, job := range owned {
+		status := jobStatus(job)
+		switch status {
+		case "Complete":
+			succeeded++
+		case "Failed":
+			failed++
+		}
+
+		jobPods := make([]gin.H, 0)
+		for _, pod := range pods {
+			if controllerUID(pod.OwnerReferences) == job.UID {
+				jobPods = append(jobPods, jobPodSummary(pod))
+			}
+		}
+
+		entry := jobSummary(job)
+		entry["status"] = status
+		entry["manual"] = job.Annotations[k8s.CronJobInstantiateAnnotation] == "manual"
+		entry["pods"] = jobPods
+		history = append(history, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cronjob":   cronJobSummary(cronJob),
+		"succeeded": succeeded,
+		"failed":    failed,
+		"jobs":      history,
+	})
+}
+
+// jobStatus returns Complete, Failed, Suspended or Running from a Job's conditions.
+func jobStatus(job *batchv1.Job) string {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != v1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return "Complete"
+		case batchv1.JobFailed:
+			return "Failed"
+		case batchv1.JobSuspended:
+			return "Suspended"
+		}
+	}
+	return "Running"
+}
+
+func jobPodSummary(pod *v1.Pod) gin.H {
+	summary := gin.H{
+		"name":  pod.Name,
+		"phase": string(pod.Status.Phase),
+		"logs":  fmt.Sprintf("/api/v1/pods/%s/logs?namespace=%s&follow=false", url.PathEscape(pod.Name), url.QueryEscape(pod.Namespace)),
+	}
+	for i := range pod.Status.ContainerStatuses {
+		if terminated := pod.Status.ContainerStatuses[i].State.Terminated; terminated != nil {
+			summary["exitCode"] = terminated.ExitCode
+			summary["reason"] = terminated.Reason
+		}
+	}
+	return summary
+}
+
+func cronJobSummary(cj *batchv1.CronJob) gin.H {
+	active := make([]string, 0, len(cj.Status.Active))
+	for _, ref := range cj.Status.Active {
+		active = append(active, ref.Name)
+	}
+
+	var lastSchedule, lastSuccessful *time.Time
+	if cj.Status.LastScheduleTime != nil {
+		lastSchedule = &cj.Status.LastScheduleTime.Time
+	}
+	if cj.Status.LastSuccessfulTime != nil {
+		lastSuccessful = &cj.Status.LastSuccessfulTime.Time
+	}
+
+	summary := gin.H{
+		"name":               cj.Name,
+		"namespace":          cj.Namespace,
+		"schedule":           cj.Spec.Schedule,
+		"suspended":          cj.Spec.Suspend != nil && *cj.Spec.Suspend,
+		"concurrencyPolicy":  string(cj.Spec.ConcurrencyPolicy),
+		"lastScheduleTime":   lastSchedule,
+		"lastSuccessfulTime": lastSuccessful,
+		"active":             active,
+		"labels":             cj.Labels,
+		"created":            cj.CreationTimestamp.Time,
+	}
+	if cj.Spec.TimeZone != nil {
+		summary["timeZone"] = *cj.Spec.TimeZone
+	}
+	if cj.Spec.SuccessfulJobsHistoryLimit != nil {
+		summary["successfulJobsHistoryLimit"] = *cj.Spec.SuccessfulJobsHistoryLimit
+	}
+	if cj.Spec.FailedJobsHistoryLimit != nil {
+		summary["failedJobsHistoryLimit"] = *cj.Spec.FailedJobsHistoryLimit
+	}
+	return summary
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HandleDescribePod returns the full detail of a pod, similar to kubectl describe pod,
+// including its events sorted by time.
+func (h *Handlers) HandleDescribePod(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	podName := c.Param("name")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	pod, err := h.podManager.GetClient().CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pod not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get pod")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pod"})
+		return
+	}
+
+	// Events are best-effort; a pod without events is still worth describing.
+	events, err := h.listEventsFor(ctx, namespace, "Pod", podName)
+	if err != nil {
+		h.logger.WithError(err).WithField("pod", podName).Warn("Failed to list pod events")
+		events = nil
+	}
+	sortEvents(events)
+
+	c.JSON(http.StatusOK, describePod(pod, events))
+}
+
+// describePod converts a pod and its events into a detailed JSON description.
+func describePod(pod *v1.Pod, events []*v1.Event) gin.H {
+	var startTime *time.Time
+	if pod.Status.StartTime != nil {
+		startTime = &pod.Status.StartTime.Time
+	}
+
+	return gin.H{
+		"name":              pod.Name,
+		"namespace":         pod.Namespace,
+		"uid":               pod.UID,
+		"status":            string(pod.Status.Phase),
+		"reason":            pod.Status.Reason,
+		"message":           pod.Status.Message,
+		"node":              pod.Spec.NodeName,
+		"podIP":             pod.Status.PodIP,
+		"hostIP":            pod.Status.HostIP,
+		"qosClass":          string(pod.Status.QOSClass),
+		"priorityClassName": pod.Spec.PriorityClassName,
+		"serviceAccount":    pod.Spec.ServiceAccountName,
+		"restartPolicy":     string(pod.Spec.RestartPolicy),
+		"createdAt":         pod.CreationTimestamp.Time,
+		"startTime":         startTime,
+		"age":               time.Since(pod.CreationTimestamp.Time).Round(time.Second).String(),
+		"restarts":          getRestartCount(pod),
+		"labels":            pod.Labels,
+		"annotations":       pod.Annotations,
+		"nodeSelector":      pod.Spec.NodeSelector,
+		"ownerReferences":   describeOwnerReferences(pod.OwnerReferences),
+		"conditions":        describePodConditions(pod.Status.Conditions),
+		"initContainers":    describeContainers(pod.Spec.InitContainers, pod.Status.InitContainerStatuses),
+		"containers":        describeContainers(pod.Spec.Containers, pod.Status.ContainerStatuses),
+		"volumes":           describeVolumes(pod.Spec.Volumes),
+		"tolerations":       describeTolerations(pod.Spec.Tolerations),
+		"events":            eventSummaries(events),
+	}
+}
+
+func describeOwnerReferences(refs []metav1.OwnerReference) []gin.H {
+	result := make([]gin.H, 0, len(refs))
+	for i := range refs {
+		ref := &refs[i]
+		result = append(result, gin.H{
+			"apiVersion": ref.APIVersion,
+			"kind":       ref.Kind,
+			"name":       ref.Name,
+			"uid":        ref.UID,
+			"controller": ref.Controller != nil && *ref.Controller,
+		})
+	}
+	return result
+}
+
+func describePodConditions(conditions []v1.PodCondition) []gin.H {
+	result := make([]gin.H, 0, len(conditions))
+	for i := range conditions {
+		condition := &conditions[i]
+		result = append(result, gin.H{
+			"type":               string(condition.Type),
+			"status":             string(condition.Status),
+			"reason":             condition.Reason,
+			"message":            condition.Message,
+			"lastTransitionTime": condition.LastTransitionTime.Time,
+		})
+	}
+	return result
+}
+
+func describeContainers(containers []v1.Container, statuses []v1.ContainerStatus) []gin.H {
+	result := make([]gin.H, 0, len(containers))
+	for i := range containers {
+		container := &containers[i]
+
+		var status *v1.ContainerStatus
+		for j := range statuses {
+			if statuses[j].Name == container.Name {
+				status = &statuses[j]
+				break
+			}
+		}
+
+		ports := make([]string, 0, len(container.Ports))
+		for _, port := range container.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", port.ContainerPort, port.Protocol))
+		}
+
+		mounts := make([]gin.H, 0, len(container.VolumeMounts))
+		for _, mount := range container.VolumeMounts {
+			mounts = append(mounts, gin.H{
+				"name":      mount.Name,
+				"mountPath": mount.MountPath,
+				"subPath":   mount.SubPath,
+				"readOnly":  mount.ReadOnly,
+			})
+		}
+
+		desc := gin.H{
+			"name":           container.Name,
+			"image":          container.Image,
+			"command":        container.Command,
+			"args":           container.Args,
+			"ports":          ports,
+			"volumeMounts":   mounts,
+			"requests":       resourceListStrings(container.Resources.Requests),
+			"limits":         resourceListStrings(container.Resources.Limits),
+			"livenessProbe":  describeProbe(container.LivenessProbe),
+			"readinessProbe": describeProbe(container.ReadinessProbe),
+			"startupProbe":   describeProbe(container.StartupProbe),
+			"ready":          false,
+			"restartCount":   int32(0),
+			"state":          gin.H{"state": "Unknown"},
+			"lastState":      nil,
+		}
+
+		if status != nil {
+			desc["imageID"] = status.ImageID
+			desc["containerID"] = status.ContainerID
+			desc["ready"] = status.Ready
+			desc["restartCount"] = status.RestartCount
+			desc["state"] = describeContainerState(&status.State)
+			if status.LastTerminationState.Terminated != nil {
+				desc["lastState"] = describeContainerState(&status.LastTerminationState)
+			}
+		}
+
+		result = append(result, desc)
+	}
+	return result
+}
+
+func describeContainerState(state *v1.ContainerState) gin.H {
+	switch {
+	case state.Running != nil:
+		return gin.H{
+			"state":     "Running",
+			"startedAt": state.Running.StartedAt.Time,
+		}
+	case state.Waiting != nil:
+		return gin.H{
+			"state":   "Waiting",
+			"reason":  state.Waiting.Reason,
+			"message": state.Waiting.Message,
+		}
+	case state.Terminated != nil:
+		return gin.H{
+			"state":      "Terminated",
+			"reason":     state.Terminated.Reason,
+			"message":    state.Terminated.Message,
+			"exitCode":   state.Terminated.ExitCode,
+			"signal":     state.Terminated.Signal,
+			"startedAt":  state.Terminated.StartedAt.Time,
+			"finishedAt": state.Terminated.FinishedAt.Time,
+		}
+	default:
+		return gin.H{"state": "Unknown"}
+	}
+}
+
+// describeProbe formats a probe the way kubectl describe does, e.g.
+// "http-get http://:8080/healthz delay=0s timeout=1s period=10s #success=1 #failure=3".
+func describeProbe(probe *v1.Probe) gin.H {
+	if probe == nil {
+		return nil
+	}
+
+	handler := "unknown"
+	switch {
+	case probe.HTTPGet != nil:
+		handler = fmt.Sprintf("http-get %s://%s:%s%s",
+			strings.ToLower(string(probe.HTTPGet.Scheme)), probe.HTTPGet.Host, probe.HTTPGet.Port.String(), probe.HTTPGet.Path)
+	case probe.TCPSocket != nil:
+		handler = fmt.Sprintf("tcp-socket %s:%s", probe.TCPSocket.Host, probe.TCPSocket.Port.String())
+	case probe.Exec != nil:
+		handler = fmt.Sprintf("exec %v", probe.Exec.Command)
+	case probe.GRPC != nil:
+		handler = fmt.Sprintf("grpc <pod>:%d", probe.GRPC.Port)
+	}
+
+	return gin.H{
+		"handler":             handler,
+		"initialDelaySeconds": probe.InitialDelaySeconds,
+		"timeoutSeconds":      probe.TimeoutSeconds,
+		"periodSeconds":       probe.PeriodSeconds,
+		"successThreshold":    probe.SuccessThreshold,
+		"failureThreshold":    probe.FailureThreshold,
+		"summary": fmt.Sprintf("%s delay=%ds timeout=%ds period=%ds #success=%d #failure=%d",
+			handler, probe.InitialDelaySeconds, probe.TimeoutSeconds, probe.PeriodSeconds,
+			probe.SuccessThreshold, probe.FailureThreshold),
+	}
+}
+
+func describeVolumes(volumes []v1.Volume) []gin.H {
+	result := make([]gin.H, 0, len(volumes))
+	for i := range volumes {
+		volume := &volumes[i]
+		volumeType, source := describeVolumeSource(&volume.VolumeSource)
+		result = append(result, gin.H{
+			"name":   volume.Name,
+			"type":   volumeType,
+			"source": source,
+		})
+	}
+	return result
+}
+
+func describeVolumeSource(source *v1.VolumeSource) (volumeType, detail string) {
+	switch {
+	case source.PersistentVolumeClaim != nil:
+		return "PersistentVolumeClaim", source.PersistentVolumeClaim.ClaimName
+	case source.ConfigMap != nil:
+		return "ConfigMap", source.ConfigMap.Name
+	case source.Secret != nil:
+		return "Secret", source.Secret.SecretName
+	case source.EmptyDir != nil:
+		return "EmptyDir", string(source.EmptyDir.Medium)
+	case source.HostPath != nil:
+		return "HostPath", source.HostPath.Path
+	case source.Projected != nil:
+		return "Projected", fmt.Sprintf("%d sources", len(source.Projected.Sources))
+	case source.DownwardAPI != nil:
+		return "DownwardAPI", ""
+	case source.NFS != nil:
+		return "NFS", fmt.Sprintf("%s:%s", source.NFS.Server, source.NFS.Path)
+	case source.CSI != nil:
+		return "CSI", source.CSI.Driver
+	case source.Ephemeral != nil:
+		return "Ephemeral", ""
+	default:
+		return "Other", ""
+	}
+}
+
+func describeTolerations(tolerations []v1.Toleration) []gin.H {
+	result := make([]gin.H, 0, len(tolerations))
+	for i := range tolerations {
+		toleration := &tolerations[i]
+		desc := gin.H{
+			"key":      toleration.Key,
+			"operator": string(toleration.Operator),
+			"value":    toleration.Value,
+			"effect":   string(toleration.Effect),
+		}
+		if toleration.TolerationSeconds != nil {
+			desc["tolerationSeconds"] = *toleration.TolerationSeconds
+		}
+		result = append(result, desc)
+	}
+	return result
+}
+
+func resourceListStrings(list v1.ResourceList) map[string]string {
+	result := make(map[string]string, len(list))
+	for name, quantity := range list {
+		result[string(name)] = quantity.String()
+	}
+	return result
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// eventFilter narrows an event listing by involved object, type and reason.
+type eventFilter struct {
+	kind   string
+	name   string
+	typ    string
+	reason string
+}
+
+func (f eventFilter) matches(event *v1.Event) bool {
+	if f.kind != "" && !strings.EqualFold(event.InvolvedObject.Kind, f.kind) {
+		return false
+	}
+	if f.name != "" && event.InvolvedObject.Name != f.name {
+		return false
+	}
+	if f.typ != "" && !strings.EqualFold(event.Type, f.typ) {
+		return false
+	}
+	if f.reason != "" && !strings.EqualFold(event.Reason, f.reason) {
+		return false
+	}
+	return true
+}
+
+// HandleListEvents lists events in a namespace, optionally filtered by involved object, type and reason.
+// The involved object can be given as kind and name parameters or as involvedObject=Kind/name.
+func (h *Handlers) HandleListEvents(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	if namespace == "*" || namespace == "all" {
+		namespace = metav1.NamespaceAll
+	}
+
+	filter := eventFilter{
+		kind:   c.Query("kind"),
+		name:   c.Query("name"),
+		typ:    c.Query("type"),
+		reason: c.Query("reason"),
+	}
+	if involved := c.Query("involvedObject"); involved != "" {
+		kind, name, ok := strings.Cut(involved, "/")
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "involvedObject must be in the form Kind/name"})
+			return
+		}
+		filter.kind = kind
+		filter.name = name
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	events, err := h.listEvents(ctx, namespace)
+	if err != nil {
+		h.logger.WithError(err).WithField("namespace", namespace).Error("Failed to list events")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list events"})
+		return
+	}
+
+	matched := make([]*v1.Event, 0, len(events))
+	for _, event := range events {
+		if filter.matches(event) {
+			matched = append(matched, event)
+		}
+	}
+	sortEvents(matched)
+
+	h.logger.WithFields(logrus.Fields{
+		"namespace":    namespace,
+		"events_count": len(matched),
+	}).Debug("Listed events")
+
+	c.JSON(http.StatusOK, gin.H{"events": eventSummaries(matched)})
+}
+
+// listEvents lists events from the informer cache, falling back to the API server until the cache has synced.
+func (h *Handlers) listEvents(ctx context.Context, namespace string) ([]*v1.Event, error) {
+	if h.cache != nil && h.cache.IsSynced("events") {
+		return h.cache.ListEvents(namespace)
+	}
+
+	list, err := h.podManager.GetClient().CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return eventPointers(list.Items), nil
+}
+
+// listEventsFor lists events for a single involved object.
+func (h *Handlers) listEventsFor(ctx context.Context, namespace, kind, name string) ([]*v1.Event, error) {
+	if h.cache != nil && h.cache.IsSynced("events") {
+		return h.cache.ListEventsFor(namespace, kind, name)
+	}
+
+	selector := fields.Set{
+		"involvedObject.kind": kind,
+		"involvedObject.name": name,
+	}.AsSelector().String()
+	list, err := h.podManager.GetClient().CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: selector,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return eventPointers(list.Items), nil
+}
+
+// eventTime returns the most relevant timestamp of an event.
+func eventTime(event *v1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// sortEvents sorts events from oldest to newest.
+func sortEvents(events []*v1.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+}
+
+func eventSummaries(events []*v1.Event) []gin.H {
+	result := make([]gin.H, 0, len(events))
+	for _, event := range events {
+		source := event.Source.Component
+		if source == "" {
+			source = event.ReportingController
+		}
+
+		result = append(result, gin.H{
+			"type":    event.Type,
+			"reason":  event.Reason,
+			"message": event.Message,
+			"count":   event.Count,
+			"source":  source,
+			"time":    eventTime(event),
+			"age":     time.Since(eventTime(event)).Round(time.Second).String(),
+			"involvedObject": gin.H{
+				"kind":      event.InvolvedObject.Kind,
+				"name":      event.InvolvedObject.Name,
+				"namespace": event.InvolvedObject.Namespace,
+			},
+		})
+	}
+	return result
+}
+
+func eventPointers(items []v1.Event) []*v1.Event {
+	events := make([]*v1.Event, 0, len(items))
+	for i := range items {
+		events = append(events, &items[i])
+	}
+	return events
+}
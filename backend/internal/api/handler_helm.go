@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/helm"
+)
+
+// HandleListHelmReleases lists the latest revision of each Helm release in a namespace or all namespaces.
+func (h *Handlers) HandleListHelmReleases(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	if namespace == "*" || namespace == "all" {
+		namespace = metav1.NamespaceAll
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	releases, err := helm.ListReleases(ctx, h.podManager.GetClient(), namespace)
+	if err != nil {
+		h.logger.WithError(err).WithField("namespace", namespace).Error("Failed to list Helm releases")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list Helm releases"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(releases))
+	for _, rel := range releases {
+		items = append(items, helmReleaseSummary(rel))
+	}
+	c.JSON(http.StatusOK, gin.H{"releases": items})
+}
+
+// HandleHelmReleaseHistory returns the revision history of a release, oldest first, like helm history.
+func (h *Handlers) HandleHelmReleaseHistory(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	revisions, err := helm.History(ctx, h.podManager.GetClient(), namespace, c.Param("name"))
+	if err != nil {
+		h.helmError(c, err)
+		return
+	}
+
+	history := make([]gin.H, 0, len(revisions))
+	for _, rel := range revisions {
+		history = append(history, helmReleaseSummary(rel))
+	}
+	c.JSON(http.StatusOK, gin.H{"name": c.Param("name"), "namespace": namespace, "history": history})
+}
+
+// HandleGetHelmRevision returns the values and rendered manifest of one revision. This requires the
+// operator role. Values and the Secrets in the manifest may hold credentials, so they are masked
+// unless the caller may reveal secrets, and revealing them is audited.
+func (h *Handlers) HandleGetHelmRevision(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+	if !h.authorize(c, auth.RoleOperator, "get", "helm-releases", namespace, name) {
+		return
+	}
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revision must be a positive integer"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	rel, err := helm.GetRevision(ctx, h.podManager.GetClient(), namespace, name, revision)
+	if err != nil {
+		h.helmError(c, err)
+		return
+	}
+
+	redact := !auth.RoleFromContext(c).Allows(defaultSecretRevealRole)
+	manifest, values, computedValues := rel.Manifest, rel.Config, rel.ComputedValues()
+	if redact {
+		manifest = helm.RedactSecrets(manifest)
+		values = helm.RedactValues(values)
+		computedValues = helm.RedactValues(computedValues)
+	} else {
+		h.recordHelmReveal(c, namespace, name, map[string]interface{}{"revision": revision})
+	}
+
+	hooks := make([]gin.H, 0, len(rel.Hooks))
+	for _, hook := range rel.Hooks {
+		hooks = append(hooks, gin.H{"name": hook.Name, "kind": hook.Kind, "path": hook.Path, "events": hook.Events})
+	}
+
+	summary := helmReleaseSummary(rel)
+	summary["values"] = values
+	summary["computedValues"] = computedValues
+	summary["redacted"] = redact
+	summary["manifest"] = manifest
+	summary["resources"] = helm.SplitManifest(rel.Manifest)
+	summary["notes"] = rel.Info.Notes
+	summary["hooks"] = hooks
+	c.JSON(http.StatusOK, summary)
+}
+
+// HandleDiffHelmRevisions compares two revisions of a release given as from and to, defaulting to
+// the previous and latest revision.
+func (h *Handlers) HandleDiffHelmRevisions(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+	if !h.authorize(c, auth.RoleOperator, "diff", "helm-releases", namespace, name) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	revisions, err := helm.History(ctx, h.podManager.GetClient(), namespace, name)
+	if err != nil {
+		h.helmError(c, err)
+		return
+	}
+	byVersion := make(map[int]*helm.Release, len(revisions))
+	for _, rel := range revisions {
+		byVersion[rel.Version] = rel
+	}
+
+	latest := revisions[len(revisions)-1].Version
+	to, err := strconv.Atoi(c.DefaultQuery("to", strconv.Itoa(latest)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a revision number"})
+		return
+	}
+	from, err := strconv.Atoi(c.DefaultQuery("from", strconv.Itoa(to-1)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a revision number"})
+		return
+	}
+
+	fromRel, okFrom := byVersion[from]
+	toRel, okTo := byVersion[to]
+	if !okFrom || !okTo {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	redact := !auth.RoleFromContext(c).Allows(defaultSecretRevealRole)
+	if !redact {
+		h.recordHelmReveal(c, namespace, name, map[string]interface{}{"from": from, "to": to})
+	}
+	c.JSON(http.StatusOK, helm.Diff(fromRel, toRel, redact))
+}
+
+// recordHelmReveal audits a caller being shown the unmasked values and Secrets of a release.
+func (h *Handlers) recordHelmReveal(c *gin.Context, namespace, name string, details map[string]interface{}) {
+	h.recordAudit(c, audit.Entry{
+		Action:    "reveal",
+		Resource:  "helm-releases",
+		Namespace: namespace,
+		Name:      name,
+		Details:   details,
+		Result:    audit.ResultSuccess,
+	})
+	h.logger.WithFields(logrus.Fields{
+		"user":      currentUser(c),
+		"namespace": namespace,
+		"release":   name,
+	}).Info("Helm release values revealed")
+}
+
+func (h *Handlers) helmError(c *gin.Context, err error) {
+	if stderrors.Is(err, helm.ErrReleaseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Release not found"})
+		return
+	}
+	h.logger.WithError(err).Error("Failed to read Helm release")
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read Helm release"})
+}
+
+func helmReleaseSummary(rel *helm.Release) gin.H {
+	return gin.H{
+		"name":        rel.Name,
+		"namespace":   rel.Namespace,
+		"revision":    rel.Version,
+		"status":      rel.Info.Status,
+		"chart":       rel.ChartRef(),
+		"chartName":   rel.Chart.Metadata.Name,
+		"appVersion":  rel.Chart.Metadata.AppVersion,
+		"description": rel.Info.Description,
+		"updated":     rel.Info.LastDeployed,
+		"icon":        rel.Chart.Metadata.Icon,
+	}
+}
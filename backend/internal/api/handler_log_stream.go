@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kubrowser/kubrowser-backend/internal/logs"
+)
+
+// logStreamBuffer is the number of events buffered between the tailer and a slow client.
+const logStreamBuffer = 256
+
+// HandleStreamLogs follows the logs of every container in the pods matching a label selector, like
+// stern. Pods created later (for example during a rollout) are picked up and deleted pods are dropped.
+// Query parameters: namespace, selector, container (a regular expression), initContainers, tail,
+// sinceSeconds, maxSources and format (ndjson, the default, or sse). The filter parameters of
+// HandlePodLogs apply per source, and matching lines carry the running match count.
+func (h *Handlers) HandleStreamLogs(c *gin.Context) {
+	opts := logs.TailOptions{
+		Namespace:   c.DefaultQuery("namespace", "default"),
+		Selector:    c.Query("selector"),
+		Container:   c.Query("container"),
+		IncludeInit: c.Query("initContainers") == "true",
+	}
+	if opts.Selector == "" {
+		opts.Selector = c.Query("labelSelector")
+	}
+
+	tailLines := int64(10)
+	if tail := c.Query("tail"); tail != "" {
+		parsed, err := strconv.ParseInt(tail, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tail must be an integer"})
+			return
+		}
+		tailLines = parsed
+	}
+	if tailLines >= 0 {
+		opts.TailLines = &tailLines
+	}
+	if since := c.Query("sinceSeconds"); since != "" {
+		seconds, err := strconv.ParseInt(since, 10, 64)
+		if err != nil || seconds <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sinceSeconds must be a positive integer"})
+			return
+		}
+		opts.SinceSeconds = &seconds
+	}
+	if maxSources := c.Query("maxSources"); maxSources != "" {
+		parsed, err := strconv.Atoi(maxSources)
+		if err != nil || parsed <= 0 || parsed > logs.DefaultMaxSources {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "maxSources must be between 1 and " + strconv.Itoa(logs.DefaultMaxSources)})
+			return
+		}
+		opts.MaxSources = parsed
+	}
+
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Active() {
+		opts.Filter = filter
+	}
+
+	format := c.DefaultQuery("format", "ndjson")
+	if format != "ndjson" && format != "sse" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be ndjson or sse"})
+		return
+	}
+
+	if err := opts.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"namespace": opts.Namespace,
+		"selector":  opts.Selector,
+		"container": opts.Container,
+		"user":      currentUser(c),
+	}).Info("Starting aggregated log stream")
+
+	if format == "sse" {
+		startSSE(c)
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+	}
+
+	ctx := c.Request.Context()
+	events := make(chan logs.Event, logStreamBuffer)
+	tailer := logs.NewTailer(h.podManager.GetClient(), opts)
+	go func() {
+		if err := tailer.Run(ctx, events); err != nil {
+			h.logger.WithError(err).Warn("Aggregated log stream stopped")
+		}
+	}()
+
+	keepalive := time.NewTicker(watchKeepaliveInterval)
+	defer keepalive.Stop()
+
+	encoder := json.NewEncoder(c.Writer)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepalive.C:
+			if format == "sse" {
+				if err := writeSSEComment(c, "keepalive"); err != nil {
+					return
+				}
+			}
+		case ev := <-events:
+			var err error
+			if format == "sse" {
+				err = writeSSE(c, "", ev.Type, ev)
+			} else {
+				err = encoder.Encode(ev)
+				c.Writer.Flush()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
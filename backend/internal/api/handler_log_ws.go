@@ -0,0 +1,448 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubrowser/kubrowser-backend/internal/logs"
+)
+
+const (
+	// logQueueLimit bounds the frames queued for a slow or paused client.
+	logQueueLimit = 1000
+
+	logWriteTimeout = 10 * time.Second
+	logPingInterval = 30 * time.Second
+)
+
+// Frame types sent by the server in addition to the logs.Event types.
+const (
+	logFrameDropped = "dropped"
+	logFramePaused  = "paused"
+	logFrameResumed = "resumed"
+	logFrameFilter  = "filter"
+	logFrameError   = "error"
+	logFrameDone    = "done"
+)
+
+// logClientFrame is a control message from the client: pause, resume or filter.
+type logClientFrame struct {
+	Type   string         `json:"type"`
+	Filter *logFilterSpec `json:"filter,omitempty"`
+}
+
+// logFilterSpec is the JSON form of the filter query parameters accepted by HandlePodLogs.
+type logFilterSpec struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+	Regex   bool     `json:"regex"`
+	Level   string   `json:"level"`
+	Before  int      `json:"before"`
+	After   int      `json:"after"`
+}
+
+func (s *logFilterSpec) matcher() (*logs.Matcher, error) {
+	opts := logs.FilterOptions{
+		Include: nonEmpty(s.Include),
+		Exclude: nonEmpty(s.Exclude),
+		Regex:   s.Regex,
+		Before:  s.Before,
+		After:   s.After,
+	}
+	if s.Level != "" {
+		level, err := logs.ParseLevel(s.Level)
+		if err != nil {
+			return nil, err
+		}
+		opts.MinLevel = level
+	}
+	if opts.Before > maxLogContextLines || opts.After > maxLogContextLines {
+		return nil, fmt.Errorf("context lines must not exceed %d", maxLogContextLines)
+	}
+	return logs.NewMatcher(opts)
+}
+
+// queuedFrame is a frame waiting to be written. Only log lines may be dropped.
+type queuedFrame struct {
+	data      interface{}
+	droppable bool
+}
+
+// logFrameQueue is a bounded queue between the log sources and the WebSocket writer. When it is
+// full, or while the client is paused, the oldest lines are dropped and reported as a single count.
+// If it fills with frames that can't be dropped, it overflows and the connection is closed.
+type logFrameQueue struct {
+	mu         sync.Mutex
+	frames     []queuedFrame
+	dropped    int
+	paused     bool
+	overflowed bool
+	notify     chan struct{}
+}
+
+func newLogFrameQueue() *logFrameQueue {
+	return &logFrameQueue{notify: make(chan struct{}, 1)}
+}
+
+func (q *logFrameQueue) push(data interface{}, droppable bool) {
+	q.mu.Lock()
+	if q.overflowed {
+		q.mu.Unlock()
+		return
+	}
+	if len(q.frames) >= logQueueLimit && !q.dropOldestLine() {
+		q.overflowed = true
+		q.frames = nil
+		q.mu.Unlock()
+		q.wake()
+		return
+	}
+	q.frames = append(q.frames, queuedFrame{data: data, droppable: droppable})
+	q.mu.Unlock()
+	q.wake()
+}
+
+// dropOldestLine removes the oldest droppable frame, reporting whether there was one.
+func (q *logFrameQueue) dropOldestLine() bool {
+	for i := range q.frames {
+		if q.frames[i].droppable {
+			q.frames = append(q.frames[:i], q.frames[i+1:]...)
+			q.dropped++
+			return true
+		}
+	}
+	return false
+}
+
+func (q *logFrameQueue) setPaused(paused bool) {
+	q.mu.Lock()
+	q.paused = paused
+	q.mu.Unlock()
+	q.wake()
+}
+
+// take returns the queued frames and the number of lines dropped since the last call. While paused
+// it only returns frames that can't be dropped. overflowed reports that the queue overflowed and the
+// stream can't continue.
+func (q *logFrameQueue) take() (frames []interface{}, dropped int, overflowed bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.overflowed {
+		return nil, 0, true
+	}
+
+	result := make([]interface{}, 0, len(q.frames))
+	if q.paused {
+		kept := q.frames[:0]
+		for _, f := range q.frames {
+			if f.droppable {
+				kept = append(kept, f)
+			} else {
+				result = append(result, f.data)
+			}
+		}
+		q.frames = kept
+		return result, 0, false
+	}
+
+	for _, f := range q.frames {
+		result = append(result, f.data)
+	}
+	q.frames = q.frames[:0]
+	dropped = q.dropped
+	q.dropped = 0
+	return result, dropped, false
+}
+
+func (q *logFrameQueue) wake() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// HandleLogsWebSocket streams logs over a WebSocket using JSON frames. With a pod name
+// (/pods/:name/logs/ws) it follows that pod's container, or every container with all-containers=true;
+// otherwise it follows all pods matching selector like HandleStreamLogs. Query parameters are the same
+// as for the HTTP endpoints. The client may send {"type":"pause"}, {"type":"resume"} and
+// {"type":"filter","filter":{...}} at any time. The server sends line frames, an end frame when a
+// container terminates, a dropped frame counting lines discarded for a slow or paused client, and a
+// done frame once every followed container has ended.
+func (h *Handlers) HandleLogsWebSocket(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	podName := c.Param("name")
+
+	req, err := parseLogRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.options.Follow = true
+	req.options.Timestamps = true
+
+	var tailOpts logs.TailOptions
+	var pod *v1.Pod
+	var containers []string
+	if podName != "" {
+		pod, err = h.podManager.GetClient().CoreV1().Pods(namespace).Get(c.Request.Context(), podName, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Pod not found"})
+				return
+			}
+			h.logger.WithError(err).Error("Failed to get pod")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pod"})
+			return
+		}
+		if containers, err = logContainers(pod, req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		tailOpts = logs.TailOptions{
+			Namespace:    namespace,
+			Selector:     c.Query("selector"),
+			Container:    c.Query("container"),
+			IncludeInit:  c.Query("initContainers") == "true",
+			TailLines:    req.options.TailLines,
+			SinceSeconds: req.options.SinceSeconds,
+		}
+		if err := tailOpts.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to upgrade to WebSocket")
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	h.logger.WithFields(logrus.Fields{
+		"namespace": namespace,
+		"pod":       podName,
+		"selector":  tailOpts.Selector,
+		"user":      currentUser(c),
+	}).Info("Starting WebSocket log stream")
+
+	events := make(chan logs.Event, logStreamBuffer)
+	sourcesDone := make(chan struct{})
+	if pod != nil {
+		go func() {
+			h.followPodLogs(ctx, pod, containers, req.options, events)
+			close(sourcesDone)
+		}()
+	} else {
+		go func() {
+			if err := logs.NewTailer(h.podManager.GetClient(), tailOpts).Run(ctx, events); err != nil {
+				h.logger.WithError(err).Warn("WebSocket log stream stopped")
+			}
+		}()
+	}
+
+	queue := newLogFrameQueue()
+	filters := make(chan *logs.Matcher, 1)
+	writerDone := make(chan struct{})
+	go h.writeLogFrames(ctx, cancel, ws, queue, writerDone)
+	go h.readLogFrames(ctx, cancel, ws, queue, filters)
+
+	filter := req.filter
+	windows := make(map[logs.Source]*logs.Window)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case filter = <-filters:
+			windows = make(map[logs.Source]*logs.Window)
+			queue.push(gin.H{"type": logFrameFilter, "active": filter.Active()}, false)
+		case <-sourcesDone:
+			// Drain lines still buffered before announcing the end of the stream.
+			for len(events) > 0 {
+				h.queueLogEvent(queue, <-events, filter, windows)
+			}
+			queue.push(gin.H{"type": logFrameDone}, false)
+			sourcesDone = nil
+		case ev := <-events:
+			h.queueLogEvent(queue, ev, filter, windows)
+		case <-writerDone:
+			return
+		}
+	}
+}
+
+// queueLogEvent applies the current filter to line events, keeping one context window per source.
+func (h *Handlers) queueLogEvent(queue *logFrameQueue, ev logs.Event, filter *logs.Matcher, windows map[logs.Source]*logs.Window) {
+	if ev.Type != logs.EventLine {
+		queue.push(ev, false)
+		return
+	}
+	if !filter.Active() || ev.Source == nil {
+		queue.push(ev, true)
+		return
+	}
+
+	window, ok := windows[*ev.Source]
+	if !ok {
+		window = filter.NewWindow()
+		windows[*ev.Source] = window
+	}
+	filtered, _ := window.Process(ev)
+	for _, out := range filtered {
+		queue.push(out, true)
+	}
+}
+
+// followPodLogs follows the given containers of a pod, sending an end event as each one terminates.
+func (h *Handlers) followPodLogs(ctx context.Context, pod *v1.Pod, containers []string, options v1.PodLogOptions, events chan<- logs.Event) {
+	var wg sync.WaitGroup
+	for _, container := range containers {
+		wg.Add(1)
+		go func(container string) {
+			defer wg.Done()
+			source := logs.NewSource(pod, container)
+			send := func(ev logs.Event) bool {
+				select {
+				case events <- ev:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			}
+
+			stream, err := h.openLogStream(ctx, pod.Namespace, pod.Name, container, options)
+			if err != nil {
+				if ctx.Err() == nil {
+					send(logs.Event{Type: logs.EventError, Source: source, Time: time.Now(), Line: err.Error()})
+				}
+				return
+			}
+			defer stream.Close()
+
+			scanner := bufio.NewScanner(stream)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				ts, line := logs.SplitTimestamp(scanner.Text())
+				if !send(logs.Event{Type: logs.EventLine, Source: source, Time: ts, Line: line}) {
+					return
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			end := logs.Event{Type: logs.EventEnd, Source: source, Time: time.Now()}
+			current, err := h.podManager.GetClient().CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if err == nil {
+				end.Reason, end.ExitCode = logs.TerminationDetails(current, container)
+			}
+			send(end)
+		}(container)
+	}
+	wg.Wait()
+}
+
+// writeLogFrames writes queued frames to the WebSocket, preceded by a dropped frame when lines were lost.
+func (h *Handlers) writeLogFrames(ctx context.Context, cancel context.CancelFunc, ws *websocket.Conn, queue *logFrameQueue,
+	done chan<- struct{}) {
+	defer close(done)
+	defer cancel()
+
+	ping := time.NewTicker(logPingInterval)
+	defer ping.Stop()
+
+	write := func(frame interface{}) bool {
+		_ = ws.SetWriteDeadline(time.Now().Add(logWriteTimeout))
+		return ws.WriteJSON(frame) == nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(logWriteTimeout)); err != nil {
+				return
+			}
+		case <-queue.notify:
+			frames, dropped, overflowed := queue.take()
+			if overflowed {
+				h.logger.Warn("WebSocket log stream queue overflowed, closing")
+				_ = ws.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "log stream queue overflowed"), time.Now().Add(logWriteTimeout))
+				return
+			}
+			if dropped > 0 && !write(gin.H{"type": logFrameDropped, "count": dropped}) {
+				return
+			}
+			for _, frame := range frames {
+				if !write(frame) {
+					return
+				}
+				if f, ok := frame.(gin.H); ok && f["type"] == logFrameDone {
+					_ = ws.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseNormalClosure, "log stream ended"), time.Now().Add(logWriteTimeout))
+					return
+				}
+			}
+		}
+	}
+}
+
+// readLogFrames handles pause, resume and filter frames from the client.
+func (h *Handlers) readLogFrames(ctx context.Context, cancel context.CancelFunc, ws *websocket.Conn, queue *logFrameQueue,
+	filters chan<- *logs.Matcher) {
+	defer cancel()
+
+	for {
+		var frame logClientFrame
+		if err := ws.ReadJSON(&frame); err != nil {
+			if ctx.Err() == nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.logger.WithError(err).Debug("WebSocket log stream read failed")
+			}
+			return
+		}
+
+		switch frame.Type {
+		case "pause":
+			queue.setPaused(true)
+			queue.push(gin.H{"type": logFramePaused}, false)
+		case "resume":
+			queue.setPaused(false)
+			queue.push(gin.H{"type": logFrameResumed}, false)
+		case "filter":
+			spec := frame.Filter
+			if spec == nil {
+				spec = &logFilterSpec{}
+			}
+			matcher, err := spec.matcher()
+			if err != nil {
+				queue.push(gin.H{"type": logFrameError, "error": err.Error()}, false)
+				continue
+			}
+			select {
+			case filters <- matcher:
+			case <-ctx.Done():
+				return
+			}
+		default:
+			queue.push(gin.H{"type": logFrameError, "error": fmt.Sprintf("unknown frame type %q", frame.Type)}, false)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import "testing"
+
+func TestLogFrameQueueDropsOldestLines(t *testing.T) {
+	q := newLogFrameQueue()
+	q.push("control", false)
+	for i := 0; i < logQueueLimit; i++ {
+		q.push(i, true)
+	}
+
+	frames, dropped, overflowed := q.take()
+	if overflowed || dropped != 1 || len(frames) != logQueueLimit {
+		t.Fatalf("take() = %d frames, %d dropped, overflowed %v", len(frames), dropped, overflowed)
+	}
+	// The control frame is kept and the oldest line is dropped.
+	if frames[0] != "control" || frames[1] != 1 {
+		t.Errorf("first frames = %v, %v", frames[0], frames[1])
+	}
+}
+
+func TestLogFrameQueuePaused(t *testing.T) {
+	q := newLogFrameQueue()
+	q.setPaused(true)
+	q.push("line", true)
+	q.push("paused", false)
+
+	frames, _, _ := q.take()
+	if len(frames) != 1 || frames[0] != "paused" {
+		t.Fatalf("take() while paused = %v, want only the control frame", frames)
+	}
+	q.setPaused(false)
+	if frames, _, _ := q.take(); len(frames) != 1 || frames[0] != "line" {
+		t.Errorf("take() after resume = %v", frames)
+	}
+}
+
+func TestLogFrameQueueOverflows(t *testing.T) {
+	q := newLogFrameQueue()
+	for i := 0; i < logQueueLimit; i++ {
+		q.push(i, false)
+	}
+	q.push("one too many", false)
+	q.push("after overflow", true)
+
+	frames, _, overflowed := q.take()
+	if !overflowed || frames != nil {
+		t.Errorf("take() = %d frames, overflowed %v, want overflow", len(frames), overflowed)
+	}
+	if len(q.frames) != 0 {
+		t.Errorf("queue holds %d frames after overflowing", len(q.frames))
+	}
+}
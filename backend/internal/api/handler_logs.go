@@ -0,0 +1,484 @@
+package api
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubrowser/kubrowser-backend/internal/logs"
+)
+
+const (
+	// defaultContainerAnnotation selects the default container for logs and exec, as in kubectl.
+	defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+	// logMatchesTrailer reports the number of filter matches once a filtered log response ends.
+	logMatchesTrailer = "X-Log-Matches"
+
+	// maxLogContextLines caps the before/after context a client may request.
+	maxLogContextLines = 100
+)
+
+// logRequest holds the parsed query parameters of a log request.
+type logRequest struct {
+	container     string
+	allContainers bool
+	download      bool
+	options       v1.PodLogOptions
+	filter        *logs.Matcher
+}
+
+// parseLogRequest parses the log query parameters shared by the log endpoints.
+func parseLogRequest(c *gin.Context) (*logRequest, error) {
+	req := &logRequest{
+		container:     c.Query("container"),
+		allContainers: c.Query("all-containers") == "true" || c.Query("allContainers") == "true",
+		download:      c.Query("download") == "true",
+	}
+
+	req.options.Follow = c.DefaultQuery("follow", "true") == "true"
+	req.options.Previous = c.Query("previous") == "true"
+	req.options.Timestamps = c.Query("timestamps") == "true"
+
+	tailLines := int64(100)
+	if tail := c.DefaultQuery("tail", "100"); tail != "" {
+		if parsed, err := strconv.ParseInt(tail, 10, 64); err == nil {
+			tailLines = parsed
+		}
+	}
+	// A negative tail means the whole log, as with kubectl --tail=-1.
+	if tailLines >= 0 {
+		req.options.TailLines = &tailLines
+	}
+
+	if since := c.Query("sinceSeconds"); since != "" {
+		seconds, err := strconv.ParseInt(since, 10, 64)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("sinceSeconds must be a positive integer")
+		}
+		req.options.SinceSeconds = &seconds
+	}
+	if since := c.Query("sinceTime"); since != "" {
+		if req.options.SinceSeconds != nil {
+			return nil, fmt.Errorf("only one of sinceSeconds or sinceTime may be set")
+		}
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, fmt.Errorf("sinceTime must be an RFC3339 timestamp")
+		}
+		sinceTime := metav1.NewTime(t)
+		req.options.SinceTime = &sinceTime
+	}
+	if limit := c.Query("limitBytes"); limit != "" {
+		bytes, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || bytes <= 0 {
+			return nil, fmt.Errorf("limitBytes must be a positive integer")
+		}
+		req.options.LimitBytes = &bytes
+	}
+
+	// Downloads are a snapshot of the log, never a live stream.
+	if req.download {
+		req.options.Follow = false
+	}
+
+	filter, err := parseLogFilter(c)
+	if err != nil {
+		return nil, err
+	}
+	req.filter = filter
+
+	return req, nil
+}
+
+// parseLogFilter parses the server-side filter parameters: include and exclude (repeatable),
+// regex=true to treat them as RE2 expressions, level (the minimum severity of JSON and logfmt lines),
+// and before, after or context for the number of context lines around each match.
+func parseLogFilter(c *gin.Context) (*logs.Matcher, error) {
+	opts := logs.FilterOptions{
+		Include: nonEmpty(c.QueryArray("include")),
+		Exclude: nonEmpty(c.QueryArray("exclude")),
+		Regex:   c.Query("regex") == "true",
+	}
+
+	if level := c.Query("level"); level != "" {
+		parsed, err := logs.ParseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		opts.MinLevel = parsed
+	}
+
+	contextLines := func(name string, fallback int) (int, error) {
+		value := c.Query(name)
+		if value == "" {
+			return fallback, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > maxLogContextLines {
+			return 0, fmt.Errorf("%s must be between 0 and %d", name, maxLogContextLines)
+		}
+		return n, nil
+	}
+	both, err := contextLines("context", 0)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Before, err = contextLines("before", both); err != nil {
+		return nil, err
+	}
+	if opts.After, err = contextLines("after", both); err != nil {
+		return nil, err
+	}
+
+	return logs.NewMatcher(opts)
+}
+
+func nonEmpty(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// logContainers returns the containers whose logs were requested. Without an explicit container it
+// honors the kubectl default-container annotation before falling back to the first container.
+func logContainers(pod *v1.Pod, req *logRequest) ([]string, error) {
+	if req.allContainers {
+		names := make([]string, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+		for i := range pod.Spec.InitContainers {
+			names = append(names, pod.Spec.InitContainers[i].Name)
+		}
+		for i := range pod.Spec.Containers {
+			names = append(names, pod.Spec.Containers[i].Name)
+		}
+		return names, nil
+	}
+
+	if req.container != "" {
+		for i := range pod.Spec.InitContainers {
+			if pod.Spec.InitContainers[i].Name == req.container {
+				return []string{req.container}, nil
+			}
+		}
+		for i := range pod.Spec.Containers {
+			if pod.Spec.Containers[i].Name == req.container {
+				return []string{req.container}, nil
+			}
+		}
+		return nil, fmt.Errorf("container %q not found in pod %s", req.container, pod.Name)
+	}
+
+	if name := pod.Annotations[defaultContainerAnnotation]; name != "" {
+		return []string{name}, nil
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("pod %s has no containers", pod.Name)
+	}
+	return []string{pod.Spec.Containers[0].Name}, nil
+}
+
+// logOutput writes log data either directly to the response or through gzip for downloads.
+type logOutput struct {
+	c      *gin.Context
+	w      io.Writer
+	gz     *gzip.Writer
+	filter *logs.Matcher
+	lock   sync.Mutex
+}
+
+// newLogOutput writes the response headers and returns the writer for log data.
+func newLogOutput(c *gin.Context, podName string, req *logRequest) *logOutput {
+	out := &logOutput{c: c, w: c.Writer}
+	if req.filter.Active() {
+		c.Header("Trailer", logMatchesTrailer)
+		out.filter = req.filter
+	}
+
+	if req.download {
+		container := req.container
+		if req.allContainers || container == "" {
+			container = "all"
+		}
+		filename := fmt.Sprintf("%s-%s-%s.log.gz", podName, container, time.Now().Format("20060102-150405"))
+		c.Header("Content-Type", "application/gzip")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+		out.gz = gzip.NewWriter(c.Writer)
+		out.w = out.gz
+		return out
+	}
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	return out
+}
+
+// Write writes p and flushes it to the client unless the output is being compressed.
+func (o *logOutput) Write(p []byte) (int, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	n, err := o.w.Write(p)
+	if err == nil && o.gz == nil {
+		o.c.Writer.Flush()
+	}
+	return n, err
+}
+
+// Close finishes the gzip stream, if any, and reports the filter match count as a trailer.
+func (o *logOutput) Close() {
+	if o.gz != nil {
+		_ = o.gz.Close()
+	}
+	if o.filter != nil {
+		o.c.Writer.Header().Set(logMatchesTrailer, strconv.FormatInt(o.filter.Matches(), 10))
+	}
+}
+
+// openLogStream opens the log stream of a single container.
+func (h *Handlers) openLogStream(ctx context.Context, namespace, podName, container string,
+	options v1.PodLogOptions) (io.ReadCloser, error) {
+	options.Container = container
+	return h.podManager.GetClient().CoreV1().Pods(namespace).GetLogs(podName, &options).Stream(ctx)
+}
+
+// copyLogStream copies a log stream to out until it ends or the context is canceled. A followed
+// stream ends when the container exits, so EOF always finishes the copy.
+func (h *Handlers) copyLogStream(ctx context.Context, out io.Writer, stream io.Reader) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := stream.Read(buf)
+		if n > 0 {
+			if _, writeErr := out.Write(buf[:n]); writeErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				h.logger.WithError(err).Error("Error reading logs")
+			}
+			return
+		}
+	}
+}
+
+// filterLogStream copies the lines of a log stream that pass the filter, with their context lines,
+// separating non-adjacent groups with "--" like grep.
+func (h *Handlers) filterLogStream(ctx context.Context, out io.Writer, stream io.Reader, timestamps bool, filter *logs.Matcher) {
+	window := filter.NewWindow()
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		ev := logs.Event{Type: logs.EventLine, Line: scanner.Text()}
+		if timestamps {
+			// Match against the message, not the timestamp prefix.
+			ev.Time, ev.Line = logs.SplitTimestamp(ev.Line)
+		}
+		events, gap := window.Process(ev)
+		if gap {
+			if _, err := io.WriteString(out, "--\n"); err != nil {
+				return
+			}
+		}
+		for _, e := range events {
+			line := e.Line + "\n"
+			if timestamps {
+				line = e.Time.Format(time.RFC3339Nano) + " " + line
+			}
+			if _, err := io.WriteString(out, line); err != nil {
+				return
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		h.logger.WithError(err).Error("Error reading logs")
+	}
+}
+
+// logLine is a single line read from one container's log.
+type logLine struct {
+	container string
+	timestamp time.Time
+	text      string
+}
+
+// containerLogStream is the log stream of one container, or the error opening it.
+type containerLogStream struct {
+	container string
+	stream    io.ReadCloser
+	err       error
+}
+
+// openContainerLogStreams opens the log stream of every container, with the timestamps
+// streamAllContainerLogs merges lines by. It returns an error if no stream could be opened.
+func (h *Handlers) openContainerLogStreams(ctx context.Context, namespace, podName string, containers []string,
+	options v1.PodLogOptions) ([]containerLogStream, error) {
+	options.Timestamps = true
+
+	streams := make([]containerLogStream, 0, len(containers))
+	var lastErr error
+	opened := 0
+	for _, container := range containers {
+		stream, err := h.openLogStream(ctx, namespace, podName, container, options)
+		if err != nil {
+			// Containers that never started (e.g. init containers of a running pod with previous=true) have no log.
+			h.logger.WithError(err).WithField("container", container).Debug("Failed to stream container logs")
+			lastErr = err
+		} else {
+			opened++
+		}
+		streams = append(streams, containerLogStream{container: container, stream: stream, err: err})
+	}
+	if opened == 0 {
+		return nil, lastErr
+	}
+	return streams, nil
+}
+
+// streamAllContainerLogs interleaves the logs of several containers, prefixing each line with the
+// container name. When following, lines are written as they arrive; otherwise they are merged by
+// timestamp as they are read, so no container's log is held in memory.
+// An active filter is applied per container. Containers whose log could not be opened get an error line.
+func (h *Handlers) streamAllContainerLogs(ctx context.Context, out io.Writer, streams []containerLogStream,
+	options v1.PodLogOptions, filter *logs.Matcher) {
+	// Following writes lines as they arrive from any container. Otherwise each container gets its
+	// own channel, so that the lines can be merged by timestamp holding only the next line of each.
+	lines := make(chan logLine, 256)
+	var sources []<-chan logLine
+	var wg sync.WaitGroup
+	for _, s := range streams {
+		if s.err != nil {
+			if _, err := fmt.Fprintf(out, "[%s] error: %v\n", s.container, s.err); err != nil {
+				return
+			}
+			continue
+		}
+		if !options.Follow {
+			source := make(chan logLine, 64)
+			sources = append(sources, source)
+			go func(s containerLogStream) {
+				defer close(source)
+				h.readContainerLines(ctx, s.container, s.stream, source)
+			}(s)
+			continue
+		}
+		wg.Add(1)
+		go func(s containerLogStream) {
+			defer wg.Done()
+			h.readContainerLines(ctx, s.container, s.stream, lines)
+		}(s)
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	format := func(line logLine) string {
+		// Streams always carry timestamps, to merge lines in order; they are only shown when requested.
+		if options.Timestamps {
+			return fmt.Sprintf("[%s] %s %s\n", line.container, line.timestamp.Format(time.RFC3339Nano), line.text)
+		}
+		return fmt.Sprintf("[%s] %s\n", line.container, line.text)
+	}
+
+	// With a filter, each container keeps its own context window so context lines stay with their match.
+	windows := make(map[string]*logs.Window)
+	write := func(line logLine) error {
+		if !filter.Active() {
+			_, err := io.WriteString(out, format(line))
+			return err
+		}
+
+		window, ok := windows[line.container]
+		if !ok {
+			window = filter.NewWindow()
+			windows[line.container] = window
+		}
+		events, _ := window.Process(logs.Event{Type: logs.EventLine, Time: line.timestamp, Line: line.text})
+		for _, ev := range events {
+			if _, err := io.WriteString(out, format(logLine{container: line.container, timestamp: ev.Time, text: ev.Line})); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if options.Follow {
+		for line := range lines {
+			if err := write(line); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	mergeLogLines(sources, write)
+}
+
+// mergeLogLines writes the lines of several timestamp-ordered sources in timestamp order, reading
+// the next line of a source only once its previous one is written. Ties go to the earlier source.
+func mergeLogLines(sources []<-chan logLine, write func(logLine) error) {
+	heads := make([]logLine, len(sources))
+	open := make([]bool, len(sources))
+	for i, source := range sources {
+		heads[i], open[i] = <-source
+	}
+	for {
+		next := -1
+		for i := range sources {
+			if open[i] && (next < 0 || heads[i].timestamp.Before(heads[next].timestamp)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return
+		}
+		if err := write(heads[next]); err != nil {
+			return
+		}
+		heads[next], open[next] = <-sources[next]
+	}
+}
+
+// readContainerLines reads a container's log line by line, splitting off the RFC3339 timestamp
+// prefix, and closes the stream.
+func (h *Handlers) readContainerLines(ctx context.Context, container string, stream io.ReadCloser, lines chan<- logLine) {
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := logLine{container: container, text: scanner.Text()}
+		if ts, rest, ok := strings.Cut(line.text, " "); ok {
+			if parsed, parseErr := time.Parse(time.RFC3339Nano, ts); parseErr == nil {
+				line.timestamp = parsed
+				line.text = rest
+			}
+		}
+
+		select {
+		case lines <- line:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
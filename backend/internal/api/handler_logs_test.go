@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestStreamAllContainerLogs(t *testing.T) {
+	h := &Handlers{logger: logrus.New()}
+	streams := []containerLogStream{
+		{container: "init", err: errors.New("container \"init\" is waiting to start")},
+		{container: "app", stream: io.NopCloser(strings.NewReader(
+			"2024-01-01T00:00:01Z started\n2024-01-01T00:00:03Z ready\n"))},
+		{container: "proxy", stream: io.NopCloser(strings.NewReader("2024-01-01T00:00:02Z listening\n"))},
+	}
+
+	var out strings.Builder
+	h.streamAllContainerLogs(context.Background(), &out, streams, v1.PodLogOptions{}, nil)
+
+	want := "[init] error: container \"init\" is waiting to start\n" +
+		"[app] started\n" +
+		"[proxy] listening\n" +
+		"[app] ready\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// maxManifestSize bounds the manifests accepted by the dry-run and apply endpoints.
+const maxManifestSize = 1 << 20
+
+// HandleGetManifest returns an object as YAML (the default) or JSON with format=json, with
+// metadata.managedFields stripped.
+func (h *Handlers) HandleGetManifest(c *gin.Context) {
+	resource, ok := h.resolveResource(c, "get")
+	if !ok {
+		return
+	}
+	format := c.DefaultQuery("format", "yaml")
+	if format != "yaml" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be yaml or json"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	obj, err := h.resources.Get(ctx, resource, resourceNamespace(c), c.Param("name"))
+	if err != nil {
+		h.resourceError(c, err, "get")
+		return
+	}
+	k8s.StripManagedFields(obj)
+
+	data, err := json.MarshalIndent(obj.Object, "", "  ")
+	if err == nil && format == "yaml" {
+		data, err = yaml.JSONToYAML(data)
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to encode manifest")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode manifest"})
+		return
+	}
+
+	contentType := "application/yaml"
+	if format == "json" {
+		contentType = "application/json"
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// HandleDryRunManifest submits an edited manifest (YAML or JSON) with a server-side apply dry run and
+// returns the field changes against the live object without persisting anything.
+func (h *Handlers) HandleDryRunManifest(c *gin.Context) {
+	h.applyManifest(c, true)
+}
+
+// HandleApplyManifest applies an edited manifest with server-side apply under the kubrowser field
+// manager. Fields owned by other managers are reported as conflicts with 409; force=true takes them over.
+func (h *Handlers) HandleApplyManifest(c *gin.Context) {
+	h.applyManifest(c, false)
+}
+
+// operatorApplyResources lists the namespaced resources operators may apply, keyed by group and
+// resource. Everything else, custom resources included, requires admin: a kind that runs pods can
+// mount any Secret of its namespace or run as any of its service accounts, which would bypass the
+// Secret reveal role, and Kubrowser can't tell which custom resources do.
+var operatorApplyResources = map[string]map[string]bool{
+	"": {
+		"configmaps": true,
+		"services":   true,
+	},
+	"networking.k8s.io": {
+		"ingresses":       true,
+		"networkpolicies": true,
+	},
+	"autoscaling": {
+		"horizontalpodautoscalers": true,
+	},
+	"policy": {
+		"poddisruptionbudgets": true,
+	},
+}
+
+// manifestApplyRole returns the role required to apply a resource in namespace. Operators may apply
+// the namespaced configuration in operatorApplyResources outside the backend's own namespace, which
+// holds the session pods, home volumes, pod template and profiles; everything else requires admin.
+func manifestApplyRole(resource k8s.APIResource, namespace, backendNamespace string) auth.Role {
+	if !resource.Namespaced || !operatorApplyResources[resource.Group][resource.Resource] {
+		return auth.RoleAdmin
+	}
+	if backendNamespace != "" && namespace == backendNamespace {
+		return auth.RoleAdmin
+	}
+	return auth.RoleOperator
+}
+
+func (h *Handlers) applyManifest(c *gin.Context, dryRun bool) {
+	resource, ok := h.resolveResource(c, "patch")
+	if !ok {
+		return
+	}
+	namespace := resourceNamespace(c)
+	name := c.Param("name")
+
+	backendNamespace := ""
+	if h.podManager != nil {
+		backendNamespace = h.podManager.Namespace()
+	}
+	required := manifestApplyRole(resource, namespace, backendNamespace)
+	action := "apply"
+	if dryRun {
+		action = "dry-run"
+	}
+	if !h.authorize(c, required, action, resourceLabel(resource), namespace, name) {
+		return
+	}
+
+	obj, err := readManifest(c.Request.Body, resource, namespace, name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	force := c.Query("force") == "true"
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	live, err := h.resources.Get(ctx, resource, namespace, name)
+	if err != nil && !errors.IsNotFound(err) {
+		h.resourceError(c, err, "get")
+		return
+	}
+	if errors.IsNotFound(err) {
+		live = nil
+	}
+
+	result, err := h.resources.Apply(ctx, resource, namespace, obj, dryRun, force)
+	if !dryRun {
+		entry := audit.Entry{
+			Action:    "apply",
+			Resource:  resourceLabel(resource),
+			Namespace: namespace,
+			Name:      name,
+			Details:   map[string]interface{}{"force": force, "created": live == nil},
+			Result:    audit.ResultSuccess,
+		}
+		if err != nil {
+			entry.Result = audit.ResultFailure
+			entry.Error = err.Error()
+		}
+		h.recordAudit(c, entry)
+	}
+
+	if err != nil {
+		if conflicts := k8s.ApplyConflicts(err); conflicts != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":     "Apply conflicts with fields owned by other managers; retry with force=true to take ownership",
+				"conflicts": conflicts,
+			})
+			return
+		}
+		if errors.IsConflict(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "The object has been modified; reload it and apply your changes again"})
+			return
+		}
+		h.resourceError(c, err, action)
+		return
+	}
+
+	changes := k8s.DiffObjects(live, result)
+	k8s.StripManagedFields(result)
+	c.JSON(http.StatusOK, gin.H{
+		"dryRun":  dryRun,
+		"created": live == nil,
+		"changed": len(changes) > 0,
+		"diff":    changes,
+		"object":  result,
+	})
+}
+
+// readManifest decodes a YAML or JSON manifest and checks that it matches the resource, namespace
+// and name of the request. Status and managed fields are dropped since apply must not set them.
+func readManifest(body io.Reader, resource k8s.APIResource, namespace, name string) (*unstructured.Unstructured, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if len(data) > maxManifestSize {
+		return nil, fmt.Errorf("manifest exceeds %d bytes", maxManifestSize)
+	}
+
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(jsonData); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	gv := schema.GroupVersion{Group: resource.Group, Version: resource.Version}
+	if obj.GetAPIVersion() != gv.String() || obj.GetKind() != resource.Kind {
+		return nil, fmt.Errorf("manifest must be %s %s", gv.String(), resource.Kind)
+	}
+	if obj.GetName() != name {
+		return nil, fmt.Errorf("manifest name %q does not match %q", obj.GetName(), name)
+	}
+	if resource.Namespaced {
+		if namespace == "" {
+			return nil, fmt.Errorf("namespace is required for namespaced resources")
+		}
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		} else if obj.GetNamespace() != namespace {
+			return nil, fmt.Errorf("manifest namespace %q does not match %q", obj.GetNamespace(), namespace)
+		}
+	}
+
+	k8s.StripManagedFields(obj)
+	unstructured.RemoveNestedField(obj.Object, "status")
+	return obj, nil
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+func TestManifestApplyRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		resource  k8s.APIResource
+		namespace string
+		want      auth.Role
+	}{
+		{"configmap", k8s.APIResource{Resource: "configmaps", Namespaced: true}, "apps", auth.RoleOperator},
+		{"node", k8s.APIResource{Resource: "nodes"}, "", auth.RoleAdmin},
+		{"role", k8s.APIResource{Group: "rbac.authorization.k8s.io", Resource: "roles", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"rolebinding", k8s.APIResource{Group: "rbac.authorization.k8s.io", Resource: "rolebindings", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"secret", k8s.APIResource{Resource: "secrets", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"serviceaccount", k8s.APIResource{Resource: "serviceaccounts", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"service", k8s.APIResource{Resource: "services", Namespaced: true}, "apps", auth.RoleOperator},
+		{"pod", k8s.APIResource{Resource: "pods", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"deployment", k8s.APIResource{Group: "apps", Resource: "deployments", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"daemonset", k8s.APIResource{Group: "apps", Resource: "daemonsets", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"job", k8s.APIResource{Group: "batch", Resource: "jobs", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"cronjob", k8s.APIResource{Group: "batch", Resource: "cronjobs", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"ingress", k8s.APIResource{Group: "networking.k8s.io", Resource: "ingresses", Namespaced: true}, "apps", auth.RoleOperator},
+		{"hpa", k8s.APIResource{Group: "autoscaling", Resource: "horizontalpodautoscalers", Namespaced: true}, "apps", auth.RoleOperator},
+		{"persistentvolumeclaim", k8s.APIResource{Resource: "persistentvolumeclaims", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"custom resource", k8s.APIResource{Group: "argoproj.io", Resource: "rollouts", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"unknown core kind", k8s.APIResource{Resource: "widgets", Namespaced: true}, "apps", auth.RoleAdmin},
+		{"backend namespace configmap", k8s.APIResource{Resource: "configmaps", Namespaced: true}, "kubrowser", auth.RoleAdmin},
+		{"backend namespace service", k8s.APIResource{Resource: "services", Namespaced: true}, "kubrowser", auth.RoleAdmin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := manifestApplyRole(tt.resource, tt.namespace, "kubrowser"); got != tt.want {
+				t.Errorf("manifestApplyRole() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubrowser/kubrowser-backend/internal/metrics"
+)
+
+// HandleMetricsHistory returns sampled CPU and memory usage of a node or pod for sparkline charts.
+// Query parameters: kind (node or pod), name, namespace (pods only), window (default 1h) and
+// points (maximum number of downsampled points, default 60).
+func (h *Handlers) HandleMetricsHistory(c *gin.Context) {
+	if h.history == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Metrics history is not enabled"})
+		return
+	}
+
+	kind := c.DefaultQuery("kind", metrics.KindNode)
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	switch kind {
+	case metrics.KindNode:
+	case metrics.KindPod:
+		if !strings.Contains(name, "/") {
+			name = c.DefaultQuery("namespace", "default") + "/" + name
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be node or pod"})
+		return
+	}
+
+	window, err := time.ParseDuration(c.DefaultQuery("window", "1h"))
+	if err != nil || window <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be a positive duration such as 1h"})
+		return
+	}
+	if window > h.history.Retention() {
+		window = h.history.Retention()
+	}
+
+	maxPoints, err := strconv.Atoi(c.DefaultQuery("points", "60"))
+	if err != nil || maxPoints <= 0 {
+		maxPoints = 60
+	}
+
+	points, ok := h.history.History(kind, name, window, maxPoints)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No metrics history for " + kind + " " + name})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kind":   kind,
+		"name":   name,
+		"window": window.String(),
+		"points": points,
+	})
+}
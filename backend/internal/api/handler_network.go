@@ -0,0 +1,530 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// Topology issue severities and types reported by HandleServiceTopology.
+const (
+	issueError   = "error"
+	issueWarning = "warning"
+	issueInfo    = "info"
+
+	issueSelectorMismatch = "selector-mismatch"
+	issueNoEndpoints      = "no-endpoints"
+	issueUnreadyEndpoint  = "unready-endpoint"
+	issuePortNoTarget     = "port-no-target"
+	issueIngressBackend   = "ingress-backend"
+	issueNoSelector       = "no-selector"
+)
+
+// maxNearMissPods caps the pods reported as almost matching a service selector.
+const maxNearMissPods = 5
+
+// HandleListServices lists services in a namespace or all namespaces.
+func (h *Handlers) HandleListServices(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	if namespace == "*" || namespace == "all" {
+		namespace = metav1.NamespaceAll
+	}
+	labelSelector := c.Query("labelSelector")
+	if err := k8s.ValidateSelectors(labelSelector, ""); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	list, err := h.podManager.GetClient().CoreV1().Services(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		h.logger.WithError(err).WithField("namespace", namespace).Error("Failed to list services")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list services"})
+		return
+	}
+
+	services := make([]gin.H, 0, len(list.Items))
+	for i := range list.Items {
+		services = append(services, serviceSummary(&list.Items[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"services": services})
+}
+
+// HandleListIngresses lists ingresses in a namespace or all namespaces.
+func (h *Handlers) HandleListIngresses(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	if namespace == "*" || namespace == "all" {
+		namespace = metav1.NamespaceAll
+	}
+	labelSelector := c.Query("labelSelector")
+	if err := k8s.ValidateSelectors(labelSelector, ""); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	list, err := h.podManager.GetClient().NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		h.logger.WithError(err).WithField("namespace", namespace).Error("Failed to list ingresses")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list ingresses"})
+		return
+	}
+
+	ingresses := make([]gin.H, 0, len(list.Items))
+	for i := range list.Items {
+		ingresses = append(ingresses, ingressSummary(&list.Items[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"ingresses": ingresses})
+}
+
+// HandleServiceTopology resolves Ingress → Service → EndpointSlice → Pod for a service and flags the
+// usual reasons a service returns nothing: a selector that matches no pods, unready endpoints, service
+// ports whose target no pod exposes, and ingress backends pointing at ports the service lacks.
+func (h *Handlers) HandleServiceTopology(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+	client := h.podManager.GetClient()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	svc, err := client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get service"})
+		return
+	}
+
+	issues := make([]gin.H, 0)
+	addIssue := func(severity, typ, message string) {
+		issues = append(issues, gin.H{"severity": severity, "type": typ, "message": message})
+	}
+
+	ingresses, err := client.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to list ingresses for topology")
+		ingresses = &networkingv1.IngressList{}
+	}
+	ingressRoutes := serviceIngressRoutes(ingresses.Items, svc, addIssue)
+
+	if svc.Spec.Type == v1.ServiceTypeExternalName {
+		addIssue(issueInfo, issueNoSelector, fmt.Sprintf("ExternalName service resolves to %s; no endpoints are involved", svc.Spec.ExternalName))
+		c.JSON(http.StatusOK, gin.H{
+			"service":        serviceSummary(svc),
+			"ingresses":      ingressRoutes,
+			"endpointSlices": []gin.H{},
+			"pods":           []gin.H{},
+			"issues":         issues,
+		})
+		return
+	}
+
+	slices, err := client.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: svc.Name}).String(),
+	})
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{"service": name, "namespace": namespace}).Error("Failed to list endpoint slices")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list endpoint slices"})
+		return
+	}
+
+	// Index endpoints by target pod to tell which selected pods are serving.
+	endpointReady := make(map[string]bool)
+	endpointCount, readyCount := 0, 0
+	sliceSummaries := make([]gin.H, 0, len(slices.Items))
+	for i := range slices.Items {
+		slice := &slices.Items[i]
+		sliceSummaries = append(sliceSummaries, endpointSliceSummary(slice))
+		for j := range slice.Endpoints {
+			ep := &slice.Endpoints[j]
+			endpointCount++
+			ready := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+			if ready {
+				readyCount++
+			}
+			if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+				endpointReady[ep.TargetRef.Name] = endpointReady[ep.TargetRef.Name] || ready
+			}
+		}
+	}
+
+	var selected []*v1.Pod
+	if len(svc.Spec.Selector) == 0 {
+		addIssue(issueInfo, issueNoSelector, "Service has no selector; its endpoints are managed outside Kubernetes")
+	} else {
+		all, listErr := h.listPods(ctx, namespace, "", "")
+		if listErr != nil {
+			h.logger.WithError(listErr).Error("Failed to list pods for topology")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list pods"})
+			return
+		}
+
+		selector := labels.SelectorFromSet(svc.Spec.Selector)
+		nearMisses := make([]gin.H, 0)
+		for _, pod := range all {
+			if selector.Matches(labels.Set(pod.Labels)) {
+				selected = append(selected, pod)
+			} else if missing := selectorMismatch(svc.Spec.Selector, pod.Labels); missing != nil && len(nearMisses) < maxNearMissPods {
+				nearMisses = append(nearMisses, gin.H{"pod": pod.Name, "mismatchedLabels": missing})
+			}
+		}
+
+		if len(selected) == 0 {
+			issue := gin.H{
+				"severity": issueError,
+				"type":     issueSelectorMismatch,
+				"message":  fmt.Sprintf("No pods match selector %s", selector.String()),
+			}
+			if len(nearMisses) > 0 {
+				issue["nearMisses"] = nearMisses
+			}
+			issues = append(issues, issue)
+		}
+	}
+
+	if endpointCount == 0 && len(selected) > 0 {
+		addIssue(issueError, issueNoEndpoints, "Pods match the selector but the service has no endpoints")
+	} else if endpointCount > 0 && readyCount == 0 {
+		addIssue(issueError, issueNoEndpoints, fmt.Sprintf("None of the %d endpoints are ready", endpointCount))
+	}
+
+	podSummaries := make([]gin.H, 0, len(selected))
+	for _, pod := range selected {
+		ready, inEndpoints := endpointReady[pod.Name]
+		podSummaries = append(podSummaries, gin.H{
+			"name":        pod.Name,
+			"phase":       string(pod.Status.Phase),
+			"ip":          pod.Status.PodIP,
+			"node":        pod.Spec.NodeName,
+			"ready":       podReady(pod),
+			"inEndpoints": inEndpoints,
+			"serving":     ready,
+		})
+		if !ready && pod.DeletionTimestamp == nil {
+			addIssue(issueWarning, issueUnreadyEndpoint, fmt.Sprintf("Pod %s matches the selector but is not a ready endpoint (%s)",
+				pod.Name, podNotReadyReason(pod)))
+		}
+	}
+
+	for i := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[i]
+		if len(selected) > 0 && !portHasTarget(port, selected) {
+			addIssue(issueError, issuePortNoTarget, fmt.Sprintf("Port %s targets %s, which no selected pod exposes",
+				servicePortName(port), port.TargetPort.String()))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"service":        serviceSummary(svc),
+		"ingresses":      ingressRoutes,
+		"endpointSlices": sliceSummaries,
+		"pods":           podSummaries,
+		"issues":         issues,
+	})
+}
+
+// serviceIngressRoutes returns the ingress rules that route to svc, flagging backends that name
+// a port the service does not have.
+func serviceIngressRoutes(ingresses []networkingv1.Ingress, svc *v1.Service, addIssue func(severity, typ, message string)) []gin.H {
+	routes := make([]gin.H, 0)
+	check := func(ing *networkingv1.Ingress, host, path string, backend *networkingv1.IngressBackend) {
+		if backend == nil || backend.Service == nil || backend.Service.Name != svc.Name {
+			return
+		}
+		route := gin.H{"ingress": ing.Name, "host": host, "path": path, "port": ingressBackendPort(backend.Service.Port)}
+		if !serviceHasPort(svc, backend.Service.Port) {
+			route["portFound"] = false
+			addIssue(issueError, issueIngressBackend, fmt.Sprintf("Ingress %s routes %s%s to port %s, which service %s does not expose",
+				ing.Name, host, path, ingressBackendPort(backend.Service.Port), svc.Name))
+		} else {
+			route["portFound"] = true
+		}
+		routes = append(routes, route)
+	}
+
+	for i := range ingresses {
+		ing := &ingresses[i]
+		check(ing, "*", "", ing.Spec.DefaultBackend)
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			host := rule.Host
+			if host == "" {
+				host = "*"
+			}
+			for j := range rule.HTTP.Paths {
+				check(ing, host, rule.HTTP.Paths[j].Path, &rule.HTTP.Paths[j].Backend)
+			}
+		}
+	}
+	return routes
+}
+
+// selectorMismatch returns the selector labels a pod lacks or has different values for, or nil when
+// the pod shares none of the selector's labels and so is unrelated.
+func selectorMismatch(selector, podLabels map[string]string) gin.H {
+	mismatched := gin.H{}
+	shared := 0
+	for key, want := range selector {
+		got, ok := podLabels[key]
+		switch {
+		case !ok:
+			mismatched[key] = gin.H{"want": want, "got": nil}
+		case got != want:
+			mismatched[key] = gin.H{"want": want, "got": got}
+		default:
+			shared++
+		}
+	}
+	if shared == 0 {
+		return nil
+	}
+	return mismatched
+}
+
+// portHasTarget reports whether any pod can receive traffic for a service port. Named target ports
+// must be declared by a container; numeric ones need not be, so they are only checked when the pods
+// declare ports at all.
+func portHasTarget(port *v1.ServicePort, pods []*v1.Pod) bool {
+	target := port.TargetPort
+	if target.Type == intstr.Int && target.IntVal == 0 {
+		target = intstr.FromInt(int(port.Port))
+	}
+
+	declaresPorts := false
+	for _, pod := range pods {
+		for i := range pod.Spec.Containers {
+			for _, cp := range pod.Spec.Containers[i].Ports {
+				declaresPorts = true
+				if cp.Protocol != port.Protocol && !(cp.Protocol == "" && port.Protocol == v1.ProtocolTCP) {
+					continue
+				}
+				if target.Type == intstr.String && cp.Name == target.StrVal {
+					return true
+				}
+				if target.Type == intstr.Int && cp.ContainerPort == target.IntVal {
+					return true
+				}
+			}
+		}
+	}
+	return target.Type == intstr.Int && !declaresPorts
+}
+
+func serviceHasPort(svc *v1.Service, port networkingv1.ServiceBackendPort) bool {
+	for i := range svc.Spec.Ports {
+		if port.Name != "" && svc.Spec.Ports[i].Name == port.Name {
+			return true
+		}
+		if port.Name == "" && svc.Spec.Ports[i].Port == port.Number {
+			return true
+		}
+	}
+	return false
+}
+
+func ingressBackendPort(port networkingv1.ServiceBackendPort) string {
+	if port.Name != "" {
+		return port.Name
+	}
+	return fmt.Sprint(port.Number)
+}
+
+func servicePortName(port *v1.ServicePort) string {
+	if port.Name != "" {
+		return fmt.Sprintf("%s (%d)", port.Name, port.Port)
+	}
+	return fmt.Sprint(port.Port)
+}
+
+func podReady(pod *v1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podNotReadyReason explains why a pod is not ready from its phase, conditions and container states.
+func podNotReadyReason(pod *v1.Pod) string {
+	if pod.Status.Phase != v1.PodRunning {
+		return "phase " + string(pod.Status.Phase)
+	}
+	for i := range pod.Status.ContainerStatuses {
+		status := &pod.Status.ContainerStatuses[i]
+		if status.Ready {
+			continue
+		}
+		if status.State.Waiting != nil {
+			return fmt.Sprintf("container %s waiting: %s", status.Name, status.State.Waiting.Reason)
+		}
+		return fmt.Sprintf("container %s not ready", status.Name)
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady && cond.Status != v1.ConditionTrue && cond.Message != "" {
+			return cond.Message
+		}
+	}
+	return "readiness gates not passed"
+}
+
+func serviceSummary(svc *v1.Service) gin.H {
+	ports := make([]gin.H, 0, len(svc.Spec.Ports))
+	for i := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[i]
+		p := gin.H{
+			"name":       port.Name,
+			"port":       port.Port,
+			"targetPort": port.TargetPort.String(),
+			"protocol":   string(port.Protocol),
+		}
+		if port.NodePort != 0 {
+			p["nodePort"] = port.NodePort
+		}
+		ports = append(ports, p)
+	}
+
+	external := make([]string, 0)
+	external = append(external, svc.Spec.ExternalIPs...)
+	for _, ing := range svc.Status.LoadBalancer.Ingress {
+		if ing.IP != "" {
+			external = append(external, ing.IP)
+		} else if ing.Hostname != "" {
+			external = append(external, ing.Hostname)
+		}
+	}
+
+	return gin.H{
+		"name":         svc.Name,
+		"namespace":    svc.Namespace,
+		"type":         string(svc.Spec.Type),
+		"clusterIP":    svc.Spec.ClusterIP,
+		"externalIPs":  external,
+		"externalName": svc.Spec.ExternalName,
+		"ports":        ports,
+		"selector":     svc.Spec.Selector,
+		"labels":       svc.Labels,
+		"created":      svc.CreationTimestamp.Time,
+	}
+}
+
+func ingressSummary(ing *networkingv1.Ingress) gin.H {
+	rules := make([]gin.H, 0)
+	if backend := ing.Spec.DefaultBackend; backend != nil && backend.Service != nil {
+		rules = append(rules, gin.H{"host": "*", "path": "", "service": backend.Service.Name,
+			"port": ingressBackendPort(backend.Service.Port), "default": true})
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			r := gin.H{"host": rule.Host, "path": path.Path}
+			if path.PathType != nil {
+				r["pathType"] = string(*path.PathType)
+			}
+			if path.Backend.Service != nil {
+				r["service"] = path.Backend.Service.Name
+				r["port"] = ingressBackendPort(path.Backend.Service.Port)
+			}
+			rules = append(rules, r)
+		}
+	}
+
+	tls := make([]gin.H, 0, len(ing.Spec.TLS))
+	for _, t := range ing.Spec.TLS {
+		tls = append(tls, gin.H{"hosts": t.Hosts, "secretName": t.SecretName})
+	}
+
+	addresses := make([]string, 0)
+	for _, lb := range ing.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			addresses = append(addresses, lb.IP)
+		} else if lb.Hostname != "" {
+			addresses = append(addresses, lb.Hostname)
+		}
+	}
+
+	summary := gin.H{
+		"name":      ing.Name,
+		"namespace": ing.Namespace,
+		"rules":     rules,
+		"tls":       tls,
+		"addresses": addresses,
+		"labels":    ing.Labels,
+		"created":   ing.CreationTimestamp.Time,
+	}
+	if ing.Spec.IngressClassName != nil {
+		summary["className"] = *ing.Spec.IngressClassName
+	}
+	return summary
+}
+
+func endpointSliceSummary(slice *discoveryv1.EndpointSlice) gin.H {
+	ports := make([]gin.H, 0, len(slice.Ports))
+	for _, port := range slice.Ports {
+		p := gin.H{}
+		if port.Name != nil {
+			p["name"] = *port.Name
+		}
+		if port.Port != nil {
+			p["port"] = *port.Port
+		}
+		if port.Protocol != nil {
+			p["protocol"] = string(*port.Protocol)
+		}
+		ports = append(ports, p)
+	}
+
+	endpoints := make([]gin.H, 0, len(slice.Endpoints))
+	for i := range slice.Endpoints {
+		ep := &slice.Endpoints[i]
+		e := gin.H{
+			"addresses":   ep.Addresses,
+			"ready":       ep.Conditions.Ready == nil || *ep.Conditions.Ready,
+			"serving":     ep.Conditions.Serving == nil || *ep.Conditions.Serving,
+			"terminating": ep.Conditions.Terminating != nil && *ep.Conditions.Terminating,
+		}
+		if ep.TargetRef != nil {
+			e["targetRef"] = gin.H{"kind": ep.TargetRef.Kind, "name": ep.TargetRef.Name}
+		}
+		if ep.NodeName != nil {
+			e["node"] = *ep.NodeName
+		}
+		endpoints = append(endpoints, e)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return fmt.Sprint(endpoints[i]["addresses"]) < fmt.Sprint(endpoints[j]["addresses"])
+	})
+
+	return gin.H{
+		"name":        slice.Name,
+		"addressType": string(slice.AddressType),
+		"ports":       ports,
+		"endpoints":   endpoints,
+	}
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -11,242 +10,342 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/client-go/kubernetes/scheme"
-	"k8s.io/client-go/rest"
-)
+	"k8s.io/apimachinery/pkg/fields"
 
-// NodeMetrics structs for parsing metrics.k8s.io response.
-type NodeMetricsList struct {
-	Items []struct {
-		Metadata struct {
-			Name string `json:"name"`
-		} `json:"metadata"`
-		Usage struct {
-			CPU    string `json:"cpu"`
-			Memory string `json:"memory"`
-		} `json:"usage"`
-	} `json:"items"`
-}
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
 
 // HandleListNodes lists all Kubernetes nodes.
 func (h *Handlers) HandleListNodes(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	nodes, err := h.podManager.GetClient().CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	labelSelector := c.Query("labelSelector")
+	fieldSelector := c.Query("fieldSelector")
+	if err := k8s.ValidateSelectors(labelSelector, fieldSelector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The cache only evaluates the fields NodeFields sets; any other field would match nothing.
+	if err := k8s.ValidateFieldSelector("nodes", fieldSelector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	nodes, err := h.listNodes(ctx, labelSelector, fieldSelector)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to list nodes")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list nodes"})
 		return
 	}
 
-	h.logger.WithField("nodes_count", len(nodes.Items)).Info("Successfully listed nodes")
+	h.logger.WithField("nodes_count", len(nodes)).Info("Successfully listed nodes")
 
 	// Fetch metrics.
 	metricsMap := make(map[string]map[string]string)
+	if h.metrics != nil {
+		usage, metricsErr := h.metrics.NodeMetrics(ctx)
+		if metricsErr != nil {
+			h.logger.WithError(metricsErr).Warn("Failed to fetch node metrics")
+		}
+		for name, u := range usage {
+			metricsMap[name] = map[string]string{
+				"cpu":    k8s.FormatCPU(&u.CPU),
+				"memory": k8s.FormatMemoryGB(&u.Memory),
+			}
+		}
+	}
 
-	// Create a REST client for metrics.
-	config := h.podManager.GetConfig()
-	if config != nil {
-		metricsConfig := *config
-		metricsConfig.GroupVersion = &schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}
-		metricsConfig.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
-		metricsConfig.APIPath = "/apis"
-
-		var metricsClient *rest.RESTClient
-		metricsClient, err = rest.RESTClientFor(&metricsConfig)
-		if err == nil {
-			var data []byte
-			data, err = metricsClient.Get().Resource("nodes").DoRaw(ctx)
-			if err == nil {
-				var metricsList NodeMetricsList
-				if err = json.Unmarshal(data, &metricsList); err == nil {
-					for _, item := range metricsList.Items {
-						// Parse CPU.
-						cpuStr := "0"
-						if cpuQ, cpuErr := resource.ParseQuantity(item.Usage.CPU); cpuErr == nil {
-							// Convert to cores (float).
-							cpuStr = fmt.Sprintf("%.2f", float64(cpuQ.MilliValue())/1000.0)
-						}
+	nodeList := make([]gin.H, 0, len(nodes))
+	for _, node := range nodes {
+		summary := nodeSummary(node, metricsMap[node.Name])
+		nodeList = append(nodeList, summary)
 
-						// Parse Memory.
-						memStr := "0"
-						if memQ, memErr := resource.ParseQuantity(item.Usage.Memory); memErr == nil {
-							// Convert to GB.
-							memGB := float64(memQ.Value()) / (1024 * 1024 * 1024)
-							memStr = fmt.Sprintf("%.2f GB", memGB)
-						}
+		// Log role for debugging.
+		h.logger.WithFields(logrus.Fields{
+			"node": node.Name,
+			"role": summary["role"],
+		}).Debug("Node role extracted")
+	}
 
-						metricsMap[item.Metadata.Name] = map[string]string{
-							"cpu":    cpuStr,
-							"memory": memStr,
-						}
-					}
-				} else {
-					h.logger.WithError(err).Warn("Failed to unmarshal node metrics")
-				}
-			} else {
-				h.logger.WithError(err).Warn("Failed to fetch node metrics")
-			}
-		} else {
-			h.logger.WithError(err).Warn("Failed to create metrics client")
+	c.JSON(http.StatusOK, gin.H{"nodes": nodeList})
+}
+
+// HandleGetNode returns the detail of a node: capacity and allocatable resources, the requests and limits
+// of the pods scheduled on it (as kubectl describe node reports them), conditions, node info, taints and images.
+func (h *Handlers) HandleGetNode(c *gin.Context) {
+	nodeName := c.Param("name")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	node, err := h.podManager.GetClient().CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Node not found"})
+			return
 		}
+		h.logger.WithError(err).WithField("node", nodeName).Error("Failed to get node")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get node"})
+		return
 	}
 
-	nodeList := make([]gin.H, 0, len(nodes.Items))
-	for i := range nodes.Items {
-		node := &nodes.Items[i]
-		// Get node status.
-		ready := false
-		status := "NotReady"
-		for _, condition := range node.Status.Conditions {
-			if condition.Type == v1.NodeReady {
-				if condition.Status == v1.ConditionTrue {
-					ready = true
-					status = "Ready"
-				} else {
-					status = "NotReady"
-				}
-				break
-			}
+	pods, err := h.listPods(ctx, metav1.NamespaceAll, "", fields.OneTermEqualSelector("spec.nodeName", nodeName).String())
+	if err != nil {
+		h.logger.WithError(err).WithField("node", nodeName).Error("Failed to list pods on node")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list pods on node"})
+		return
+	}
+
+	// Sum requests and limits of non-terminated pods, as kubectl describe node does.
+	totalRequests, totalLimits := v1.ResourceList{}, v1.ResourceList{}
+	podList := make([]gin.H, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		reqs, limits := k8s.PodRequestsAndLimits(pod)
+		for name, quantity := range reqs {
+			value := totalRequests[name]
+			value.Add(quantity)
+			totalRequests[name] = value
+		}
+		for name, quantity := range limits {
+			value := totalLimits[name]
+			value.Add(quantity)
+			totalLimits[name] = value
 		}
 
-		// Get internal IP.
-		internalIP := ""
-		for _, addr := range node.Status.Addresses {
-			if addr.Type == v1.NodeInternalIP {
-				internalIP = addr.Address
-				break
-			}
+		summary := podSummary(pod)
+		summary["requests"] = resourceListStrings(reqs)
+		summary["limits"] = resourceListStrings(limits)
+		podList = append(podList, summary)
+	}
+
+	allocated := make(map[string]gin.H)
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory, v1.ResourceEphemeralStorage} {
+		allocatable := node.Status.Allocatable[name]
+		requests := totalRequests[name]
+		limits := totalLimits[name]
+		allocated[string(name)] = gin.H{
+			"requests":        requests.String(),
+			"limits":          limits.String(),
+			"requestsPercent": quantityPercent(&requests, &allocatable),
+			"limitsPercent":   quantityPercent(&limits, &allocatable),
 		}
+	}
+
+	conditions := make([]gin.H, 0, len(node.Status.Conditions))
+	for i := range node.Status.Conditions {
+		cond := &node.Status.Conditions[i]
+		conditions = append(conditions, gin.H{
+			"type":               string(cond.Type),
+			"status":             string(cond.Status),
+			"reason":             cond.Reason,
+			"message":            cond.Message,
+			"lastHeartbeatTime":  cond.LastHeartbeatTime.Time,
+			"lastTransitionTime": cond.LastTransitionTime.Time,
+		})
+	}
+
+	taints := make([]gin.H, 0, len(node.Spec.Taints))
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		taints = append(taints, gin.H{
+			"key":    taint.Key,
+			"value":  taint.Value,
+			"effect": string(taint.Effect),
+		})
+	}
+
+	images := make([]gin.H, 0, len(node.Status.Images))
+	for i := range node.Status.Images {
+		image := &node.Status.Images[i]
+		images = append(images, gin.H{
+			"names":     image.Names,
+			"sizeBytes": image.SizeBytes,
+		})
+	}
+
+	info := node.Status.NodeInfo
+	detail := nodeSummary(node, nil)
+	detail["unschedulable"] = node.Spec.Unschedulable
+	detail["podCIDR"] = node.Spec.PodCIDR
+	detail["capacity"] = resourceListStrings(node.Status.Capacity)
+	detail["allocatable"] = resourceListStrings(node.Status.Allocatable)
+	detail["allocated"] = allocated
+	detail["conditions"] = conditions
+	detail["taints"] = taints
+	detail["images"] = images
+	detail["pods"] = podList
+	detail["nodeInfo"] = gin.H{
+		"machineID":               info.MachineID,
+		"systemUUID":              info.SystemUUID,
+		"bootID":                  info.BootID,
+		"kernelVersion":           info.KernelVersion,
+		"osImage":                 info.OSImage,
+		"containerRuntimeVersion": info.ContainerRuntimeVersion,
+		"kubeletVersion":          info.KubeletVersion,
+		"kubeProxyVersion":        info.KubeProxyVersion,
+		"operatingSystem":         info.OperatingSystem,
+		"architecture":            info.Architecture,
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// quantityPercent returns value as a whole percentage of total, or 0 when total is zero.
+func quantityPercent(value, total *resource.Quantity) int64 {
+	if total.IsZero() {
+		return 0
+	}
+	return value.MilliValue() * 100 / total.MilliValue()
+}
 
-		// Get external IP (if available).
-		externalIP := ""
-		for _, addr := range node.Status.Addresses {
-			if addr.Type == v1.NodeExternalIP {
-				externalIP = addr.Address
-				break
+// nodeSummary converts a node into the JSON shape returned by the node list and watch endpoints.
+// usage holds the formatted "cpu" and "memory" metrics for the node and may be nil.
+func nodeSummary(node *v1.Node, usage map[string]string) gin.H {
+	// Get node status.
+	ready := false
+	status := "NotReady"
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			if condition.Status == v1.ConditionTrue {
+				ready = true
+				status = "Ready"
+			} else {
+				status = "NotReady"
 			}
+			break
 		}
+	}
 
-		// Calculate uptime from creation timestamp and format as days/hours.
-		uptimeDuration := time.Since(node.CreationTimestamp.Time)
-		days := int(uptimeDuration.Hours() / 24)
-		hours := int(uptimeDuration.Hours()) % 24
-		var uptimeStr string
-		if days > 0 {
-			uptimeStr = fmt.Sprintf("%dd %dh", days, hours)
-		} else {
-			uptimeStr = fmt.Sprintf("%dh", hours)
+	// Get internal IP.
+	internalIP := ""
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP {
+			internalIP = addr.Address
+			break
 		}
+	}
 
-		// The presence of the label itself indicates the role.
-		role := "worker"
-		if node.Labels != nil {
-			// Label exists (value can be empty or "true").
-			if _, ok := node.Labels["node-role.kubernetes.io/control-plane"]; ok {
-				role = "control-plane"
-			} else if _, ok := node.Labels["node-role.kubernetes.io/master"]; ok {
-				// Check for master role (older Kubernetes versions).
-				role = "master"
-			} else if val, ok := node.Labels["kubernetes.io/role"]; ok && val != "" {
-				// Check for generic role label.
-				role = val
-			} else {
-				// Check all labels for any node-role pattern.
-				for key := range node.Labels {
-					if strings.HasPrefix(key, "node-role.kubernetes.io/") {
-						parts := strings.Split(key, "/")
-						if len(parts) > 1 {
-							// Extract role from label key (e.g., "control-plane" from "node-role.kubernetes.io/control-plane").
-							roleName := parts[1]
-							if roleName != "" {
-								role = roleName
-								break
-							}
+	// Get external IP (if available).
+	externalIP := ""
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeExternalIP {
+			externalIP = addr.Address
+			break
+		}
+	}
+
+	// Calculate uptime from creation timestamp and format as days/hours.
+	uptimeDuration := time.Since(node.CreationTimestamp.Time)
+	days := int(uptimeDuration.Hours() / 24)
+	hours := int(uptimeDuration.Hours()) % 24
+	var uptimeStr string
+	if days > 0 {
+		uptimeStr = fmt.Sprintf("%dd %dh", days, hours)
+	} else {
+		uptimeStr = fmt.Sprintf("%dh", hours)
+	}
+
+	// The presence of the label itself indicates the role.
+	role := "worker"
+	if node.Labels != nil {
+		// Label exists (value can be empty or "true").
+		if _, ok := node.Labels["node-role.kubernetes.io/control-plane"]; ok {
+			role = "control-plane"
+		} else if _, ok := node.Labels["node-role.kubernetes.io/master"]; ok {
+			// Check for master role (older Kubernetes versions).
+			role = "master"
+		} else if val, ok := node.Labels["kubernetes.io/role"]; ok && val != "" {
+			// Check for generic role label.
+			role = val
+		} else {
+			// Check all labels for any node-role pattern.
+			for key := range node.Labels {
+				if strings.HasPrefix(key, "node-role.kubernetes.io/") {
+					parts := strings.Split(key, "/")
+					if len(parts) > 1 {
+						// Extract role from label key (e.g., "control-plane" from "node-role.kubernetes.io/control-plane").
+						roleName := parts[1]
+						if roleName != "" {
+							role = roleName
+							break
 						}
 					}
 				}
 			}
 		}
+	}
 
-		// Get node info.
-		kubeletVersion := node.Status.NodeInfo.KubeletVersion
-		osImage := node.Status.NodeInfo.OSImage
-		containerRuntime := node.Status.NodeInfo.ContainerRuntimeVersion
-		architecture := node.Status.NodeInfo.Architecture
-		operatingSystem := node.Status.NodeInfo.OperatingSystem
-
-		// Get CPU and memory capacity.
-		cpuCapacity := node.Status.Capacity[v1.ResourceCPU]
-		memoryCapacity := node.Status.Capacity[v1.ResourceMemory]
-		cpuAllocatable := node.Status.Allocatable[v1.ResourceCPU]
-		memoryAllocatable := node.Status.Allocatable[v1.ResourceMemory]
-
-		// Convert memory to GB.
-		memoryCapacityGB := float64(memoryCapacity.Value()) / (1024 * 1024 * 1024)
-		memoryAllocatableGB := float64(memoryAllocatable.Value()) / (1024 * 1024 * 1024)
-
-		// Get Usage if available.
-		cpuUsage := "0"
-		memoryUsage := "0"
-		if metrics, ok := metricsMap[node.Name]; ok {
-			cpuUsage = metrics["cpu"]
-			memoryUsage = metrics["memory"]
-		}
+	// Get node info.
+	kubeletVersion := node.Status.NodeInfo.KubeletVersion
+	osImage := node.Status.NodeInfo.OSImage
+	containerRuntime := node.Status.NodeInfo.ContainerRuntimeVersion
+	architecture := node.Status.NodeInfo.Architecture
+	operatingSystem := node.Status.NodeInfo.OperatingSystem
 
-		// Get labels.
-		labels := make(map[string]string)
-		if node.Labels != nil {
-			for k, v := range node.Labels {
-				labels[k] = v
-			}
-		}
+	// Get CPU and memory capacity.
+	cpuCapacity := node.Status.Capacity[v1.ResourceCPU]
+	memoryCapacity := node.Status.Capacity[v1.ResourceMemory]
+	cpuAllocatable := node.Status.Allocatable[v1.ResourceCPU]
+	memoryAllocatable := node.Status.Allocatable[v1.ResourceMemory]
 
-		// Get taints.
-		taints := make([]string, 0)
-		for _, taint := range node.Spec.Taints {
-			taints = append(taints, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
-		}
+	// Convert memory to GB.
+	memoryCapacityGB := float64(memoryCapacity.Value()) / (1024 * 1024 * 1024)
+	memoryAllocatableGB := float64(memoryAllocatable.Value()) / (1024 * 1024 * 1024)
 
-		// Ensure role is never empty.
-		if role == "" {
-			role = "worker"
+	// Get Usage if available.
+	cpuUsage := "0"
+	memoryUsage := "0"
+	if usage != nil {
+		cpuUsage = usage["cpu"]
+		memoryUsage = usage["memory"]
+	}
+
+	// Get labels.
+	labels := make(map[string]string)
+	if node.Labels != nil {
+		for k, v := range node.Labels {
+			labels[k] = v
 		}
+	}
 
-		nodeList = append(nodeList, gin.H{
-			"name":              node.Name,
-			"status":            status,
-			"ready":             ready,
-			"role":              role,
-			"internalIP":        internalIP,
-			"externalIP":        externalIP,
-			"uptime":            uptimeStr,
-			"kubeletVersion":    kubeletVersion,
-			"osImage":           osImage,
-			"containerRuntime":  containerRuntime,
-			"architecture":      architecture,
-			"operatingSystem":   operatingSystem,
-			"cpuCapacity":       cpuCapacity.String(),
-			"memoryCapacity":    fmt.Sprintf("%.2f GB", memoryCapacityGB),
-			"cpuAllocatable":    cpuAllocatable.String(),
-			"memoryAllocatable": fmt.Sprintf("%.2f GB", memoryAllocatableGB),
-			"cpuUsage":          cpuUsage,
-			"memoryUsage":       memoryUsage,
-			"labels":            labels,
-			"taints":            taints,
-			"age":               time.Since(node.CreationTimestamp.Time).Round(time.Second).String(),
-		})
+	// Get taints.
+	taints := make([]string, 0)
+	for _, taint := range node.Spec.Taints {
+		taints = append(taints, fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect))
+	}
 
-		// Log role for debugging.
-		h.logger.WithFields(logrus.Fields{
-			"node": node.Name,
-			"role": role,
-		}).Debug("Node role extracted")
+	// Ensure role is never empty.
+	if role == "" {
+		role = "worker"
 	}
 
-	c.JSON(http.StatusOK, gin.H{"nodes": nodeList})
+	return gin.H{
+		"name":              node.Name,
+		"status":            status,
+		"ready":             ready,
+		"role":              role,
+		"internalIP":        internalIP,
+		"externalIP":        externalIP,
+		"uptime":            uptimeStr,
+		"kubeletVersion":    kubeletVersion,
+		"osImage":           osImage,
+		"containerRuntime":  containerRuntime,
+		"architecture":      architecture,
+		"operatingSystem":   operatingSystem,
+		"cpuCapacity":       cpuCapacity.String(),
+		"memoryCapacity":    fmt.Sprintf("%.2f GB", memoryCapacityGB),
+		"cpuAllocatable":    cpuAllocatable.String(),
+		"memoryAllocatable": fmt.Sprintf("%.2f GB", memoryAllocatableGB),
+		"cpuUsage":          cpuUsage,
+		"memoryUsage":       memoryUsage,
+		"labels":            labels,
+		"taints":            taints,
+		"age":               time.Since(node.CreationTimestamp.Time).Round(time.Second).String(),
+	}
 }
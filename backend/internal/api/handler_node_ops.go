@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+const defaultDrainTimeout = 5 * time.Minute
+
+// HandleCordonNode marks a node unschedulable.
+func (h *Handlers) HandleCordonNode(c *gin.Context) {
+	h.setNodeUnschedulable(c, "cordon", true)
+}
+
+// HandleUncordonNode marks a node schedulable again.
+func (h *Handlers) HandleUncordonNode(c *gin.Context) {
+	h.setNodeUnschedulable(c, "uncordon", false)
+}
+
+func (h *Handlers) setNodeUnschedulable(c *gin.Context, action string, unschedulable bool) {
+	nodeName := c.Param("name")
+	if !h.authorize(c, auth.RoleAdmin, action, "nodes", "", nodeName) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err := k8s.SetNodeUnschedulable(ctx, h.podManager.GetClient(), nodeName, unschedulable)
+	entry := audit.Entry{Action: action, Resource: "nodes", Name: nodeName, Result: audit.ResultSuccess}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	}
+	h.recordAudit(c, entry)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Node not found"})
+			return
+		}
+		h.logger.WithError(err).WithField("node", nodeName).Errorf("Failed to %s node", action)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + action + " node"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "node": nodeName, "unschedulable": unschedulable})
+}
+
+// HandleDrainNode cordons a node and evicts its pods, streaming per-pod progress as Server-Sent Events.
+// Options are taken from the query string: ignoreDaemonSets, deleteEmptyDirData, force,
+// gracePeriod (seconds, -1 for the pod default) and timeout (a Go duration, default 5m).
+func (h *Handlers) HandleDrainNode(c *gin.Context) {
+	nodeName := c.Param("name")
+	if !h.authorize(c, auth.RoleAdmin, "drain", "nodes", "", nodeName) {
+		return
+	}
+
+	opts := k8s.DrainOptions{
+		IgnoreDaemonSets:   c.Query("ignoreDaemonSets") == "true",
+		DeleteEmptyDirData: c.Query("deleteEmptyDirData") == "true",
+		Force:              c.Query("force") == "true",
+		Timeout:            defaultDrainTimeout,
+	}
+	if grace := c.Query("gracePeriod"); grace != "" {
+		seconds, err := strconv.ParseInt(grace, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "gracePeriod must be an integer number of seconds"})
+			return
+		}
+		if seconds >= 0 {
+			opts.GracePeriodSeconds = &seconds
+		}
+	}
+	if timeout := c.Query("timeout"); timeout != "" {
+		parsed, err := time.ParseDuration(timeout)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "timeout must be a positive duration such as 5m"})
+			return
+		}
+		opts.Timeout = parsed
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"node":               nodeName,
+		"user":               currentUser(c),
+		"ignoreDaemonSets":   opts.IgnoreDaemonSets,
+		"deleteEmptyDirData": opts.DeleteEmptyDirData,
+		"force":              opts.Force,
+		"timeout":            opts.Timeout,
+	}).Info("Starting node drain")
+
+	startSSE(c)
+
+	evicted := 0
+	err := k8s.DrainNode(c.Request.Context(), h.podManager.GetClient(), nodeName, opts, func(event k8s.DrainEvent) {
+		if event.Type == k8s.DrainEventDeleted {
+			evicted++
+		}
+		_ = writeSSE(c, "", "progress", event)
+	})
+
+	entry := audit.Entry{
+		Action:   "drain",
+		Resource: "nodes",
+		Name:     nodeName,
+		Details: map[string]interface{}{
+			"ignoreDaemonSets":   opts.IgnoreDaemonSets,
+			"deleteEmptyDirData": opts.DeleteEmptyDirData,
+			"force":              opts.Force,
+			"evicted":            evicted,
+		},
+		Result: audit.ResultSuccess,
+	}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	}
+	h.recordAudit(c, entry)
+
+	if err != nil {
+		h.logger.WithError(err).WithField("node", nodeName).Error("Node drain failed")
+		_ = writeSSE(c, "", "error", gin.H{"node": nodeName, "error": err.Error(), "evicted": evicted})
+		return
+	}
+
+	_ = writeSSE(c, "", "complete", gin.H{"node": nodeName, "evicted": evicted})
+}
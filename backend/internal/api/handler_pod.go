@@ -3,9 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -16,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
 	"github.com/kubrowser/kubrowser-backend/internal/terminal"
 )
 
@@ -24,16 +23,21 @@ func (h *Handlers) HandleListNamespaces(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	namespaces, err := h.podManager.GetClient().CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	labelSelector := c.Query("labelSelector")
+	if err := k8s.ValidateSelectors(labelSelector, ""); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	namespaces, err := h.listNamespaces(ctx, labelSelector)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to list namespaces")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list namespaces"})
 		return
 	}
 
-	namespaceList := make([]gin.H, 0, len(namespaces.Items))
-	for i := range namespaces.Items {
-		ns := &namespaces.Items[i]
+	namespaceList := make([]gin.H, 0, len(namespaces))
+	for _, ns := range namespaces {
 		namespaceList = append(namespaceList, gin.H{
 			"name":   ns.Name,
 			"status": ns.Status.Phase,
@@ -43,7 +47,8 @@ func (h *Handlers) HandleListNamespaces(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"namespaces": namespaceList})
 }
 
-// HandleListPods lists pods in a namespace or all namespaces.
+// HandleListPods lists pods in a namespace or all namespaces, with usage from metrics-server when
+// metrics=true.
 func (h *Handlers) HandleListPods(c *gin.Context) {
 	namespace := c.DefaultQuery("namespace", "default")
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
@@ -58,7 +63,19 @@ func (h *Handlers) HandleListPods(c *gin.Context) {
 		h.logger.WithField("namespace", namespace).Info("Listing pods from namespace")
 	}
 
-	pods, err := h.podManager.GetClient().CoreV1().Pods(listNamespace).List(ctx, metav1.ListOptions{})
+	labelSelector := c.Query("labelSelector")
+	fieldSelector := c.Query("fieldSelector")
+	if err := k8s.ValidateSelectors(labelSelector, fieldSelector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The cache only evaluates the fields PodFields sets; any other field would match nothing.
+	if err := k8s.ValidateFieldSelector("pods", fieldSelector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pods, err := h.listPods(ctx, listNamespace, labelSelector, fieldSelector)
 	if err != nil {
 		h.logger.WithError(err).WithField("namespace", namespace).Error("Failed to list pods")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list pods"})
@@ -68,39 +85,22 @@ func (h *Handlers) HandleListPods(c *gin.Context) {
 	h.logger.WithFields(logrus.Fields{
 		"namespace":      namespace,
 		"list_namespace": listNamespace,
-		"pods_count":     len(pods.Items),
+		"pods_count":     len(pods),
 	}).Info("Successfully listed pods")
 
-	podList := make([]gin.H, 0, len(pods.Items))
-	for i := range pods.Items {
-		pod := &pods.Items[i]
-		// Get pod status.
-		status := string(pod.Status.Phase)
-		ready := false
-		for _, condition := range pod.Status.Conditions {
-			if condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue {
-				ready = true
-				break
-			}
-		}
+	podList := make([]gin.H, 0, len(pods))
+	for _, pod := range pods {
+		podList = append(podList, podSummary(pod))
+	}
 
-		podList = append(podList, gin.H{
-			"name":        pod.Name,
-			"namespace":   pod.Namespace,
-			"status":      status,
-			"ready":       ready,
-			"age":         time.Since(pod.CreationTimestamp.Time).Round(time.Second).String(),
-			"restarts":    getRestartCount(pod),
-			"node":        pod.Spec.NodeName,
-			"podIP":       pod.Status.PodIP,
-			"qosClass":    string(pod.Status.QOSClass),
-			"labels":      pod.Labels,
-			"annotations": pod.Annotations,
-			"containers":  getContainerInfo(pod),
-		})
+	// Merge usage from metrics-server when asked for; it is a synchronous call, so listing stays
+	// cheap by default.
+	metricsAvailable := false
+	if c.Query("metrics") == "true" {
+		metricsAvailable = h.applyPodMetrics(ctx, listNamespace, podList)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"pods": podList})
+	c.JSON(http.StatusOK, gin.H{"pods": podList, "metricsAvailable": metricsAvailable})
 }
 
 // HandleGetPod gets a specific pod by name.
@@ -163,15 +163,23 @@ func (h *Handlers) HandleDeletePod(c *gin.Context) {
 }
 
 // HandlePodLogs streams logs from a pod.
+// Besides container, tail and follow it supports previous, sinceSeconds, sinceTime, timestamps and
+// limitBytes, all-containers=true to interleave every container (init containers included) with a
+// "[container]" prefix, and download=true to return a gzip attachment instead of a stream.
+// include, exclude, regex, level and before/after/context filter lines on the server; the number of
+// matches is sent in the X-Log-Matches trailer.
 func (h *Handlers) HandlePodLogs(c *gin.Context) {
 	namespace := c.DefaultQuery("namespace", "default")
 	podName := c.Param("name")
-	containerName := c.DefaultQuery("container", "")
-	tailLines := c.DefaultQuery("tail", "100")
-	follow := c.DefaultQuery("follow", "true") == "true"
+
+	req, err := parseLogRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	ctx := c.Request.Context()
-	if !follow {
+	if !req.options.Follow {
 		// For non-following logs, use a timeout.
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
@@ -190,28 +198,28 @@ func (h *Handlers) HandlePodLogs(c *gin.Context) {
 		return
 	}
 
-	// Use first container if not specified.
-	if containerName == "" && len(pod.Spec.Containers) > 0 {
-		containerName = pod.Spec.Containers[0].Name
+	containers, err := logContainers(pod, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Parse tail lines.
-	tailLinesInt := int64(100)
-	if tailLines != "" {
-		if parsed, parseErr := strconv.ParseInt(tailLines, 10, 64); parseErr == nil {
-			tailLinesInt = parsed
+	// Every line is prefixed with its container in all-containers mode, even for a single container.
+	if req.allContainers {
+		streams, err := h.openContainerLogStreams(ctx, namespace, podName, containers, req.options)
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to stream logs of any container")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stream logs: " + err.Error()})
+			return
 		}
+		out := newLogOutput(c, pod.Name, req)
+		defer out.Close()
+		h.streamAllContainerLogs(ctx, out, streams, req.options, req.filter)
+		return
 	}
 
 	// Get logs.
-	podLogOpts := &v1.PodLogOptions{
-		Container: containerName,
-		Follow:    follow,
-		TailLines: &tailLinesInt,
-	}
-
-	req := h.podManager.GetClient().CoreV1().Pods(namespace).GetLogs(podName, podLogOpts)
-	stream, err := req.Stream(ctx)
+	stream, err := h.openLogStream(ctx, namespace, podName, containers[0], req.options)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to stream logs")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stream logs"})
@@ -219,37 +227,13 @@ func (h *Handlers) HandlePodLogs(c *gin.Context) {
 	}
 	defer stream.Close()
 
-	c.Header("Content-Type", "text/plain; charset=utf-8")
-	c.Header("Cache-Control", "no-cache")
-
-	// Stream logs to response.
-	buf := make([]byte, 4096)
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-		}
-		n, err := stream.Read(buf)
-		if n > 0 {
-			if _, writeErr := c.Writer.Write(buf[:n]); writeErr != nil {
-				break
-			}
-			c.Writer.Flush()
-		}
-		if err != nil {
-			if err == io.EOF {
-				if !follow {
-					break
-				}
-				// For follow mode, EOF might be temporary, continue reading.
-				time.Sleep(100 * time.Millisecond)
-				continue
-			}
-			h.logger.WithError(err).Error("Error reading logs")
-			break
-		}
+	out := newLogOutput(c, pod.Name, req)
+	defer out.Close()
+	if req.filter.Active() {
+		h.filterLogStream(ctx, out, stream, req.options.Timestamps, req.filter)
+		return
 	}
+	h.copyLogStream(ctx, out, stream)
 }
 
 // HandlePodExec handles WebSocket connections for exec into a pod.
@@ -376,6 +360,34 @@ func (h *Handlers) HandlePodExec(c *gin.Context) {
 	}
 }
 
+// podSummary converts a pod into the JSON shape returned by the pod list and watch endpoints.
+func podSummary(pod *v1.Pod) gin.H {
+	// Get pod status.
+	status := string(pod.Status.Phase)
+	ready := false
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue {
+			ready = true
+			break
+		}
+	}
+
+	return gin.H{
+		"name":        pod.Name,
+		"namespace":   pod.Namespace,
+		"status":      status,
+		"ready":       ready,
+		"age":         time.Since(pod.CreationTimestamp.Time).Round(time.Second).String(),
+		"restarts":    getRestartCount(pod),
+		"node":        pod.Spec.NodeName,
+		"podIP":       pod.Status.PodIP,
+		"qosClass":    string(pod.Status.QOSClass),
+		"labels":      pod.Labels,
+		"annotations": pod.Annotations,
+		"containers":  getContainerInfo(pod),
+	}
+}
+
 func getContainerInfo(pod *v1.Pod) []gin.H {
 	containers := make([]gin.H, 0, len(pod.Spec.Containers))
 	for i := range pod.Spec.Containers {
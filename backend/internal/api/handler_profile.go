@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+var (
+	errUnknownProfile    = errors.New("unknown profile")
+	errProfileNotAllowed = errors.New("profile not allowed")
+)
+
+// HandleListProfiles lists the terminal profiles the current user may launch and the one used
+// when none is chosen.
+func (h *Handlers) HandleListProfiles(c *gin.Context) {
+	role := auth.RoleFromContext(c)
+	profiles := h.podManager.Profiles()
+
+	result := []gin.H{}
+	defaultProfile := ""
+	if profiles != nil {
+		for _, profile := range profiles.Profiles {
+			if !h.profileAllowed(role, profile) {
+				continue
+			}
+			result = append(result, gin.H{
+				"name":        profile.Name,
+				"description": profile.Description,
+				"image":       profile.Image,
+				"cpu":         profile.CPU,
+				"memory":      profile.Memory,
+				"minRole":     profile.MinRole,
+			})
+		}
+		if selected, err := h.selectProfile(role, ""); err == nil {
+			defaultProfile = selected.Name
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enabled": profiles != nil, "default": defaultProfile, "profiles": result})
+}
+
+// selectProfile returns the profile a user with role asked for by name. An empty name selects the
+// default profile, or the first allowed one if the default is restricted. It returns nil when
+// profiles are not configured.
+func (h *Handlers) selectProfile(role auth.Role, name string) (*k8s.Profile, error) {
+	profiles := h.podManager.Profiles()
+	if profiles == nil {
+		if name != "" {
+			return nil, fmt.Errorf("%w %q: profiles are not configured", errUnknownProfile, name)
+		}
+		return nil, nil
+	}
+
+	profile, ok := profiles.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errUnknownProfile, name)
+	}
+	if h.profileAllowed(role, profile) {
+		return profile, nil
+	}
+	if name != "" {
+		return nil, fmt.Errorf("%w: %q requires the %s role", errProfileNotAllowed, name, profile.MinRole)
+	}
+
+	for _, candidate := range profiles.Profiles {
+		if h.profileAllowed(role, candidate) {
+			return candidate, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no terminal profile is available to the %s role", errProfileNotAllowed, role)
+}
+
+// sessionProfile returns the profile to start username's terminal with. When no profile is asked
+// for and the user already has a session pod, the pod's profile is kept rather than replacing the pod
+// with one running the default profile.
+func (h *Handlers) sessionProfile(ctx context.Context, role auth.Role, username, name string) (*k8s.Profile, error) {
+	profiles := h.podManager.Profiles()
+	if name == "" && profiles != nil {
+		if pod, err := h.podManager.FindExistingPod(ctx, username); err == nil && pod != nil && pod.Labels[k8s.ProfileLabel] != "" {
+			if profile, ok := profiles.Get(pod.Labels[k8s.ProfileLabel]); ok && h.profileAllowed(role, profile) {
+				return profile, nil
+			}
+		}
+	}
+	return h.selectProfile(role, name)
+}
+
+// profileAllowed reports whether role may launch profile. Profiles with an unknown minimum role are denied.
+func (h *Handlers) profileAllowed(role auth.Role, profile *k8s.Profile) bool {
+	if profile.MinRole == "" {
+		return true
+	}
+	required, ok := auth.ParseRole(profile.MinRole)
+	if !ok {
+		h.logger.WithField("profile", profile.Name).Warnf("Unknown minRole %q, denying profile", profile.MinRole)
+		return false
+	}
+	return role.Allows(required)
+}
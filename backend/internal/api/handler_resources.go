@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+const (
+	defaultResourcePageSize = 500
+	maxResourcePageSize     = 5000
+)
+
+// sensitiveResources can only be read through the generic browser by admins, since it returns
+// objects verbatim.
+var sensitiveResources = map[string]bool{
+	"/secrets": true,
+}
+
+// HandleListAPIResources returns every resource kind the API server serves, CRDs included.
+func (h *Handlers) HandleListAPIResources(c *gin.Context) {
+	resources, err := h.resources.APIResources()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to discover API resources")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to discover API resources"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"resources": resources})
+}
+
+// HandleListResources lists objects of any resource at /resources/:group/:version/:resource, using
+// "core" as the group of the legacy API. Supports namespace, labelSelector, fieldSelector, limit and
+// continue. Responds with the server-side Table format when the request accepts
+// application/json;as=Table or sets format=table.
+func (h *Handlers) HandleListResources(c *gin.Context) {
+	resource, ok := h.resolveResource(c, "list")
+	if !ok {
+		return
+	}
+
+	labelSelector := c.Query("labelSelector")
+	fieldSelector := c.Query("fieldSelector")
+	if err := k8s.ValidateSelectors(labelSelector, fieldSelector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", strconv.Itoa(defaultResourcePageSize)), 10, 64)
+	if err != nil || limit <= 0 {
+		limit = defaultResourcePageSize
+	}
+	if limit > maxResourcePageSize {
+		limit = maxResourcePageSize
+	}
+
+	opts := metav1.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
+		Limit:         limit,
+		Continue:      c.Query("continue"),
+	}
+	namespace := resourceNamespace(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	if wantsTable(c) {
+		table, err := h.resources.ListTable(ctx, resource, namespace, opts)
+		if err != nil {
+			h.resourceError(c, err, "list")
+			return
+		}
+		c.JSON(http.StatusOK, table)
+		return
+	}
+
+	list, err := h.resources.List(ctx, resource, namespace, opts)
+	if err != nil {
+		h.resourceError(c, err, "list")
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// HandleGetResource returns a single object of any resource, optionally as a Table.
+func (h *Handlers) HandleGetResource(c *gin.Context) {
+	resource, ok := h.resolveResource(c, "get")
+	if !ok {
+		return
+	}
+	namespace := resourceNamespace(c)
+	if resource.Namespaced && namespace == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "namespace is required for namespaced resources"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if wantsTable(c) {
+		table, err := h.resources.GetTable(ctx, resource, namespace, c.Param("name"))
+		if err != nil {
+			h.resourceError(c, err, "get")
+			return
+		}
+		c.JSON(http.StatusOK, table)
+		return
+	}
+
+	obj, err := h.resources.Get(ctx, resource, namespace, c.Param("name"))
+	if err != nil {
+		h.resourceError(c, err, "get")
+		return
+	}
+	c.JSON(http.StatusOK, obj)
+}
+
+// HandleDeleteResource deletes a single object of any resource. Admin only, since it can delete
+// anything the service account can; the shipped RBAC grants delete on no browsable kind, so it is
+// refused until an admin adds it (see k8s/rbac.yaml). propagationPolicy may be Background (default),
+// Foreground or Orphan.
+func (h *Handlers) HandleDeleteResource(c *gin.Context) {
+	resource, ok := h.resolveResource(c, "delete")
+	if !ok {
+		return
+	}
+	namespace := resourceNamespace(c)
+	name := c.Param("name")
+	if !h.authorize(c, auth.RoleAdmin, "delete", resourceLabel(resource), namespace, name) {
+		return
+	}
+	if resource.Namespaced && namespace == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "namespace is required for namespaced resources"})
+		return
+	}
+
+	policy := metav1.DeletePropagationBackground
+	switch p := metav1.DeletionPropagation(c.DefaultQuery("propagationPolicy", string(policy))); p {
+	case metav1.DeletePropagationBackground, metav1.DeletePropagationForeground, metav1.DeletePropagationOrphan:
+		policy = p
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "propagationPolicy must be Background, Foreground or Orphan"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err := h.resources.Delete(ctx, resource, namespace, name, metav1.DeleteOptions{PropagationPolicy: &policy})
+	entry := audit.Entry{
+		Action:    "delete",
+		Resource:  resourceLabel(resource),
+		Namespace: namespace,
+		Name:      name,
+		Details:   map[string]interface{}{"propagationPolicy": string(policy)},
+		Result:    audit.ResultSuccess,
+	}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	}
+	h.recordAudit(c, entry)
+
+	if err != nil {
+		h.resourceError(c, err, "delete")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted", "name": name, "namespace": namespace})
+}
+
+// resolveResource resolves the :group/:version/:resource path parameters through discovery and
+// checks that the resource supports verb. It responds with an error and returns false otherwise.
+func (h *Handlers) resolveResource(c *gin.Context, verb string) (k8s.APIResource, bool) {
+	resource, err := h.resources.Resolve(c.Param("group"), c.Param("version"), c.Param("resource"))
+	if err != nil {
+		if stderrors.Is(err, k8s.ErrResourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return resource, false
+		}
+		h.logger.WithError(err).Error("Failed to resolve resource")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve resource"})
+		return resource, false
+	}
+
+	if !resource.Supports(verb) {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Resource does not support " + verb})
+		return resource, false
+	}
+	if sensitiveResources[resource.Group+"/"+resource.Resource] &&
+		!h.authorize(c, auth.RoleAdmin, verb, resourceLabel(resource), resourceNamespace(c), c.Param("name")) {
+		return resource, false
+	}
+	return resource, true
+}
+
+// resourceError maps API errors from the dynamic client to responses.
+func (h *Handlers) resourceError(c *gin.Context, err error, verb string) {
+	switch {
+	case errors.IsNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.IsForbidden(err):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.IsBadRequest(err), errors.IsInvalid(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.IsResourceExpired(err):
+		c.JSON(http.StatusGone, gin.H{"error": "continue token expired, restart the list"})
+	default:
+		h.logger.WithError(err).Errorf("Failed to %s resource", verb)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to " + verb + " resource"})
+	}
+}
+
+// resourceNamespace returns the namespace query parameter, where "", "*" and "all" mean all namespaces.
+func resourceNamespace(c *gin.Context) string {
+	namespace := c.Query("namespace")
+	if namespace == "*" || namespace == "all" {
+		return metav1.NamespaceAll
+	}
+	return namespace
+}
+
+// wantsTable reports whether the client asked for the server-side Table format.
+func wantsTable(c *gin.Context) bool {
+	return c.Query("format") == "table" || strings.Contains(c.GetHeader("Accept"), "as=Table")
+}
+
+// resourceLabel formats a resource as group/version/resource for audit entries.
+func resourceLabel(r k8s.APIResource) string {
+	group := r.Group
+	if group == "" {
+		group = k8s.CoreGroup
+	}
+	return group + "/" + r.Version + "/" + r.Resource
+}
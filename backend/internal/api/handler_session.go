@@ -2,10 +2,19 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+	"github.com/kubrowser/kubrowser-backend/internal/session"
 )
 
 // HandleSessionInfo returns session information.
@@ -20,11 +29,61 @@ func (h *Handlers) HandleSessionInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"session_id": sess.ID,
 		"pod_name":   sess.PodName,
+		"profile":    sess.Profile,
 		"created_at": sess.CreatedAt,
 		"last_used":  sess.LastUsed,
 	})
 }
 
+// HandleCreateSession starts a terminal pod for the current user with the profile in
+// {"profile": "name"} and creates a session for it. When omitted, a running pod keeps its profile and
+// a new one gets the default. The terminal is then attached over the WebSocket with session_id and
+// reconnect=true.
+func (h *Handlers) HandleCreateSession(c *gin.Context) {
+	var req struct {
+		Profile string `json:"profile"`
+	}
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := auth.RoleFromContext(c)
+	username := currentUser(c)
+	profile, err := h.sessionProfile(c.Request.Context(), role, username, req.Profile)
+	if err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, errUnknownProfile) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 6*time.Minute)
+	defer cancel()
+
+	pod, err := h.podManager.CreatePodWithStatus(ctx, generateSessionID(), username, string(role),
+		k8s.PodOptions{Profile: profile}, time.Now(), nil)
+	if err != nil {
+		if status, ok := podOptionsStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithError(err).WithField("user", username).Error("Failed to create pod")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pod: " + err.Error()})
+		return
+	}
+
+	sess := h.sessionMgr.CreateSession(pod.Name, username, pod.Labels[k8s.ProfileLabel])
+	c.JSON(http.StatusCreated, gin.H{
+		"session_id": sess.ID,
+		"pod_name":   sess.PodName,
+		"profile":    sess.Profile,
+		"created_at": sess.CreatedAt,
+	})
+}
+
 // HandleDeleteSession deletes a session and its associated pod.
 func (h *Handlers) HandleDeleteSession(c *gin.Context) {
 	sessionID := c.Param("session_id")
@@ -46,3 +105,158 @@ func (h *Handlers) HandleDeleteSession(c *gin.Context) {
 	h.sessionMgr.DeleteSession(sessionID)
 	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
 }
+
+// HandleGetSessionResources returns the resources of a session's pod and the most the user may resize it to.
+func (h *Handlers) HandleGetSessionResources(c *gin.Context) {
+	sess, ok := h.ownSession(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	pod, err := h.podManager.GetPod(ctx, sess.PodName)
+	if err != nil {
+		h.logger.WithError(err).WithField("pod_name", sess.PodName).Error("Failed to get pod")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod not found"})
+		return
+	}
+
+	requests, limits := k8s.PodRequestsAndLimits(pod)
+	maxCPU, maxMemory := h.podManager.ResourcePolicy().Max(sess.UserID, string(auth.RoleFromContext(c)))
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sess.ID,
+		"pod_name":   sess.PodName,
+		"requests":   requests,
+		"limits":     limits,
+		"max":        gin.H{"cpu": maxCPU, "memory": maxMemory},
+	})
+}
+
+// HandleResizeSessionPod resizes the current user's session pod to {"cpu": "1", "memory": "2Gi"}
+// within their resource policy. The pod is recreated with the same home volume, so the terminal
+// must reconnect; the session ID stays valid.
+func (h *Handlers) HandleResizeSessionPod(c *gin.Context) {
+	sess, ok := h.ownSession(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		CPU    string `json:"cpu"`
+		Memory string `json:"memory"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.CPU == "" && req.Memory == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cpu or memory is required"})
+		return
+	}
+	for _, value := range []string{req.CPU, req.Memory} {
+		if _, err := resource.ParseQuantity(value); value != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cpu and memory must be quantities such as 500m or 2Gi"})
+			return
+		}
+	}
+
+	role := auth.RoleFromContext(c)
+	var profile *k8s.Profile
+	if sess.Profile != "" {
+		var found bool
+		if profile, found = h.podManager.Profiles().Get(sess.Profile); !found {
+			c.JSON(http.StatusConflict, gin.H{"error": "The session's profile no longer exists; start a new session"})
+			return
+		}
+		// The user's role may have been lowered since the session started.
+		if !h.profileAllowed(role, profile) {
+			err := fmt.Errorf("%w: %q requires the %s role", errProfileNotAllowed, profile.Name, profile.MinRole)
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 6*time.Minute)
+	defer cancel()
+
+	// Keep the limit that isn't being resized, rather than resetting it to the policy default.
+	cpu, memory := req.CPU, req.Memory
+	if existing, err := h.podManager.FindExistingPod(ctx, sess.UserID); err == nil && existing != nil {
+		current := terminalContainerLimits(existing)
+		if cpu == "" && !current.Cpu().IsZero() {
+			cpu = current.Cpu().String()
+		}
+		if memory == "" && !current.Memory().IsZero() {
+			memory = current.Memory().String()
+		}
+	}
+
+	opts := k8s.PodOptions{Profile: profile, CPU: cpu, Memory: memory, Replace: true}
+	pod, err := h.podManager.CreatePodWithStatus(ctx, sess.ID, sess.UserID, string(role), opts, time.Now(), nil)
+
+	entry := audit.Entry{
+		Action:   "resize",
+		Resource: "sessions",
+		Name:     sess.PodName,
+		Details:  map[string]interface{}{"cpu": cpu, "memory": memory},
+		Result:   audit.ResultSuccess,
+	}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	}
+	h.recordAudit(c, entry)
+
+	if err != nil {
+		if status, ok := podOptionsStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithError(err).WithField("pod_name", sess.PodName).Error("Failed to resize session pod")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resize pod: " + err.Error()})
+		return
+	}
+
+	requests, limits := k8s.PodRequestsAndLimits(pod)
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sess.ID,
+		"pod_name":   pod.Name,
+		"requests":   requests,
+		"limits":     limits,
+	})
+}
+
+// terminalContainerLimits returns the limits of the container the terminal of pod attaches to.
+func terminalContainerLimits(pod *v1.Pod) v1.ResourceList {
+	name := k8s.TerminalContainerName(pod)
+	for _, container := range pod.Spec.Containers {
+		if container.Name == name {
+			return container.Resources.Limits
+		}
+	}
+	return v1.ResourceList{}
+}
+
+// ownSession returns the session named in the path if it belongs to the current user, responding
+// with an error otherwise.
+func (h *Handlers) ownSession(c *gin.Context) (*session.Session, bool) {
+	sess, exists := h.sessionMgr.GetSession(c.Param("session_id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+	if sess.UserID != currentUser(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return nil, false
+	}
+	return sess, true
+}
+
+// podOptionsStatus maps errors from resolving a pod's resources and budget to an HTTP status.
+func podOptionsStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, k8s.ErrBudgetExceeded):
+		return http.StatusServiceUnavailable, true
+	case errors.Is(err, k8s.ErrResourcesNotAllowed):
+		return http.StatusForbidden, true
+	}
+	return 0, false
+}
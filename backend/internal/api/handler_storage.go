@@ -0,0 +1,341 @@
+package api
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// HandleListPVCs lists PersistentVolumeClaims with their binding, capacity and the pods that mount them.
+func (h *Handlers) HandleListPVCs(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	if namespace == "*" || namespace == "all" {
+		namespace = metav1.NamespaceAll
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	list, err := h.podManager.GetClient().CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.logger.WithError(err).WithField("namespace", namespace).Error("Failed to list persistent volume claims")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list persistent volume claims"})
+		return
+	}
+
+	mounts := make(map[string][]string)
+	pods, err := h.listPods(ctx, namespace, "", "")
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to list pods for claim usage")
+	}
+	for _, pod := range pods {
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil {
+				key := pod.Namespace + "/" + vol.PersistentVolumeClaim.ClaimName
+				mounts[key] = append(mounts[key], pod.Name)
+			}
+		}
+	}
+
+	claims := make([]gin.H, 0, len(list.Items))
+	for i := range list.Items {
+		pvc := &list.Items[i]
+		summary := pvcSummary(pvc)
+		mountedBy := mounts[pvc.Namespace+"/"+pvc.Name]
+		if mountedBy == nil {
+			mountedBy = []string{}
+		}
+		summary["mountedBy"] = mountedBy
+		claims = append(claims, summary)
+	}
+	c.JSON(http.StatusOK, gin.H{"persistentVolumeClaims": claims})
+}
+
+// HandleListPVs lists PersistentVolumes with the claim each one is bound to.
+func (h *Handlers) HandleListPVs(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	list, err := h.podManager.GetClient().CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list persistent volumes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list persistent volumes"})
+		return
+	}
+
+	volumes := make([]gin.H, 0, len(list.Items))
+	for i := range list.Items {
+		volumes = append(volumes, pvSummary(&list.Items[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"persistentVolumes": volumes})
+}
+
+// HandleListStorageClasses lists StorageClasses, marking the default one.
+func (h *Handlers) HandleListStorageClasses(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	list, err := h.podManager.GetClient().StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list storage classes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list storage classes"})
+		return
+	}
+
+	classes := make([]gin.H, 0, len(list.Items))
+	for i := range list.Items {
+		classes = append(classes, storageClassSummary(&list.Items[i]))
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		return classes[i]["name"].(string) < classes[j]["name"].(string)
+	})
+	c.JSON(http.StatusOK, gin.H{"storageClasses": classes})
+}
+
+// HandleListHomeVolumes lists the Kubrowser home volumes of all users with their last-used time. Admin only.
+func (h *Handlers) HandleListHomeVolumes(c *gin.Context) {
+	if !h.authorize(c, auth.RoleAdmin, "list", "home-volumes", "", "") {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	volumes, err := h.podManager.ListHomeVolumes(ctx)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list home volumes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list home volumes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"homeVolumes": volumes})
+}
+
+// HandleHomeVolumeGCReport lists the home volumes the garbage collector would delete, with the
+// reason and the snapshot it would take, without deleting anything. Admin only.
+func (h *Handlers) HandleHomeVolumeGCReport(c *gin.Context) {
+	if !h.authorize(c, auth.RoleAdmin, "list", "home-volumes", "", "") {
+		return
+	}
+	if h.homeGC == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false, "orphaned": []k8s.OrphanedHomeVolume{}})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	orphaned, err := h.homeGC.Report(ctx)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to find orphaned home volumes")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find orphaned home volumes"})
+		return
+	}
+	if orphaned == nil {
+		orphaned = []k8s.OrphanedHomeVolume{}
+	}
+
+	opts := h.homeGC.Options()
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":   true,
+		"dryRun":    true,
+		"retention": opts.Retention.String(),
+		"snapshot":  opts.Snapshot,
+		"orphaned":  orphaned,
+	})
+}
+
+// HandleDeleteHomeVolume deletes a user's home volume. It refuses while the user has a session
+// unless force=true. Admin only.
+func (h *Handlers) HandleDeleteHomeVolume(c *gin.Context) {
+	username := c.Param("username")
+	if !h.authorize(c, auth.RoleAdmin, "delete", "home-volumes", "", username) {
+		return
+	}
+	force := c.Query("force") == "true"
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	err := h.podManager.DeleteHomeVolume(ctx, username, force)
+	entry := audit.Entry{
+		Action:   "delete",
+		Resource: "home-volumes",
+		Name:     username,
+		Details:  map[string]interface{}{"force": force},
+		Result:   audit.ResultSuccess,
+	}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	}
+	h.recordAudit(c, entry)
+
+	if err != nil {
+		switch {
+		case errors.IsNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Home volume not found"})
+		case stderrors.Is(err, k8s.ErrHomeVolumeInUse):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.logger.WithError(err).WithField("username", username).Error("Failed to delete home volume")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete home volume"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted", "username": username})
+}
+
+// HandleExpandHomeVolume grows a user's home volume to {"size": "5Gi"}. Admin only.
+func (h *Handlers) HandleExpandHomeVolume(c *gin.Context) {
+	username := c.Param("username")
+	if !h.authorize(c, auth.RoleAdmin, "expand", "home-volumes", "", username) {
+		return
+	}
+
+	var req struct {
+		Size string `json:"size"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Size == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size is required"})
+		return
+	}
+	size, err := resource.ParseQuantity(req.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be a quantity such as 5Gi"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	pvc, err := h.podManager.ExpandHomeVolume(ctx, username, size)
+	entry := audit.Entry{
+		Action:   "expand",
+		Resource: "home-volumes",
+		Name:     username,
+		Details:  map[string]interface{}{"size": size.String()},
+		Result:   audit.ResultSuccess,
+	}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	}
+	h.recordAudit(c, entry)
+
+	if err != nil {
+		switch {
+		case errors.IsNotFound(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Home volume not found"})
+		case stderrors.Is(err, k8s.ErrShrinkNotAllowed), stderrors.Is(err, k8s.ErrExpansionNotAllowed):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.logger.WithError(err).WithField("username", username).Error("Failed to expand home volume")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to expand home volume"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, pvcSummary(pvc))
+}
+
+func pvcSummary(pvc *v1.PersistentVolumeClaim) gin.H {
+	requested := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	summary := gin.H{
+		"name":        pvc.Name,
+		"namespace":   pvc.Namespace,
+		"phase":       string(pvc.Status.Phase),
+		"volumeName":  pvc.Spec.VolumeName,
+		"requested":   requested.String(),
+		"accessModes": pvc.Spec.AccessModes,
+		"labels":      pvc.Labels,
+		"created":     pvc.CreationTimestamp.Time,
+	}
+	if capacity, ok := pvc.Status.Capacity[v1.ResourceStorage]; ok {
+		summary["capacity"] = capacity.String()
+	}
+	if pvc.Spec.StorageClassName != nil {
+		summary["storageClass"] = *pvc.Spec.StorageClassName
+	}
+	if pvc.Spec.VolumeMode != nil {
+		summary["volumeMode"] = string(*pvc.Spec.VolumeMode)
+	}
+	return summary
+}
+
+func pvSummary(pv *v1.PersistentVolume) gin.H {
+	capacity := pv.Spec.Capacity[v1.ResourceStorage]
+	summary := gin.H{
+		"name":          pv.Name,
+		"phase":         string(pv.Status.Phase),
+		"capacity":      capacity.String(),
+		"accessModes":   pv.Spec.AccessModes,
+		"reclaimPolicy": string(pv.Spec.PersistentVolumeReclaimPolicy),
+		"storageClass":  pv.Spec.StorageClassName,
+		"source":        pvSourceType(pv),
+		"created":       pv.CreationTimestamp.Time,
+	}
+	if ref := pv.Spec.ClaimRef; ref != nil {
+		summary["claim"] = gin.H{"namespace": ref.Namespace, "name": ref.Name}
+	}
+	if pv.Status.Reason != "" {
+		summary["reason"] = pv.Status.Reason
+	}
+	return summary
+}
+
+// pvSourceType names the volume plugin backing a PersistentVolume.
+func pvSourceType(pv *v1.PersistentVolume) string {
+	source := pv.Spec.PersistentVolumeSource
+	switch {
+	case source.CSI != nil:
+		return "csi:" + source.CSI.Driver
+	case source.HostPath != nil:
+		return "hostPath"
+	case source.Local != nil:
+		return "local"
+	case source.NFS != nil:
+		return "nfs"
+	case source.AWSElasticBlockStore != nil:
+		return "awsElasticBlockStore"
+	case source.GCEPersistentDisk != nil:
+		return "gcePersistentDisk"
+	case source.AzureDisk != nil:
+		return "azureDisk"
+	case source.ISCSI != nil:
+		return "iscsi"
+	case source.FC != nil:
+		return "fc"
+	default:
+		return "other"
+	}
+}
+
+func storageClassSummary(sc *storagev1.StorageClass) gin.H {
+	summary := gin.H{
+		"name":                 sc.Name,
+		"provisioner":          sc.Provisioner,
+		"parameters":           sc.Parameters,
+		"allowVolumeExpansion": sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion,
+		"default":              sc.Annotations[k8s.DefaultStorageClassAnnotation] == "true",
+		"created":              sc.CreationTimestamp.Time,
+	}
+	if sc.ReclaimPolicy != nil {
+		summary["reclaimPolicy"] = string(*sc.ReclaimPolicy)
+	}
+	if sc.VolumeBindingMode != nil {
+		summary["volumeBindingMode"] = string(*sc.VolumeBindingMode)
+	}
+	return summary
+}
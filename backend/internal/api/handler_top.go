@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// HandleTopPods ranks pods by CPU or memory usage, like kubectl top pods, including usage as a
+// percentage of requests and limits. Responds with metricsAvailable=false when metrics-server is absent.
+func (h *Handlers) HandleTopPods(c *gin.Context) {
+	namespace := c.DefaultQuery("namespace", "default")
+	if namespace == "*" || namespace == "all" {
+		namespace = metav1.NamespaceAll
+	}
+	sortBy := c.DefaultQuery("sortBy", "cpu")
+	if sortBy != "cpu" && sortBy != "memory" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sortBy must be cpu or memory"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	labelSelector := c.Query("labelSelector")
+	if err := k8s.ValidateSelectors(labelSelector, ""); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if h.metrics == nil {
+		c.JSON(http.StatusOK, gin.H{"metricsAvailable": false, "pods": []gin.H{}})
+		return
+	}
+
+	usage, err := h.metrics.PodMetrics(ctx, namespace)
+	if err != nil {
+		if stderrors.Is(err, k8s.ErrMetricsUnavailable) {
+			c.JSON(http.StatusOK, gin.H{"metricsAvailable": false, "pods": []gin.H{}})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to fetch pod metrics")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pod metrics"})
+		return
+	}
+
+	pods, err := h.listPods(ctx, namespace, labelSelector, "")
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list pods")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list pods"})
+		return
+	}
+
+	type rankedPod struct {
+		pod   *v1.Pod
+		usage k8s.PodUsage
+	}
+	ranked := make([]rankedPod, 0, len(pods))
+	for _, pod := range pods {
+		if u, ok := usage[k8s.PodKey(pod.Namespace, pod.Name)]; ok {
+			ranked = append(ranked, rankedPod{pod: pod, usage: u})
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if sortBy == "memory" {
+			return ranked[i].usage.Total.Memory.Cmp(ranked[j].usage.Total.Memory) > 0
+		}
+		return ranked[i].usage.Total.CPU.Cmp(ranked[j].usage.Total.CPU) > 0
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	result := make([]gin.H, 0, len(ranked))
+	for _, r := range ranked {
+		reqs, limits := k8s.PodRequestsAndLimits(r.pod)
+		cpuRequest, cpuLimit := reqs[v1.ResourceCPU], limits[v1.ResourceCPU]
+		memRequest, memLimit := reqs[v1.ResourceMemory], limits[v1.ResourceMemory]
+
+		result = append(result, gin.H{
+			"name":                 r.pod.Name,
+			"namespace":            r.pod.Namespace,
+			"node":                 r.pod.Spec.NodeName,
+			"cpuMillicores":        r.usage.Total.CPU.MilliValue(),
+			"memoryBytes":          r.usage.Total.Memory.Value(),
+			"cpuUsage":             k8s.FormatCPU(&r.usage.Total.CPU),
+			"memoryUsage":          k8s.FormatMemoryGB(&r.usage.Total.Memory),
+			"cpuRequestPercent":    usagePercent(&r.usage.Total.CPU, &cpuRequest),
+			"cpuLimitPercent":      usagePercent(&r.usage.Total.CPU, &cpuLimit),
+			"memoryRequestPercent": usagePercent(&r.usage.Total.Memory, &memRequest),
+			"memoryLimitPercent":   usagePercent(&r.usage.Total.Memory, &memLimit),
+			"containers":           containerUsageList(r.usage.Containers),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"metricsAvailable": true, "sortBy": sortBy, "pods": result})
+}
+
+// applyPodMetrics merges pod and container usage into pod summaries built by podSummary.
+// Returns false when metrics are not available.
+func (h *Handlers) applyPodMetrics(ctx context.Context, namespace string, summaries []gin.H) bool {
+	if h.metrics == nil {
+		return false
+	}
+
+	usage, err := h.metrics.PodMetrics(ctx, namespace)
+	if err != nil {
+		if !stderrors.Is(err, k8s.ErrMetricsUnavailable) {
+			h.logger.WithError(err).Warn("Failed to fetch pod metrics")
+		}
+		return false
+	}
+
+	for _, summary := range summaries {
+		name, _ := summary["name"].(string)
+		ns, _ := summary["namespace"].(string)
+		u, ok := usage[k8s.PodKey(ns, name)]
+		if !ok {
+			continue
+		}
+
+		summary["cpuUsage"] = k8s.FormatCPU(&u.Total.CPU)
+		summary["memoryUsage"] = k8s.FormatMemoryGB(&u.Total.Memory)
+		if containers, ok := summary["containers"].([]gin.H); ok {
+			for _, container := range containers {
+				containerName, _ := container["name"].(string)
+				if cu, found := u.Containers[containerName]; found {
+					container["cpuUsage"] = k8s.FormatCPU(&cu.CPU)
+					container["memoryUsage"] = k8s.FormatMemoryGB(&cu.Memory)
+				}
+			}
+		}
+	}
+	return true
+}
+
+// usagePercent returns usage as a whole percentage of total, or nil when total is unset.
+func usagePercent(usage, total *resource.Quantity) interface{} {
+	if total.IsZero() {
+		return nil
+	}
+	return usage.MilliValue() * 100 / total.MilliValue()
+}
+
+func containerUsageList(containers map[string]k8s.Usage) []gin.H {
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		u := containers[name]
+		result = append(result, gin.H{
+			"name":          name,
+			"cpuMillicores": u.CPU.MilliValue(),
+			"memoryBytes":   u.Memory.Value(),
+			"cpuUsage":      k8s.FormatCPU(&u.CPU),
+			"memoryUsage":   k8s.FormatMemoryGB(&u.Memory),
+		})
+	}
+	return result
+}
@@ -0,0 +1,559 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+const (
+	watchKeepaliveInterval = 30 * time.Second
+	watchRetryInterval     = 2 * time.Second
+)
+
+// watchKind describes a resource kind that can be streamed by HandleWatch. Deltas come from the
+// shared informer of the resource cache; list and watch are only used when there is no cache.
+type watchKind struct {
+	namespaced bool
+	informer   func(rc *k8s.ResourceCache) cache.SharedIndexInformer
+	deltas     func(rc *k8s.ResourceCache) *k8s.DeltaRing
+	list       func(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]gin.H, string, error)
+	watch      func(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) (watch.Interface, error)
+	summarize  func(obj runtime.Object) (gin.H, bool)
+}
+
+var watchKinds = map[string]watchKind{
+	"pods": {
+		namespaced: true,
+		informer:   (*k8s.ResourceCache).PodInformer,
+		deltas:     (*k8s.ResourceCache).PodDeltas,
+		list: func(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]gin.H, string, error) {
+			list, err := client.CoreV1().Pods(namespace).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			items := make([]gin.H, 0, len(list.Items))
+			for i := range list.Items {
+				items = append(items, podSummary(&list.Items[i]))
+			}
+			return items, list.ResourceVersion, nil
+		},
+		watch: func(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().Pods(namespace).Watch(ctx, opts)
+		},
+		summarize: func(obj runtime.Object) (gin.H, bool) {
+			pod, ok := obj.(*v1.Pod)
+			if !ok {
+				return nil, false
+			}
+			return podSummary(pod), true
+		},
+	},
+	"nodes": {
+		namespaced: false,
+		informer:   (*k8s.ResourceCache).NodeInformer,
+		deltas:     (*k8s.ResourceCache).NodeDeltas,
+		list: func(ctx context.Context, client kubernetes.Interface, _ string, opts metav1.ListOptions) ([]gin.H, string, error) {
+			list, err := client.CoreV1().Nodes().List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			items := make([]gin.H, 0, len(list.Items))
+			for i := range list.Items {
+				items = append(items, nodeSummary(&list.Items[i], nil))
+			}
+			return items, list.ResourceVersion, nil
+		},
+		watch: func(ctx context.Context, client kubernetes.Interface, _ string, opts metav1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().Nodes().Watch(ctx, opts)
+		},
+		summarize: func(obj runtime.Object) (gin.H, bool) {
+			node, ok := obj.(*v1.Node)
+			if !ok {
+				return nil, false
+			}
+			return nodeSummary(node, nil), true
+		},
+	},
+}
+
+// watchEvent is a single delta, bookmark or snapshot produced by a per-kind watch loop.
+type watchEvent struct {
+	kind            string
+	eventType       string
+	resourceVersion string
+	data            gin.H
+}
+
+// HandleWatch streams ADDED, MODIFIED and DELETED deltas for the requested kinds as Server-Sent Events.
+// Each kind starts with a SNAPSHOT event in the same shape as the list endpoints. Every event carries
+// an id encoding the resourceVersion of each kind, so a reconnecting EventSource resumes from where it
+// left off via Last-Event-ID (or the resourceVersion query parameter). Deltas are served from the
+// resource cache's informers, so open streams don't each watch the API server.
+func (h *Handlers) HandleWatch(c *gin.Context) {
+	kinds := strings.Split(c.DefaultQuery("kinds", "pods,nodes"), ",")
+
+	requested := make(map[string]watchKind)
+	for _, name := range kinds {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		kind, ok := watchKinds[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported kind: %s", name)})
+			return
+		}
+		requested[name] = kind
+	}
+	if len(requested) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one kind is required"})
+		return
+	}
+
+	namespace := c.DefaultQuery("namespace", "")
+	if namespace == "*" || namespace == "all" {
+		namespace = metav1.NamespaceAll
+	}
+
+	labelSelector := c.Query("labelSelector")
+	fieldSelector := c.Query("fieldSelector")
+	selector, err := k8s.NewObjectSelector(namespace, labelSelector, fieldSelector)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The cache only evaluates the fields PodFields and NodeFields set; any other field would match nothing.
+	for name := range requested {
+		if err := k8s.ValidateFieldSelector(name, fieldSelector); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	cursorStr := c.GetHeader("Last-Event-ID")
+	if rv := c.Query("resourceVersion"); rv != "" {
+		cursorStr = rv
+	}
+	cursor := parseWatchCursor(cursorStr)
+
+	user, _ := c.Get("user")
+	h.logger.WithFields(logrus.Fields{
+		"user":      user,
+		"kinds":     kinds,
+		"namespace": namespace,
+		"resume":    cursorStr != "",
+	}).Info("Starting resource watch stream")
+
+	ctx := c.Request.Context()
+	events := make(chan watchEvent, 64)
+	for name, kind := range requested {
+		kindNamespace := namespace
+		if !kind.namespaced {
+			kindNamespace = metav1.NamespaceAll
+		}
+		if h.cache != nil {
+			go h.runCacheWatch(ctx, name, kind, selector, cursor[name], events)
+			continue
+		}
+		go h.runWatch(ctx, name, kind, kindNamespace, labelSelector, fieldSelector, cursor[name], events)
+	}
+
+	startSSE(c)
+
+	keepalive := time.NewTicker(watchKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.WithField("user", user).Info("Resource watch stream closed")
+			return
+		case <-keepalive.C:
+			if err := writeSSEComment(c, "keepalive"); err != nil {
+				return
+			}
+		case ev := <-events:
+			switch {
+			case ev.resourceVersion != "":
+				cursor[ev.kind] = ev.resourceVersion
+			case ev.eventType == "SNAPSHOT":
+				// A snapshot without a version can't be resumed from.
+				delete(cursor, ev.kind)
+			}
+			if err := writeSSE(c, formatWatchCursor(cursor), ev.eventType, ev.data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// runCacheWatch streams a single kind from the shared informer of the resource cache, so clients
+// add no load on the API server. A client resumes from resourceVersion by replaying the cache's
+// recent deltas, and gets a fresh snapshot only if the version is older than the deltas kept.
+func (h *Handlers) runCacheWatch(ctx context.Context, name string, kind watchKind, selector *k8s.ObjectSelector,
+	resourceVersion string, out chan<- watchEvent) {
+	send := func(ev watchEvent) bool {
+		select {
+		case out <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	informer := kind.informer(h.cache)
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return
+	}
+	ring := kind.deltas(h.cache)
+
+	// Deltas of objects outside the selection still advance the version, which is sent as a bookmark
+	// when the stream is idle so that a reconnecting client doesn't fall behind the ring.
+	var handled, sent uint64
+	bookmark := time.NewTicker(watchKeepaliveInterval)
+	defer bookmark.Stop()
+
+	forward := func(d k8s.Delta) bool {
+		if d.ResourceVersion > handled {
+			handled = d.ResourceVersion
+			resourceVersion = strconv.FormatUint(handled, 10)
+		}
+		ev, ok := deltaWatchEvent(name, kind, selector, d)
+		if !ok {
+			return true
+		}
+		if d.ResourceVersion > sent {
+			sent = d.ResourceVersion
+		}
+		return send(ev)
+	}
+
+	for {
+		sub, replay, resumed := ring.Subscribe(resourceVersion)
+		if !resumed {
+			// The subscription is taken first, so anything the cache sees from now on is delivered
+			// as a delta; those the snapshot already reflects are applied idempotently by key.
+			items, rv := cacheSnapshot(informer, kind, selector)
+			if version, err := strconv.ParseUint(rv, 10, 64); err == nil && sub.Version() > version {
+				rv = strconv.FormatUint(sub.Version(), 10)
+			}
+			if !send(watchEvent{
+				kind:            name,
+				eventType:       "SNAPSHOT",
+				resourceVersion: rv,
+				data:            gin.H{"kind": name, name: items, "resourceVersion": rv},
+			}) {
+				sub.Close()
+				return
+			}
+			resourceVersion = rv
+		}
+		handled, _ = strconv.ParseUint(resourceVersion, 10, 64)
+		sent = handled
+
+		for _, d := range replay {
+			if !forward(d) {
+				sub.Close()
+				return
+			}
+		}
+
+	stream:
+		for {
+			select {
+			case <-ctx.Done():
+				sub.Close()
+				return
+			case <-bookmark.C:
+				if handled > sent {
+					sent = handled
+					if !send(watchEvent{
+						kind:            name,
+						eventType:       "BOOKMARK",
+						resourceVersion: resourceVersion,
+						data:            gin.H{"kind": name, "resourceVersion": resourceVersion},
+					}) {
+						sub.Close()
+						return
+					}
+				}
+			case d, ok := <-sub.Deltas():
+				if !ok {
+					// The client fell behind or the cache relisted; resume from the last delta handled.
+					break stream
+				}
+				if !forward(d) {
+					sub.Close()
+					return
+				}
+			}
+		}
+	}
+}
+
+// deltaWatchEvent builds the event sent for a delta of the cache. An object whose labels or fields
+// change moves in or out of the selection, as in an API watch.
+func deltaWatchEvent(name string, kind watchKind, selector *k8s.ObjectSelector, d k8s.Delta) (watchEvent, bool) {
+	eventType := d.Type
+	if d.Type == watch.Modified {
+		wasSelected, isSelected := selector.Matches(d.Old), selector.Matches(d.Object)
+		switch {
+		case wasSelected && isSelected:
+		case isSelected:
+			eventType = watch.Added
+		case wasSelected:
+			eventType = watch.Deleted
+		default:
+			return watchEvent{}, false
+		}
+	} else if !selector.Matches(d.Object) {
+		return watchEvent{}, false
+	}
+
+	resourceVersion := ""
+	if d.ResourceVersion != 0 {
+		resourceVersion = strconv.FormatUint(d.ResourceVersion, 10)
+	}
+	return cacheWatchEvent(name, kind, eventType, resourceVersion, d.Object)
+}
+
+// cacheSnapshot returns the summaries of the cached objects matching selector, sorted by namespace
+// and name, and the resourceVersion to resume from. The cache's own resourceVersion can be ahead of
+// its contents, so the snapshot carries the version of the newest object in it; resourceVersions are
+// compared as the etcd revisions they are, and an empty version forces a new snapshot on resume.
+func cacheSnapshot(informer cache.SharedIndexInformer, kind watchKind, selector *k8s.ObjectSelector) ([]gin.H, string) {
+	var objs []metav1.Object
+	for _, obj := range informer.GetStore().List() {
+		if accessor, err := meta.Accessor(obj); err == nil && selector.Matches(obj) {
+			objs = append(objs, accessor)
+		}
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		if objs[i].GetNamespace() != objs[j].GetNamespace() {
+			return objs[i].GetNamespace() < objs[j].GetNamespace()
+		}
+		return objs[i].GetName() < objs[j].GetName()
+	})
+
+	items := make([]gin.H, 0, len(objs))
+	var newest uint64
+	comparable := true
+	for _, obj := range objs {
+		runtimeObj, ok := obj.(runtime.Object)
+		if !ok {
+			continue
+		}
+		summary, ok := kind.summarize(runtimeObj)
+		if !ok {
+			continue
+		}
+		items = append(items, summary)
+
+		version, err := strconv.ParseUint(obj.GetResourceVersion(), 10, 64)
+		if err != nil {
+			comparable = false
+		} else if version > newest {
+			newest = version
+		}
+	}
+	if !comparable || newest == 0 {
+		return items, ""
+	}
+	return items, strconv.FormatUint(newest, 10)
+}
+
+// cacheWatchEvent builds the event sent for an object of the cache. An empty resourceVersion leaves
+// the client's cursor where it is.
+func cacheWatchEvent(name string, kind watchKind, eventType watch.EventType, resourceVersion string, obj interface{}) (watchEvent, bool) {
+	runtimeObj, ok := obj.(runtime.Object)
+	if !ok {
+		return watchEvent{}, false
+	}
+	summary, ok := kind.summarize(runtimeObj)
+	if !ok {
+		return watchEvent{}, false
+	}
+	return watchEvent{
+		kind:            name,
+		eventType:       string(eventType),
+		resourceVersion: resourceVersion,
+		data:            gin.H{"kind": name, "type": string(eventType), "object": summary},
+	}, true
+}
+
+// runWatch lists and watches a single kind against the API server when there is no resource cache,
+// resuming from resourceVersion and falling back to a fresh snapshot whenever the API server reports
+// that the version is too old.
+func (h *Handlers) runWatch(ctx context.Context, name string, kind watchKind, namespace, labelSelector, fieldSelector,
+	resourceVersion string, out chan<- watchEvent) {
+	client := h.podManager.GetClient()
+	send := func(ev watchEvent) bool {
+		select {
+		case out <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for ctx.Err() == nil {
+		if resourceVersion == "" {
+			// ResourceVersion "0" lets the API server answer from its watch cache.
+			items, rv, err := kind.list(ctx, client, namespace, metav1.ListOptions{
+				LabelSelector:   labelSelector,
+				FieldSelector:   fieldSelector,
+				ResourceVersion: "0",
+			})
+			if err != nil {
+				h.logger.WithError(err).WithField("kind", name).Warn("Failed to list resources for watch snapshot")
+				if !send(watchEvent{kind: name, eventType: "ERROR", data: gin.H{"kind": name, "error": "Failed to list " + name}}) {
+					return
+				}
+				if !sleepContext(ctx, watchRetryInterval) {
+					return
+				}
+				continue
+			}
+			resourceVersion = rv
+			if !send(watchEvent{
+				kind:            name,
+				eventType:       "SNAPSHOT",
+				resourceVersion: rv,
+				data:            gin.H{"kind": name, name: items, "resourceVersion": rv},
+			}) {
+				return
+			}
+		}
+
+		w, err := kind.watch(ctx, client, namespace, metav1.ListOptions{
+			LabelSelector:       labelSelector,
+			FieldSelector:       fieldSelector,
+			ResourceVersion:     resourceVersion,
+			AllowWatchBookmarks: true,
+		})
+		if err != nil {
+			if errors.IsResourceExpired(err) || errors.IsGone(err) {
+				resourceVersion = ""
+				continue
+			}
+			h.logger.WithError(err).WithField("kind", name).Warn("Failed to start watch")
+			if !sleepContext(ctx, watchRetryInterval) {
+				return
+			}
+			continue
+		}
+
+		resourceVersion = h.drainWatch(ctx, name, kind, w, resourceVersion, send)
+		w.Stop()
+	}
+}
+
+// drainWatch forwards events from a single watch until it closes and returns the resourceVersion
+// to resume from. An empty result means the version expired and a new snapshot is required.
+func (h *Handlers) drainWatch(ctx context.Context, name string, kind watchKind, w watch.Interface, resourceVersion string,
+	send func(watchEvent) bool) string {
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return resourceVersion
+			}
+
+			switch ev.Type {
+			case watch.Added, watch.Modified, watch.Deleted:
+				accessor, err := meta.Accessor(ev.Object)
+				if err != nil {
+					continue
+				}
+				resourceVersion = accessor.GetResourceVersion()
+				summary, ok := kind.summarize(ev.Object)
+				if !ok {
+					continue
+				}
+				if !send(watchEvent{
+					kind:            name,
+					eventType:       string(ev.Type),
+					resourceVersion: resourceVersion,
+					data:            gin.H{"kind": name, "type": string(ev.Type), "object": summary},
+				}) {
+					return resourceVersion
+				}
+			case watch.Bookmark:
+				accessor, err := meta.Accessor(ev.Object)
+				if err != nil {
+					continue
+				}
+				resourceVersion = accessor.GetResourceVersion()
+				if !send(watchEvent{
+					kind:            name,
+					eventType:       "BOOKMARK",
+					resourceVersion: resourceVersion,
+					data:            gin.H{"kind": name, "resourceVersion": resourceVersion},
+				}) {
+					return resourceVersion
+				}
+			case watch.Error:
+				status := errors.FromObject(ev.Object)
+				if errors.IsResourceExpired(status) || errors.IsGone(status) {
+					return ""
+				}
+				h.logger.WithError(status).WithField("kind", name).Warn("Watch returned an error")
+				return resourceVersion
+			}
+		}
+	}
+}
+
+// parseWatchCursor parses a "pods:123,nodes:456" cursor into resourceVersions keyed by kind.
+func parseWatchCursor(cursor string) map[string]string {
+	result := make(map[string]string)
+	for _, part := range strings.Split(cursor, ",") {
+		kind, rv, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if ok && kind != "" && rv != "" {
+			result[kind] = rv
+		}
+	}
+	return result
+}
+
+// formatWatchCursor formats resourceVersions keyed by kind as a stable "pods:123,nodes:456" string.
+func formatWatchCursor(cursor map[string]string) string {
+	parts := make([]string, 0, len(cursor))
+	for kind, rv := range cursor {
+		parts = append(parts, kind+":"+rv)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// sleepContext waits for d or until ctx is done. Returns false if the context ended first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
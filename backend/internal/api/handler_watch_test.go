@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+func watchTestPod(namespace, name, app, resourceVersion string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       namespace,
+		Name:            name,
+		Labels:          map[string]string{"app": app},
+		ResourceVersion: resourceVersion,
+	}}
+}
+
+func nextWatchEvent(t *testing.T, events <-chan watchEvent) watchEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a watch event")
+		return watchEvent{}
+	}
+}
+
+func TestRunCacheWatch(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		watchTestPod("default", "web-1", "web", ""),
+		watchTestPod("default", "db-1", "db", ""),
+		watchTestPod("other", "web-2", "web", ""),
+	)
+	// The fake clientset drops events sent before a watch is opened, so wait for the pod informer's watch.
+	watching := make(chan struct{})
+	var watchOnce sync.Once
+	client.PrependWatchReactor("pods", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		w, err := client.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return false, nil, err
+		}
+		watchOnce.Do(func() { close(watching) })
+		return true, w, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rc, err := k8s.NewResourceCache(client, 0)
+	if err != nil {
+		t.Fatalf("NewResourceCache() error = %v", err)
+	}
+	rc.Start(ctx)
+
+	h := &Handlers{logger: logrus.New(), cache: rc}
+	selector, err := k8s.NewObjectSelector("default", "app=web", "")
+	if err != nil {
+		t.Fatalf("NewObjectSelector() error = %v", err)
+	}
+	events := make(chan watchEvent, 16)
+	go h.runCacheWatch(ctx, "pods", watchKinds["pods"], selector, "", events)
+
+	snapshot := nextWatchEvent(t, events)
+	if snapshot.eventType != "SNAPSHOT" {
+		t.Fatalf("first event = %s, want SNAPSHOT", snapshot.eventType)
+	}
+	items, _ := snapshot.data["pods"].([]gin.H)
+	if len(items) != 1 || items[0]["name"] != "web-1" {
+		t.Fatalf("snapshot = %v, want only web-1", items)
+	}
+	<-watching
+
+	pods := client.CoreV1().Pods("default")
+	if _, err := pods.Create(ctx, watchTestPod("default", "web-3", "web", ""), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// A pod created outside the selection is not sent.
+	if _, err := pods.Create(ctx, watchTestPod("default", "db-2", "db", ""), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Relabelling a pod out of the selection deletes it from the client's view.
+	if _, err := pods.Update(ctx, watchTestPod("default", "web-1", "db", ""), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pods.Delete(ctx, "web-3", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Informers only order the deltas of each object.
+	got := map[string]int{}
+	for i := 0; i < 3; i++ {
+		ev := nextWatchEvent(t, events)
+		object, _ := ev.data["object"].(gin.H)
+		got[fmt.Sprintf("%s %v", ev.eventType, object["name"])] = i
+	}
+	for _, want := range []string{"ADDED web-3", "DELETED web-1", "DELETED web-3"} {
+		if _, ok := got[want]; !ok {
+			t.Errorf("events = %v, want %s", got, want)
+		}
+	}
+	if got["ADDED web-3"] > got["DELETED web-3"] {
+		t.Errorf("events = %v, want web-3 added before it is deleted", got)
+	}
+}
+
+func TestRunCacheWatchResume(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		watchTestPod("default", "web-1", "web", "10"),
+		watchTestPod("default", "db-1", "db", "20"),
+	)
+	// The fake clientset doesn't version its lists; the informer's initial list is at 20.
+	client.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		obj, err := client.Tracker().List(v1.SchemeGroupVersion.WithResource("pods"), v1.SchemeGroupVersion.WithKind("Pod"), action.GetNamespace())
+		if err != nil {
+			return false, nil, err
+		}
+		list := obj.(*v1.PodList)
+		list.ResourceVersion = "20"
+		return true, list, nil
+	})
+	watching := make(chan struct{})
+	var watchOnce sync.Once
+	client.PrependWatchReactor("pods", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		w, err := client.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return false, nil, err
+		}
+		watchOnce.Do(func() { close(watching) })
+		return true, w, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rc, err := k8s.NewResourceCache(client, 0)
+	if err != nil {
+		t.Fatalf("NewResourceCache() error = %v", err)
+	}
+	rc.Start(ctx)
+
+	h := &Handlers{logger: logrus.New(), cache: rc}
+	selector, err := k8s.NewObjectSelector("default", "app=web", "")
+	if err != nil {
+		t.Fatalf("NewObjectSelector() error = %v", err)
+	}
+
+	// The first connection gets a snapshot and one delta, then goes away.
+	firstCtx, firstCancel := context.WithCancel(ctx)
+	first := make(chan watchEvent, 16)
+	go h.runCacheWatch(firstCtx, "pods", watchKinds["pods"], selector, "", first)
+	if ev := nextWatchEvent(t, first); ev.eventType != "SNAPSHOT" {
+		t.Fatalf("first event = %s, want SNAPSHOT", ev.eventType)
+	}
+	<-watching
+
+	pods := client.CoreV1().Pods("default")
+	if _, err := pods.Create(ctx, watchTestPod("default", "web-2", "web", "21"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	added := nextWatchEvent(t, first)
+	if added.eventType != "ADDED" || added.resourceVersion != "21" {
+		t.Fatalf("event = %s at %q, want ADDED at 21", added.eventType, added.resourceVersion)
+	}
+	firstCancel()
+
+	// Unrelated churn while the client is away, and one pod it should be told about.
+	barrier, _, _ := rc.PodDeltas().Subscribe("")
+	defer barrier.Close()
+	for _, pod := range []*v1.Pod{
+		watchTestPod("default", "db-2", "db", "22"),
+		watchTestPod("other", "web-9", "web", "23"),
+	} {
+		if _, err := client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := pods.Update(ctx, watchTestPod("default", "db-1", "db", "24"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, pod := range []*v1.Pod{
+		watchTestPod("default", "web-3", "web", "25"),
+		watchTestPod("default", "db-3", "db", "26"),
+	} {
+		if _, err := pods.Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for recorded := 0; recorded < 5; recorded++ {
+		select {
+		case <-barrier.Deltas():
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the cache to record the churn")
+		}
+	}
+
+	resumed := make(chan watchEvent, 16)
+	go h.runCacheWatch(ctx, "pods", watchKinds["pods"], selector, added.resourceVersion, resumed)
+	ev := nextWatchEvent(t, resumed)
+	object, _ := ev.data["object"].(gin.H)
+	if ev.eventType != "ADDED" || object["name"] != "web-3" || ev.resourceVersion != "25" {
+		t.Fatalf("resumed event = %s %v at %q, want ADDED web-3 at 25", ev.eventType, object["name"], ev.resourceVersion)
+	}
+
+	// A version older than the recorded deltas needs a snapshot.
+	stale := make(chan watchEvent, 16)
+	go h.runCacheWatch(ctx, "pods", watchKinds["pods"], selector, "5", stale)
+	snapshot := nextWatchEvent(t, stale)
+	if snapshot.eventType != "SNAPSHOT" {
+		t.Fatalf("stale event = %s, want SNAPSHOT", snapshot.eventType)
+	}
+	if items, _ := snapshot.data["pods"].([]gin.H); len(items) != 3 {
+		t.Errorf("snapshot = %v, want web-1, web-2 and web-3", items)
+	}
+	if snapshot.resourceVersion != "26" {
+		t.Errorf("snapshot resourceVersion = %q, want 26", snapshot.resourceVersion)
+	}
+}
+
+func TestCacheSnapshot(t *testing.T) {
+	tests := []struct {
+		name      string
+		pods      []*v1.Pod
+		wantNames []string
+		wantRV    string
+	}{
+		{
+			name: "newest selected version",
+			pods: []*v1.Pod{
+				watchTestPod("default", "web-b", "web", "12"),
+				watchTestPod("default", "web-a", "web", "5"),
+				watchTestPod("default", "db", "db", "20"),
+				watchTestPod("apps", "web-c", "web", "7"),
+			},
+			wantNames: []string{"web-c", "web-a", "web-b"},
+			wantRV:    "12",
+		},
+		{
+			name:      "unparseable version",
+			pods:      []*v1.Pod{watchTestPod("default", "web-a", "web", "5"), watchTestPod("default", "web-b", "web", "x")},
+			wantNames: []string{"web-a", "web-b"},
+			wantRV:    "",
+		},
+		{
+			name:      "empty",
+			wantNames: []string{},
+			wantRV:    "",
+		},
+	}
+	selector, err := k8s.NewObjectSelector("", "app=web", "")
+	if err != nil {
+		t.Fatalf("NewObjectSelector() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &v1.Pod{}, 0, cache.Indexers{})
+			for _, pod := range tt.pods {
+				if err := informer.GetStore().Add(pod); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			items, rv := cacheSnapshot(informer, watchKinds["pods"], selector)
+			if rv != tt.wantRV {
+				t.Errorf("resourceVersion = %q, want %q", rv, tt.wantRV)
+			}
+			names := make([]string, 0, len(items))
+			for _, item := range items {
+				names = append(names, item["name"].(string))
+			}
+			if len(names) != len(tt.wantNames) {
+				t.Fatalf("names = %v, want %v", names, tt.wantNames)
+			}
+			for i := range names {
+				if names[i] != tt.wantNames[i] {
+					t.Errorf("names = %v, want %v", names, tt.wantNames)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestWatchCursor(t *testing.T) {
+	cursor := parseWatchCursor(" nodes:456, pods:123,bogus,:1,events:")
+	if len(cursor) != 2 || cursor["pods"] != "123" || cursor["nodes"] != "456" {
+		t.Fatalf("parseWatchCursor() = %v", cursor)
+	}
+	if got := formatWatchCursor(cursor); got != "nodes:456,pods:123" {
+		t.Errorf("formatWatchCursor() = %q, want nodes:456,pods:123", got)
+	}
+}
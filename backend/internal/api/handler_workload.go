@@ -0,0 +1,419 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// workloadKind describes how to list and get one of the supported workload resources.
+type workloadKind struct {
+	list func(ctx context.Context, client kubernetes.Interface, namespace string) ([]gin.H, error)
+	get  func(ctx context.Context, client kubernetes.Interface, namespace, name string) (gin.H, types.UID, error)
+}
+
+var workloadKinds = map[string]workloadKind{
+	"deployments": {
+		list: func(ctx context.Context, client kubernetes.Interface, namespace string) ([]gin.H, error) {
+			list, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			items := make([]gin.H, 0, len(list.Items))
+			for i := range list.Items {
+				items = append(items, deploymentSummary(&list.Items[i]))
+			}
+			return items, nil
+		},
+		get: func(ctx context.Context, client kubernetes.Interface, namespace, name string) (gin.H, types.UID, error) {
+			obj, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			return deploymentSummary(obj), obj.UID, nil
+		},
+	},
+	"statefulsets": {
+		list: func(ctx context.Context, client kubernetes.Interface, namespace string) ([]gin.H, error) {
+			list, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			items := make([]gin.H, 0, len(list.Items))
+			for i := range list.Items {
+				items = append(items, statefulSetSummary(&list.Items[i]))
+			}
+			return items, nil
+		},
+		get: func(ctx context.Context, client kubernetes.Interface, namespace, name string) (gin.H, types.UID, error) {
+			obj, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			return statefulSetSummary(obj), obj.UID, nil
+		},
+	},
+	"daemonsets": {
+		list: func(ctx context.Context, client kubernetes.Interface, namespace string) ([]gin.H, error) {
+			list, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			items := make([]gin.H, 0, len(list.Items))
+			for i := range list.Items {
+				items = append(items, daemonSetSummary(&list.Items[i]))
+			}
+			return items, nil
+		},
+		get: func(ctx context.Context, client kubernetes.Interface, namespace, name string) (gin.H, types.UID, error) {
+			obj, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			return daemonSetSummary(obj), obj.UID, nil
+		},
+	},
+	"replicasets": {
+		list: func(ctx context.Context, client kubernetes.Interface, namespace string) ([]gin.H, error) {
+			list, err := client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			items := make([]gin.H, 0, len(list.Items))
+			for i := range list.Items {
+				items = append(items, replicaSetSummary(&list.Items[i]))
+			}
+			return items, nil
+		},
+		get: func(ctx context.Context, client kubernetes.Interface, namespace, name string) (gin.H, types.UID, error) {
+			obj, err := client.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			return replicaSetSummary(obj), obj.UID, nil
+		},
+	},
+	"jobs": {
+		list: func(ctx context.Context, client kubernetes.Interface, namespace string) ([]gin.H, error) {
+			list, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			items := make([]gin.H, 0, len(list.Items))
+			for i := range list.Items {
+				items = append(items, jobSummary(&list.Items[i]))
+			}
+			return items, nil
+		},
+		get: func(ctx context.Context, client kubernetes.Interface, namespace, name string) (gin.H, types.UID, error) {
+			obj, err := client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return nil, "", err
+			}
+			return jobSummary(obj), obj.UID, nil
+		},
+	},
+}
+
+// HandleListWorkloads lists workloads of one kind (deployments, statefulsets, daemonsets, replicasets or jobs)
+// in a namespace or all namespaces.
+func (h *Handlers) HandleListWorkloads(c *gin.Context) {
+	kindName := c.Param("kind")
+	kind, ok := workloadKinds[kindName]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported workload kind: %s", kindName)})
+		return
+	}
+
+	namespace := c.DefaultQuery("namespace", "default")
+	if namespace == "*" || namespace == "all" {
+		namespace = metav1.NamespaceAll
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	items, err := kind.list(ctx, h.podManager.GetClient(), namespace)
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"kind":      kindName,
+			"namespace": namespace,
+		}).Error("Failed to list workloads")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list " + kindName})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{kindName: items})
+}
+
+// HandleGetWorkload gets a single workload together with the pods it owns.
+func (h *Handlers) HandleGetWorkload(c *gin.Context) {
+	kindName := c.Param("kind")
+	kind, ok := workloadKinds[kindName]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Unsupported workload kind: %s", kindName)})
+		return
+	}
+
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	summary, uid, err := kind.get(ctx, h.podManager.GetClient(), namespace, name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workload not found"})
+			return
+		}
+		h.logger.WithError(err).WithField("kind", kindName).Error("Failed to get workload")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get workload"})
+		return
+	}
+
+	pods, err := h.ownedPods(ctx, kindName, namespace, uid)
+	if err != nil {
+		h.logger.WithError(err).WithField("kind", kindName).Warn("Failed to resolve owned pods")
+		pods = nil
+	}
+
+	podList := make([]gin.H, 0, len(pods))
+	for _, pod := range pods {
+		podList = append(podList, podSummary(pod))
+	}
+	summary["pods"] = podList
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// ownedPods returns the pods controlled by a workload. Deployments own pods through their ReplicaSets.
+func (h *Handlers) ownedPods(ctx context.Context, kindName, namespace string, uid types.UID) ([]*v1.Pod, error) {
+	owners := map[types.UID]bool{uid: true}
+
+	if kindName == "deployments" {
+		replicaSets, err := h.podManager.GetClient().AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		owners = make(map[types.UID]bool)
+		for i := range replicaSets.Items {
+			rs := &replicaSets.Items[i]
+			if controllerUID(rs.OwnerReferences) == uid {
+				owners[rs.UID] = true
+			}
+		}
+	}
+
+	pods, err := h.listPods(ctx, namespace, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]*v1.Pod, 0)
+	for _, pod := range pods {
+		if owners[controllerUID(pod.OwnerReferences)] {
+			owned = append(owned, pod)
+		}
+	}
+	return owned, nil
+}
+
+// controllerUID returns the UID of the controlling owner reference, if any.
+func controllerUID(refs []metav1.OwnerReference) types.UID {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller {
+			return refs[i].UID
+		}
+	}
+	return ""
+}
+
+func deploymentSummary(d *appsv1.Deployment) gin.H {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+
+	conditions := make([]gin.H, 0, len(d.Status.Conditions))
+	for i := range d.Status.Conditions {
+		cond := &d.Status.Conditions[i]
+		conditions = append(conditions, workloadCondition(string(cond.Type), string(cond.Status),
+			cond.Reason, cond.Message, cond.LastTransitionTime.Time))
+	}
+
+	summary := workloadMeta("Deployment", &d.ObjectMeta, d.Spec.Selector, &d.Spec.Template.Spec)
+	summary["replicas"] = gin.H{
+		"desired":   desired,
+		"current":   d.Status.Replicas,
+		"ready":     d.Status.ReadyReplicas,
+		"available": d.Status.AvailableReplicas,
+		"updated":   d.Status.UpdatedReplicas,
+	}
+	summary["strategy"] = string(d.Spec.Strategy.Type)
+	summary["paused"] = d.Spec.Paused
+	summary["revision"] = d.Annotations[k8s.DeploymentRevisionAnnotation]
+	summary["conditions"] = conditions
+	return summary
+}
+
+func statefulSetSummary(s *appsv1.StatefulSet) gin.H {
+	desired := int32(1)
+	if s.Spec.Replicas != nil {
+		desired = *s.Spec.Replicas
+	}
+
+	conditions := make([]gin.H, 0, len(s.Status.Conditions))
+	for i := range s.Status.Conditions {
+		cond := &s.Status.Conditions[i]
+		conditions = append(conditions, workloadCondition(string(cond.Type), string(cond.Status),
+			cond.Reason, cond.Message, cond.LastTransitionTime.Time))
+	}
+
+	summary := workloadMeta("StatefulSet", &s.ObjectMeta, s.Spec.Selector, &s.Spec.Template.Spec)
+	summary["replicas"] = gin.H{
+		"desired":   desired,
+		"current":   s.Status.Replicas,
+		"ready":     s.Status.ReadyReplicas,
+		"available": s.Status.AvailableReplicas,
+		"updated":   s.Status.UpdatedReplicas,
+	}
+	summary["serviceName"] = s.Spec.ServiceName
+	summary["strategy"] = string(s.Spec.UpdateStrategy.Type)
+	summary["currentRevision"] = s.Status.CurrentRevision
+	summary["updateRevision"] = s.Status.UpdateRevision
+	summary["conditions"] = conditions
+	return summary
+}
+
+func daemonSetSummary(d *appsv1.DaemonSet) gin.H {
+	conditions := make([]gin.H, 0, len(d.Status.Conditions))
+	for i := range d.Status.Conditions {
+		cond := &d.Status.Conditions[i]
+		conditions = append(conditions, workloadCondition(string(cond.Type), string(cond.Status),
+			cond.Reason, cond.Message, cond.LastTransitionTime.Time))
+	}
+
+	summary := workloadMeta("DaemonSet", &d.ObjectMeta, d.Spec.Selector, &d.Spec.Template.Spec)
+	summary["replicas"] = gin.H{
+		"desired":   d.Status.DesiredNumberScheduled,
+		"current":   d.Status.CurrentNumberScheduled,
+		"ready":     d.Status.NumberReady,
+		"available": d.Status.NumberAvailable,
+		"updated":   d.Status.UpdatedNumberScheduled,
+	}
+	summary["misscheduled"] = d.Status.NumberMisscheduled
+	summary["strategy"] = string(d.Spec.UpdateStrategy.Type)
+	summary["nodeSelector"] = d.Spec.Template.Spec.NodeSelector
+	summary["conditions"] = conditions
+	return summary
+}
+
+func replicaSetSummary(r *appsv1.ReplicaSet) gin.H {
+	desired := int32(1)
+	if r.Spec.Replicas != nil {
+		desired = *r.Spec.Replicas
+	}
+
+	conditions := make([]gin.H, 0, len(r.Status.Conditions))
+	for i := range r.Status.Conditions {
+		cond := &r.Status.Conditions[i]
+		conditions = append(conditions, workloadCondition(string(cond.Type), string(cond.Status),
+			cond.Reason, cond.Message, cond.LastTransitionTime.Time))
+	}
+
+	summary := workloadMeta("ReplicaSet", &r.ObjectMeta, r.Spec.Selector, &r.Spec.Template.Spec)
+	summary["replicas"] = gin.H{
+		"desired":   desired,
+		"current":   r.Status.Replicas,
+		"ready":     r.Status.ReadyReplicas,
+		"available": r.Status.AvailableReplicas,
+	}
+	summary["revision"] = r.Annotations[k8s.DeploymentRevisionAnnotation]
+	summary["conditions"] = conditions
+	return summary
+}
+
+func jobSummary(j *batchv1.Job) gin.H {
+	completions := int32(1)
+	if j.Spec.Completions != nil {
+		completions = *j.Spec.Completions
+	}
+	parallelism := int32(1)
+	if j.Spec.Parallelism != nil {
+		parallelism = *j.Spec.Parallelism
+	}
+
+	conditions := make([]gin.H, 0, len(j.Status.Conditions))
+	for i := range j.Status.Conditions {
+		cond := &j.Status.Conditions[i]
+		conditions = append(conditions, workloadCondition(string(cond.Type), string(cond.Status),
+			cond.Reason, cond.Message, cond.LastTransitionTime.Time))
+	}
+
+	var startTime, completionTime *time.Time
+	duration := ""
+	if j.Status.StartTime != nil {
+		startTime = &j.Status.StartTime.Time
+		end := time.Now()
+		if j.Status.CompletionTime != nil {
+			completionTime = &j.Status.CompletionTime.Time
+			end = j.Status.CompletionTime.Time
+		}
+		duration = end.Sub(j.Status.StartTime.Time).Round(time.Second).String()
+	}
+
+	summary := workloadMeta("Job", &j.ObjectMeta, j.Spec.Selector, &j.Spec.Template.Spec)
+	summary["completions"] = completions
+	summary["parallelism"] = parallelism
+	summary["active"] = j.Status.Active
+	summary["succeeded"] = j.Status.Succeeded
+	summary["failed"] = j.Status.Failed
+	summary["startTime"] = startTime
+	summary["completionTime"] = completionTime
+	summary["duration"] = duration
+	summary["conditions"] = conditions
+	return summary
+}
+
+// workloadMeta returns the fields shared by every workload summary.
+func workloadMeta(kind string, meta *metav1.ObjectMeta, selector *metav1.LabelSelector, podSpec *v1.PodSpec) gin.H {
+	images := make([]string, 0, len(podSpec.Containers))
+	for i := range podSpec.Containers {
+		images = append(images, podSpec.Containers[i].Image)
+	}
+
+	return gin.H{
+		"kind":            kind,
+		"name":            meta.Name,
+		"namespace":       meta.Namespace,
+		"uid":             meta.UID,
+		"age":             time.Since(meta.CreationTimestamp.Time).Round(time.Second).String(),
+		"labels":          meta.Labels,
+		"selector":        metav1.FormatLabelSelector(selector),
+		"images":          images,
+		"ownerReferences": describeOwnerReferences(meta.OwnerReferences),
+	}
+}
+
+func workloadCondition(condType, status, reason, message string, lastTransition time.Time) gin.H {
+	return gin.H{
+		"type":               condType,
+		"status":             status,
+		"reason":             reason,
+		"message":            message,
+		"lastTransitionTime": lastTransition,
+	}
+}
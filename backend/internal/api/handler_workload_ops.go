@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// workloadOperation performs a mutation on a workload and returns extra details for the audit log and response.
+type workloadOperation func(ctx context.Context, kind, namespace, name string) (gin.H, error)
+
+// HandleScaleWorkload sets the replica count of a Deployment or StatefulSet through the scale subresource.
+func (h *Handlers) HandleScaleWorkload(c *gin.Context) {
+	var req struct {
+		Replicas *int32 `json:"replicas"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Replicas == nil || *req.Replicas < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "replicas must be a non-negative integer"})
+		return
+	}
+
+	h.runWorkloadOperation(c, "scale", func(ctx context.Context, kind, namespace, name string) (gin.H, error) {
+		err := k8s.ScaleWorkload(ctx, h.podManager.GetClient(), kind, namespace, name, *req.Replicas)
+		return gin.H{"replicas": *req.Replicas}, err
+	})
+}
+
+// HandleRestartWorkload triggers a rolling restart of a Deployment or StatefulSet.
+func (h *Handlers) HandleRestartWorkload(c *gin.Context) {
+	h.runWorkloadOperation(c, "restart", func(ctx context.Context, kind, namespace, name string) (gin.H, error) {
+		return nil, k8s.RestartWorkload(ctx, h.podManager.GetClient(), kind, namespace, name)
+	})
+}
+
+// HandleUndoWorkload rolls a Deployment or StatefulSet back to a revision (the previous one if omitted).
+func (h *Handlers) HandleUndoWorkload(c *gin.Context) {
+	var req struct {
+		Revision int64 `json:"revision"`
+	}
+	if err := bindOptionalJSON(c, &req); err != nil || req.Revision < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revision must be a non-negative integer"})
+		return
+	}
+
+	h.runWorkloadOperation(c, "undo", func(ctx context.Context, kind, namespace, name string) (gin.H, error) {
+		revision, err := k8s.UndoWorkload(ctx, h.podManager.GetClient(), kind, namespace, name, req.Revision)
+		return gin.H{"revision": revision}, err
+	})
+}
+
+// HandlePauseWorkload pauses a Deployment rollout.
+func (h *Handlers) HandlePauseWorkload(c *gin.Context) {
+	h.runWorkloadOperation(c, "pause", func(ctx context.Context, kind, namespace, name string) (gin.H, error) {
+		if kind != k8s.KindDeployments {
+			return nil, k8s.ErrUnsupportedKind
+		}
+		return nil, k8s.SetDeploymentPaused(ctx, h.podManager.GetClient(), namespace, name, true)
+	})
+}
+
+// HandleResumeWorkload resumes a paused Deployment rollout.
+func (h *Handlers) HandleResumeWorkload(c *gin.Context) {
+	h.runWorkloadOperation(c, "resume", func(ctx context.Context, kind, namespace, name string) (gin.H, error) {
+		if kind != k8s.KindDeployments {
+			return nil, k8s.ErrUnsupportedKind
+		}
+		return nil, k8s.SetDeploymentPaused(ctx, h.podManager.GetClient(), namespace, name, false)
+	})
+}
+
+// runWorkloadOperation authorizes, runs and audits a workload operation, then responds with the rollout status.
+func (h *Handlers) runWorkloadOperation(c *gin.Context, action string, op workloadOperation) {
+	kind := c.Param("kind")
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+
+	if !h.authorize(c, auth.RoleOperator, action, kind, namespace, name) {
+		return
+	}
+	if kind != k8s.KindDeployments && kind != k8s.KindStatefulSets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is not supported for %s", action, kind)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	details, err := op(ctx, kind, namespace, name)
+	entry := audit.Entry{
+		Action:    action,
+		Resource:  kind,
+		Namespace: namespace,
+		Name:      name,
+		Details:   details,
+		Result:    audit.ResultSuccess,
+	}
+	if err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+	}
+	h.recordAudit(c, entry)
+
+	if err != nil {
+		h.logger.WithError(err).WithFields(logrus.Fields{
+			"action":    action,
+			"kind":      kind,
+			"namespace": namespace,
+			"name":      name,
+		}).Error("Workload operation failed")
+		status, msg := workloadErrorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	rollout, err := k8s.GetRolloutStatus(ctx, h.podManager.GetClient(), kind, namespace, name)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to get rollout status after operation")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"action":  action,
+		"details": details,
+		"rollout": rollout,
+	})
+}
+
+// HandleRolloutStatus returns the rollout status of a Deployment or StatefulSet.
+func (h *Handlers) HandleRolloutStatus(c *gin.Context) {
+	kind := c.Param("kind")
+	namespace := c.DefaultQuery("namespace", "default")
+	name := c.Param("name")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	rollout, err := k8s.GetRolloutStatus(ctx, h.podManager.GetClient(), kind, namespace, name)
+	if err != nil {
+		status, msg := workloadErrorStatus(err)
+		c.JSON(status, gin.H{"error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, rollout)
+}
+
+// workloadErrorStatus maps a workload operation error to an HTTP status and user-facing message.
+func workloadErrorStatus(err error) (status int, message string) {
+	switch {
+	case errors.IsNotFound(err):
+		return http.StatusNotFound, "Workload not found"
+	case stderrors.Is(err, k8s.ErrUnsupportedKind):
+		return http.StatusBadRequest, err.Error()
+	case stderrors.Is(err, k8s.ErrRevisionNotFound):
+		return http.StatusNotFound, err.Error()
+	case stderrors.Is(err, k8s.ErrPaused):
+		return http.StatusConflict, "Rollout is paused; resume it first"
+	case errors.IsConflict(err):
+		return http.StatusConflict, "Workload was modified concurrently, please retry"
+	case errors.IsForbidden(err):
+		return http.StatusForbidden, "Kubrowser's service account is not allowed to do this"
+	default:
+		return http.StatusInternalServerError, "Workload operation failed"
+	}
+}
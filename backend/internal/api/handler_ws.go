@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,8 @@ import (
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
 	"github.com/kubrowser/kubrowser-backend/internal/session"
 )
 
@@ -65,11 +68,36 @@ func (h *Handlers) HandleWebSocket(c *gin.Context) {
 			usernameStr = "anonymous"
 		}
 
+		role := auth.RoleFromContext(c)
+		profile, profileErr := h.sessionProfile(c.Request.Context(), role, usernameStr, c.Query("profile"))
+		if profileErr != nil {
+			sendStatusUpdate(fmt.Sprintf("\r\n\x1b[31m[✗] %s\x1b[0m\r\n", profileErr.Error()))
+			_ = ws.WriteMessage(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, profileErr.Error()))
+			return
+		}
+		if profile != nil {
+			sendStatusUpdate(fmt.Sprintf("\x1b[90mProfile: %s\x1b[0m\r\n", profile.Name))
+		}
+
 		newSessionID := generateSessionID()
 		var pod *v1.Pod
-		pod, err = h.podManager.CreatePodWithStatus(c.Request.Context(), newSessionID, usernameStr, startTime, func(status string) {
-			sendStatusUpdate(status)
-		})
+		pod, err = h.podManager.CreatePodWithStatus(c.Request.Context(), newSessionID, usernameStr, string(role),
+			k8s.PodOptions{Profile: profile}, startTime, func(status string) {
+				sendStatusUpdate(status)
+			})
+		if stderrors.Is(err, k8s.ErrBudgetExceeded) {
+			h.logger.WithError(err).WithField("user", usernameStr).Warn("Refused session over resource budget")
+			_ = ws.WriteMessage(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Kubrowser is at capacity"))
+			return
+		}
+		if stderrors.Is(err, k8s.ErrResourcesNotAllowed) {
+			h.logger.WithError(err).WithField("user", usernameStr).Warn("Refused session over resource limit")
+			_ = ws.WriteMessage(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "The profile's resources exceed your limit"))
+			return
+		}
 		if err != nil {
 			h.logger.WithError(err).Error("Failed to create pod")
 			duration := time.Since(startTime)
@@ -80,7 +108,7 @@ func (h *Handlers) HandleWebSocket(c *gin.Context) {
 			return
 		}
 
-		sess = h.sessionMgr.CreateSession(pod.Name, usernameStr)
+		sess = h.sessionMgr.CreateSession(pod.Name, usernameStr, pod.Labels[k8s.ProfileLabel])
 		sessionID = sess.ID
 
 		// Calculate total duration.
@@ -211,8 +239,6 @@ func (h *Handlers) HandleWebSocket(c *gin.Context) {
 		}
 	}()
 
-	containerName := "terminal"
-
 	// Check if pod is still running before exec.
 	pod, err := h.podManager.GetPod(ctx, sess.PodName)
 	if err != nil {
@@ -229,6 +255,8 @@ func (h *Handlers) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	containerName := k8s.TerminalContainerName(pod)
+
 	// Stream terminal - this blocks until connection closes or context is canceled.
 	h.logger.WithFields(logrus.Fields{
 		"session_id": sessionID,
@@ -304,7 +332,14 @@ func (h *Handlers) HandleResize(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := h.terminalExec.ResizeTerminal(ctx, sess.PodName, "terminal", req.Width, req.Height); err != nil {
+	pod, err := h.podManager.GetPod(ctx, sess.PodName)
+	if err != nil {
+		h.logger.WithError(err).WithField("pod_name", sess.PodName).Error("Failed to get pod")
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pod not found"})
+		return
+	}
+
+	if err := h.terminalExec.ResizeTerminal(ctx, sess.PodName, k8s.TerminalContainerName(pod), req.Width, req.Height); err != nil {
 		h.logger.WithError(err).Error("Failed to resize terminal")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resize terminal"})
 		return
@@ -1,13 +1,20 @@
 package api
 
 import (
+	"bytes"
+	"io"
 	"net/http"
 
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 
+	"github.com/kubrowser/kubrowser-backend/internal/audit"
+	"github.com/kubrowser/kubrowser-backend/internal/cleanup"
 	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+	"github.com/kubrowser/kubrowser-backend/internal/metrics"
 	"github.com/kubrowser/kubrowser-backend/internal/session"
 	"github.com/kubrowser/kubrowser-backend/internal/terminal"
 )
@@ -26,23 +33,42 @@ type Handlers struct {
 	podManager   *k8s.PodManager
 	sessionMgr   *session.Manager
 	terminalExec *terminal.Executor
+	cache        *k8s.ResourceCache
+	audit        *audit.Logger
+	metrics      *k8s.MetricsClient
+	history      *metrics.Sampler
+	resources    *k8s.ResourceBrowser
+	homeGC       *cleanup.HomeVolumeCollector
+	secretReveal SecretRevealPolicy
 }
 
 // NewHandlers creates a new handlers instance.
 func NewHandlers(logger *logrus.Logger, podManager *k8s.PodManager,
-	sessionMgr *session.Manager, terminalExec *terminal.Executor) *Handlers {
+	sessionMgr *session.Manager, terminalExec *terminal.Executor, resourceCache *k8s.ResourceCache,
+	auditLog *audit.Logger, metricsClient *k8s.MetricsClient, history *metrics.Sampler,
+	resources *k8s.ResourceBrowser, homeGC *cleanup.HomeVolumeCollector) *Handlers {
 	return &Handlers{
 		logger:       logger,
 		podManager:   podManager,
 		sessionMgr:   sessionMgr,
 		terminalExec: terminalExec,
+		cache:        resourceCache,
+		audit:        auditLog,
+		metrics:      metricsClient,
+		history:      history,
+		resources:    resources,
+		homeGC:       homeGC,
 	}
 }
 
 // NewHandlersWithConfig creates handlers with REST config for terminal executor.
-func NewHandlersWithConfig(logger *logrus.Logger, podManager *k8s.PodManager, sessionMgr *session.Manager, namespace string) *Handlers {
+// metricsClient and history may be nil when metrics-server is not installed, and homeGC when home
+// volume garbage collection is disabled.
+func NewHandlersWithConfig(logger *logrus.Logger, podManager *k8s.PodManager, sessionMgr *session.Manager,
+	resourceCache *k8s.ResourceCache, auditLog *audit.Logger, metricsClient *k8s.MetricsClient,
+	history *metrics.Sampler, resources *k8s.ResourceBrowser, homeGC *cleanup.HomeVolumeCollector, namespace string) *Handlers {
 	terminalExec := terminal.NewExecutor(podManager.GetClient(), podManager.GetConfig(), namespace)
-	return NewHandlers(logger, podManager, sessionMgr, terminalExec)
+	return NewHandlers(logger, podManager, sessionMgr, terminalExec, resourceCache, auditLog, metricsClient, history, resources, homeGC)
 }
 
 // getRestartCount returns the total restart count for all containers in a pod.
@@ -54,3 +80,19 @@ func getRestartCount(pod *v1.Pod) int32 {
 	}
 	return totalRestarts
 }
+
+// maxOptionalBodySize bounds request bodies read by bindOptionalJSON.
+const maxOptionalBodySize = 1 << 20
+
+// bindOptionalJSON decodes a JSON request body into obj, leaving obj unchanged when the body is empty.
+// The body is read whatever its Content-Length, so chunked requests are validated too.
+func bindOptionalJSON(c *gin.Context, obj interface{}) error {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxOptionalBodySize))
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return binding.JSON.BindBody(body, obj)
+}
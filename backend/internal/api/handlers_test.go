@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindOptionalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int64
+		wantErr bool
+	}{
+		{"empty", "", 0, false},
+		{"whitespace", " \n", 0, false},
+		{"revision", `{"revision": 3}`, 3, false},
+		{"malformed", `{"revision": `, 0, true},
+		{"wrong type", `{"revision": "3"}`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			// Chunked requests carry no Content-Length.
+			c.Request.ContentLength = -1
+
+			var req struct {
+				Revision int64 `json:"revision"`
+			}
+			err := bindOptionalJSON(c, &req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bindOptionalJSON() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && req.Revision != tt.want {
+				t.Errorf("revision = %d, want %d", req.Revision, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// startSSE writes the response headers for a Server-Sent Events stream.
+func startSSE(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx).
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+}
+
+// writeSSE writes a single event with a JSON payload and flushes it to the client.
+// id is optional and is what EventSource sends back as Last-Event-ID on reconnect.
+func writeSSE(c *gin.Context, id, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", payload)
+
+	if _, err := c.Writer.WriteString(b.String()); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// writeSSEComment writes a comment line, used as a keepalive that clients ignore.
+func writeSSEComment(c *gin.Context, comment string) error {
+	if _, err := fmt.Fprintf(c.Writer, ": %s\n\n", comment); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
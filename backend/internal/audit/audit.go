@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Result values recorded on audit entries.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultDenied  = "denied"
+)
+
+// Entry is a single audited action.
+type Entry struct {
+	Time      time.Time              `json:"time"`
+	User      string                 `json:"user"`
+	Role      string                 `json:"role"`
+	Action    string                 `json:"action"`
+	Resource  string                 `json:"resource"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Result    string                 `json:"result"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// Logger records audit entries to the application log and keeps the most recent ones in memory.
+type Logger struct {
+	logger     *logrus.Logger
+	mu         sync.RWMutex
+	entries    []Entry
+	maxEntries int
+}
+
+// NewLogger creates a new audit logger retaining up to maxEntries entries in memory.
+func NewLogger(logger *logrus.Logger, maxEntries int) *Logger {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &Logger{
+		logger:     logger,
+		entries:    make([]Entry, 0, maxEntries),
+		maxEntries: maxEntries,
+	}
+}
+
+// Record writes an audit entry. Time defaults to now.
+func (l *Logger) Record(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	l.logger.WithFields(logrus.Fields{
+		"audit":     true,
+		"user":      entry.User,
+		"role":      entry.Role,
+		"action":    entry.Action,
+		"resource":  entry.Resource,
+		"namespace": entry.Namespace,
+		"name":      entry.Name,
+		"details":   entry.Details,
+		"result":    entry.Result,
+		"error":     entry.Error,
+	}).Info("Audit")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) >= l.maxEntries {
+		// Drop the oldest entry.
+		copy(l.entries, l.entries[1:])
+		l.entries = l.entries[:len(l.entries)-1]
+	}
+	l.entries = append(l.entries, entry)
+}
+
+// Recent returns up to limit of the most recent entries, newest first.
+func (l *Logger) Recent(limit int) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if limit <= 0 || limit > len(l.entries) {
+		limit = len(l.entries)
+	}
+
+	result := make([]Entry, 0, limit)
+	for i := len(l.entries) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, l.entries[i])
+	}
+	return result
+}
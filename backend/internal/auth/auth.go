@@ -22,12 +22,16 @@ type Config struct {
 	SessionSecret      string
 	BaseURL            string
 	AllowedUsers       []string
+	UserRoles          map[string]string
+	DefaultRole        string
 }
 
 // Handler manages authentication requests.
 type Handler struct {
 	oauthConfig  *oauth2.Config
 	allowedUsers map[string]bool
+	userRoles    map[string]Role
+	defaultRole  Role
 	logger       *logrus.Logger
 	cookieName   string
 }
@@ -52,6 +56,26 @@ func NewHandler(cfg Config, logger *logrus.Logger) *Handler {
 		allowed[strings.ToLower(user)] = true
 	}
 
+	// An unknown default role must not grant write access to every allowed user.
+	defaultRole, ok := ParseRole(cfg.DefaultRole)
+	if !ok {
+		logger.WithField("role", cfg.DefaultRole).Warn("Unknown default role, falling back to viewer")
+		defaultRole = RoleViewer
+	}
+
+	roles := make(map[string]Role)
+	for user, roleName := range cfg.UserRoles {
+		role, valid := ParseRole(roleName)
+		if !valid {
+			logger.WithFields(logrus.Fields{
+				"user": user,
+				"role": roleName,
+			}).Warn("Ignoring unknown role in user role mapping")
+			continue
+		}
+		roles[strings.ToLower(user)] = role
+	}
+
 	return &Handler{
 		oauthConfig: &oauth2.Config{
 			ClientID:     cfg.GitHubClientID,
@@ -61,6 +85,8 @@ func NewHandler(cfg Config, logger *logrus.Logger) *Handler {
 			Endpoint:     github.Endpoint,
 		},
 		allowedUsers: allowed,
+		userRoles:    roles,
+		defaultRole:  defaultRole,
 		logger:       logger,
 		cookieName:   authCookieName,
 	}
@@ -175,6 +201,7 @@ func (h *Handler) Me(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"login":      user,
 		"avatar_url": fmt.Sprintf("https://github.com/%s.png", user),
+		"role":       RoleFromContext(c),
 	})
 }
 
@@ -200,10 +227,19 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 		}
 
 		c.Set("user", user)
+		c.Set("role", h.RoleFor(user))
 		c.Next()
 	}
 }
 
+// RoleFor returns the role assigned to a user, falling back to the default role.
+func (h *Handler) RoleFor(user string) Role {
+	if role, ok := h.userRoles[strings.ToLower(user)]; ok {
+		return role
+	}
+	return h.defaultRole
+}
+
 // Logout clears the session.
 func (h *Handler) Logout(c *gin.Context) {
 	c.SetCookie(h.cookieName, "", -1, "/", "", false, true)
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Role is a coarse-grained permission level assigned to an authenticated user.
+type Role string
+
+const (
+	// RoleViewer may read cluster state but not change it.
+	RoleViewer Role = "viewer"
+	// RoleOperator may additionally run day-to-day operations such as restarts and scaling.
+	RoleOperator Role = "operator"
+	// RoleAdmin may do everything, including revealing secrets and managing other users' resources.
+	RoleAdmin Role = "admin"
+)
+
+var roleRank = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// ParseRole parses a role name, returning false if it is unknown.
+func ParseRole(name string) (Role, bool) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := roleRank[role]; !ok {
+		return "", false
+	}
+	return role, true
+}
+
+// Allows reports whether the role grants at least the permissions of the required role.
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// RoleFromContext returns the role set by AuthMiddleware, or RoleViewer if none is present.
+func RoleFromContext(c *gin.Context) Role {
+	if value, exists := c.Get("role"); exists {
+		if role, ok := value.(Role); ok {
+			return role
+		}
+	}
+	return RoleViewer
+}
+
+// RequireRole aborts the request unless the authenticated user holds at least the given role.
+func RequireRole(required Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !RoleFromContext(c).Allows(required) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
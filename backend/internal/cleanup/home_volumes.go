@@ -0,0 +1,105 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// HomeVolumeCollector garbage collects home volumes of users who are gone.
+type HomeVolumeCollector struct {
+	logger     *logrus.Logger
+	podManager *k8s.PodManager
+	opts       k8s.HomeVolumeGCOptions
+	stopChan   chan struct{}
+	interval   time.Duration
+}
+
+// NewHomeVolumeCollector creates a new home volume garbage collector. The interval must be positive.
+func NewHomeVolumeCollector(logger *logrus.Logger, podManager *k8s.PodManager, opts k8s.HomeVolumeGCOptions,
+	interval time.Duration) (*HomeVolumeCollector, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("home volume collection interval must be positive, got %v", interval)
+	}
+	return &HomeVolumeCollector{
+		logger:     logger,
+		podManager: podManager,
+		opts:       opts,
+		interval:   interval,
+		stopChan:   make(chan struct{}),
+	}, nil
+}
+
+// Start starts the garbage collection loop.
+func (g *HomeVolumeCollector) Start(ctx context.Context) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			g.collect(ctx)
+		case <-g.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the garbage collector.
+func (g *HomeVolumeCollector) Stop() {
+	close(g.stopChan)
+}
+
+// Report returns the home volumes the next collection would remove, without removing them. Volumes
+// that have no last-used time yet are stamped with the current time.
+func (g *HomeVolumeCollector) Report(ctx context.Context) ([]k8s.OrphanedHomeVolume, error) {
+	return g.podManager.FindOrphanedHomeVolumes(ctx, g.opts)
+}
+
+// Options returns the retention policy of the collector.
+func (g *HomeVolumeCollector) Options() k8s.HomeVolumeGCOptions {
+	return g.opts
+}
+
+// collect deletes orphaned home volumes, snapshotting them first when configured.
+func (g *HomeVolumeCollector) collect(ctx context.Context) {
+	g.logger.Debug("Starting home volume collection")
+
+	orphaned, err := g.Report(ctx)
+	if err != nil {
+		g.logger.WithError(err).Error("Failed to find orphaned home volumes")
+		return
+	}
+
+	deletedCount := 0
+	for _, orphan := range orphaned {
+		log := g.logger.WithFields(logrus.Fields{
+			"pvc":      orphan.PVC,
+			"username": orphan.Username,
+			"reason":   orphan.Reason,
+		})
+
+		deleteCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		deleted, err := g.podManager.CollectHomeVolume(deleteCtx, orphan, g.opts)
+		cancel()
+		switch {
+		case err != nil:
+			log.WithError(err).Error("Failed to collect home volume")
+		case !deleted:
+			log.WithField("snapshot", orphan.Snapshot).Info("Waiting for home volume snapshot before deleting")
+		default:
+			log.Info("Deleted orphaned home volume")
+			deletedCount++
+		}
+	}
+
+	if deletedCount > 0 {
+		g.logger.WithField("count", deletedCount).Info("Home volume collection completed")
+	}
+}
@@ -12,10 +12,11 @@ import (
 
 // Config holds the application configuration.
 type Config struct {
-	Pod    PodConfig
-	Auth   AuthConfig
-	K8s    K8sConfig
-	Server ServerConfig
+	Pod     PodConfig
+	Auth    AuthConfig
+	K8s     K8sConfig
+	Server  ServerConfig
+	Metrics MetricsConfig
 }
 
 // AuthConfig holds authentication configuration.
@@ -25,6 +26,12 @@ type AuthConfig struct {
 	SessionSecret      string
 	BaseURL            string
 	AllowedUsers       []string
+	UserRoles          map[string]string
+	DefaultRole        string
+	// SecretRevealNamespaces lists the namespaces where the kubrowser.io/reveal-role annotation may
+	// lower the role required to reveal Secret values, and SecretRevealMinRole how far.
+	SecretRevealNamespaces []string
+	SecretRevealMinRole    string
 }
 
 // ServerConfig holds server-related configuration.
@@ -33,6 +40,13 @@ type ServerConfig struct {
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
 	IdleTimeout  time.Duration
+	AuditLogSize int
+}
+
+// MetricsConfig holds configuration for the in-memory metrics history.
+type MetricsConfig struct {
+	SampleInterval time.Duration
+	Retention      time.Duration
 }
 
 // K8sConfig holds Kubernetes client configuration.
@@ -40,6 +54,7 @@ type K8sConfig struct {
 	KubeconfigPath    string
 	KubeconfigContent string
 	Namespace         string
+	InformerResync    time.Duration
 }
 
 // PodConfig holds pod-related configuration.
@@ -50,12 +65,59 @@ type PodConfig struct {
 	ServiceAccount     string
 	SessionTimeout     time.Duration
 	MaxSessionsPerUser int
+	HomeVolume         HomeVolumeConfig
+	Template           PodTemplateConfig
+	Profiles           ProfilesConfig
+}
+
+// ProfilesConfig locates the optional terminal profile definitions: a file, or a key of a ConfigMap.
+type ProfilesConfig struct {
+	File      string
+	ConfigMap string
+	Key       string
+}
+
+// PodTemplateConfig locates an optional session pod template: a file, or a key of a ConfigMap.
+type PodTemplateConfig struct {
+	File      string
+	ConfigMap string
+	Key       string
+}
+
+// HomeVolumeConfig holds configuration for user home directories.
+type HomeVolumeConfig struct {
+	Mode           string
+	StorageClass   string
+	Size           string
+	AccessMode     string
+	MaxSize        string
+	ExpandInterval time.Duration
+	// Retention is how long an unused home volume is kept before garbage collection; zero keeps it forever.
+	Retention     time.Duration
+	GCInterval    time.Duration
+	Snapshot      bool
+	SnapshotClass string
+	// UserOverrides and RoleOverrides map a username or role to settings such as "size:5Gi;mode:ephemeral".
+	UserOverrides map[string]string
+	RoleOverrides map[string]string
 }
 
 // ResourceLimits holds resource limit configuration.
 type ResourceLimits struct {
 	CPU    string
 	Memory string
+	// CPURequest and MemoryRequest default to the limits when empty.
+	CPURequest    string
+	MemoryRequest string
+	// MaxCPU and MaxMemory cap what a user may resize their pod to; empty means the limits.
+	MaxCPU    string
+	MaxMemory string
+	// UserOverrides and RoleOverrides map a username or role to settings such as "cpu:2;memory:4Gi".
+	UserOverrides map[string]string
+	RoleOverrides map[string]string
+	// BudgetCPU and BudgetMemory cap the requests of all session pods together; empty is unlimited.
+	BudgetCPU    string
+	BudgetMemory string
 }
 
 // Load loads configuration from environment variables with defaults.
@@ -70,11 +132,13 @@ func Load() *Config {
 			ReadTimeout:  getDurationEnv("READ_TIMEOUT", 15*time.Second),
 			WriteTimeout: getDurationEnv("WRITE_TIMEOUT", 15*time.Second),
 			IdleTimeout:  getDurationEnv("IDLE_TIMEOUT", 60*time.Second),
+			AuditLogSize: getIntEnv("AUDIT_LOG_SIZE", 1000),
 		},
 		K8s: K8sConfig{
 			KubeconfigPath:    getKubeconfigPath(),
 			KubeconfigContent: getEnv("KUBECONFIG_CONTENT", ""),
 			Namespace:         getEnv("POD_NAMESPACE", "default"),
+			InformerResync:    getDurationEnv("INFORMER_RESYNC", 10*time.Minute),
 		},
 		Pod: PodConfig{
 			Image:              getEnv("POD_IMAGE", "bitnami/kubectl:latest"),
@@ -83,16 +147,56 @@ func Load() *Config {
 			SessionTimeout:     getDurationEnv("SESSION_TIMEOUT", 60*time.Minute), // Default 1 hour.
 			MaxSessionsPerUser: getIntEnv("MAX_SESSIONS_PER_USER", 5),
 			ResourceLimits: ResourceLimits{
-				CPU:    getEnv("POD_CPU_LIMIT", "500m"),
-				Memory: getEnv("POD_MEMORY_LIMIT", "512Mi"),
+				CPU:           getEnv("POD_CPU_LIMIT", "500m"),
+				Memory:        getEnv("POD_MEMORY_LIMIT", "512Mi"),
+				CPURequest:    getEnv("POD_CPU_REQUEST", ""),
+				MemoryRequest: getEnv("POD_MEMORY_REQUEST", ""),
+				MaxCPU:        getEnv("POD_MAX_CPU", ""),
+				MaxMemory:     getEnv("POD_MAX_MEMORY", ""),
+				UserOverrides: getStringMapEnv("POD_RESOURCE_USER_OVERRIDES", map[string]string{}),
+				RoleOverrides: getStringMapEnv("POD_RESOURCE_ROLE_OVERRIDES", map[string]string{}),
+				BudgetCPU:     getEnv("POD_BUDGET_CPU", ""),
+				BudgetMemory:  getEnv("POD_BUDGET_MEMORY", ""),
+			},
+			Template: PodTemplateConfig{
+				File:      getEnv("POD_TEMPLATE_FILE", ""),
+				ConfigMap: getEnv("POD_TEMPLATE_CONFIGMAP", ""),
+				Key:       getEnv("POD_TEMPLATE_KEY", "pod.yaml"),
 			},
+			Profiles: ProfilesConfig{
+				File:      getEnv("PROFILES_FILE", ""),
+				ConfigMap: getEnv("PROFILES_CONFIGMAP", ""),
+				Key:       getEnv("PROFILES_KEY", "profiles.yaml"),
+			},
+			HomeVolume: HomeVolumeConfig{
+				Mode:           getEnv("HOME_VOLUME_MODE", "persistent"),
+				StorageClass:   getEnv("HOME_VOLUME_STORAGE_CLASS", ""),
+				Size:           getEnv("HOME_VOLUME_SIZE", "1Gi"),
+				AccessMode:     getEnv("HOME_VOLUME_ACCESS_MODE", "ReadWriteOnce"),
+				MaxSize:        getEnv("HOME_VOLUME_MAX_SIZE", ""),
+				ExpandInterval: getDurationEnv("HOME_VOLUME_EXPAND_INTERVAL", 5*time.Minute),
+				Retention:      getDurationEnv("HOME_VOLUME_RETENTION", 90*24*time.Hour),
+				GCInterval:     getDurationEnv("HOME_VOLUME_GC_INTERVAL", time.Hour),
+				Snapshot:       getBoolEnv("HOME_VOLUME_SNAPSHOT", false),
+				SnapshotClass:  getEnv("HOME_VOLUME_SNAPSHOT_CLASS", ""),
+				UserOverrides:  getStringMapEnv("HOME_VOLUME_USER_OVERRIDES", map[string]string{}),
+				RoleOverrides:  getStringMapEnv("HOME_VOLUME_ROLE_OVERRIDES", map[string]string{}),
+			},
+		},
+		Metrics: MetricsConfig{
+			SampleInterval: getDurationEnv("METRICS_SAMPLE_INTERVAL", 30*time.Second),
+			Retention:      getDurationEnv("METRICS_RETENTION", 2*time.Hour),
 		},
 		Auth: AuthConfig{
-			GitHubClientID:     getEnv("GITHUB_CLIENT_ID", ""),
-			GitHubClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
-			SessionSecret:      getEnv("SESSION_SECRET", "change-me-in-production-secret-key-must-be-32-bytes"),
-			AllowedUsers:       getStringSliceEnv("ALLOWED_USERS", []string{"tpural", "gregyjames"}),
-			BaseURL:            getEnv("BASE_URL", "http://localhost:8080"),
+			GitHubClientID:         getEnv("GITHUB_CLIENT_ID", ""),
+			GitHubClientSecret:     getEnv("GITHUB_CLIENT_SECRET", ""),
+			SessionSecret:          getEnv("SESSION_SECRET", "change-me-in-production-secret-key-must-be-32-bytes"),
+			AllowedUsers:           getStringSliceEnv("ALLOWED_USERS", []string{"tpural", "gregyjames"}),
+			BaseURL:                getEnv("BASE_URL", "http://localhost:8080"),
+			UserRoles:              getStringMapEnv("USER_ROLES", map[string]string{}),
+			DefaultRole:            getEnv("DEFAULT_ROLE", "viewer"),
+			SecretRevealNamespaces: getStringSliceEnv("SECRET_REVEAL_NAMESPACES", []string{}),
+			SecretRevealMinRole:    getEnv("SECRET_REVEAL_MIN_ROLE", "operator"),
 		},
 	}
 }
@@ -113,6 +217,15 @@ func getIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getKubeconfigPath() string {
 	// 1. Check KUBECONFIG_PATH (backward compatibility).
 	if path := os.Getenv("KUBECONFIG_PATH"); path != "" {
@@ -154,3 +267,21 @@ func getStringSliceEnv(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+// getStringMapEnv parses a comma-separated list of key=value pairs.
+func getStringMapEnv(key string, defaultValue map[string]string) map[string]string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return result
+}
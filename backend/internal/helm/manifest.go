@@ -0,0 +1,277 @@
+package helm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// maxLineDiffCells bounds the work of a line diff; larger documents are reported as replaced whole.
+const maxLineDiffCells = 1_000_000
+
+const redactedValue = "********"
+
+// Document is one resource of a rendered release manifest.
+type Document struct {
+	Source    string `json:"source,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Content   string `json:"-"`
+}
+
+// Key identifies the resource a document renders.
+func (d Document) Key() string {
+	return fmt.Sprintf("%s/%s/%s", d.Kind, d.Namespace, d.Name)
+}
+
+// ResourceDiff is the change to one resource between two revisions.
+type ResourceDiff struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name"`
+	Change    string   `json:"change"`
+	Lines     []string `json:"lines,omitempty"`
+}
+
+// RevisionDiff compares two revisions of a release.
+type RevisionDiff struct {
+	From      int               `json:"from"`
+	To        int               `json:"to"`
+	Chart     []k8s.FieldChange `json:"chart"`
+	Values    []k8s.FieldChange `json:"values"`
+	Resources []ResourceDiff    `json:"resources"`
+}
+
+// SplitManifest splits a rendered manifest into its resource documents.
+func SplitManifest(manifest string) []Document {
+	docs := make([]Document, 0)
+	for _, content := range strings.Split(manifest, "\n---") {
+		content = strings.TrimPrefix(strings.TrimSpace(content), "---")
+		content = strings.TrimSpace(content)
+		if content == "" {
+			continue
+		}
+
+		doc := Document{Content: content + "\n"}
+		if strings.HasPrefix(content, "# Source: ") {
+			line, _, _ := strings.Cut(content, "\n")
+			doc.Source = strings.TrimPrefix(line, "# Source: ")
+		}
+
+		var meta struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(content), &meta); err == nil {
+			doc.Kind, doc.Name, doc.Namespace = meta.Kind, meta.Metadata.Name, meta.Metadata.Namespace
+		}
+		if doc.Kind == "" && doc.Name == "" {
+			// Comment-only documents, e.g. templates that rendered nothing.
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// RedactSecrets masks the values of Secret resources in a rendered manifest.
+func RedactSecrets(manifest string) string {
+	docs := SplitManifest(manifest)
+	parts := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		parts = append(parts, redactDocument(doc))
+	}
+	return strings.Join(parts, "---\n")
+}
+
+// RedactValues returns a copy of release values with every scalar masked. Maps and lists are kept,
+// so the keys that are set stay visible.
+func RedactValues(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	redacted, _ := redactValue(values).(map[string]interface{})
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			redacted[key] = redactValue(item)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redactValue(item)
+		}
+		return redacted
+	case nil:
+		return nil
+	}
+	return redactedValue
+}
+
+func redactDocument(doc Document) string {
+	if doc.Kind != "Secret" {
+		return doc.Content
+	}
+
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal([]byte(doc.Content), &obj); err != nil {
+		return fmt.Sprintf("# Source: %s\n# Secret %s could not be parsed and is hidden\n", doc.Source, doc.Name)
+	}
+	for _, field := range []string{"data", "stringData"} {
+		if values, ok := obj[field].(map[string]interface{}); ok {
+			for key := range values {
+				values[key] = redactedValue
+			}
+		}
+	}
+	redacted, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Sprintf("# Source: %s\n# Secret %s could not be parsed and is hidden\n", doc.Source, doc.Name)
+	}
+	if doc.Source != "" {
+		return "# Source: " + doc.Source + "\n" + string(redacted)
+	}
+	return string(redacted)
+}
+
+// Diff compares two revisions: chart metadata, user-supplied values and each rendered resource.
+// With redact, Secret values are masked before comparing, and the old and new values of changed
+// values are masked after comparing, so the changed paths are still reported.
+func Diff(from, to *Release, redact bool) *RevisionDiff {
+	chartFields := func(r *Release) map[string]interface{} {
+		return map[string]interface{}{
+			"name":       r.Chart.Metadata.Name,
+			"version":    r.Chart.Metadata.Version,
+			"appVersion": r.Chart.Metadata.AppVersion,
+		}
+	}
+
+	result := &RevisionDiff{
+		From:      from.Version,
+		To:        to.Version,
+		Chart:     k8s.DiffValues(chartFields(from), chartFields(to)),
+		Values:    k8s.DiffValues(from.Config, to.Config),
+		Resources: make([]ResourceDiff, 0),
+	}
+	if redact {
+		for i := range result.Values {
+			result.Values[i].Old = redactValue(result.Values[i].Old)
+			result.Values[i].New = redactValue(result.Values[i].New)
+		}
+	}
+
+	index := func(r *Release) map[string]Document {
+		docs := make(map[string]Document)
+		for _, doc := range SplitManifest(r.Manifest) {
+			if redact {
+				doc.Content = redactDocument(doc)
+			}
+			docs[doc.Key()] = doc
+		}
+		return docs
+	}
+	fromDocs, toDocs := index(from), index(to)
+
+	for key, doc := range fromDocs {
+		next, ok := toDocs[key]
+		switch {
+		case !ok:
+			result.Resources = append(result.Resources, ResourceDiff{Kind: doc.Kind, Namespace: doc.Namespace, Name: doc.Name,
+				Change: "removed", Lines: prefixLines("-", doc.Content)})
+		case next.Content != doc.Content:
+			result.Resources = append(result.Resources, ResourceDiff{Kind: doc.Kind, Namespace: doc.Namespace, Name: doc.Name,
+				Change: "changed", Lines: lineDiff(doc.Content, next.Content)})
+		}
+	}
+	for key, doc := range toDocs {
+		if _, ok := fromDocs[key]; !ok {
+			result.Resources = append(result.Resources, ResourceDiff{Kind: doc.Kind, Namespace: doc.Namespace, Name: doc.Name,
+				Change: "added", Lines: prefixLines("+", doc.Content)})
+		}
+	}
+
+	sort.Slice(result.Resources, func(i, j int) bool {
+		a, b := result.Resources[i], result.Resources[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return result
+}
+
+func prefixLines(prefix, content string) []string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i := range lines {
+		lines[i] = prefix + lines[i]
+	}
+	return lines
+}
+
+// lineDiff returns a line diff of two documents with " ", "-" and "+" prefixes, computed from the
+// longest common subsequence of lines.
+func lineDiff(from, to string) []string {
+	a := strings.Split(strings.TrimSuffix(from, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(to, "\n"), "\n")
+	if len(a)*len(b) > maxLineDiffCells {
+		return append(prefixLines("-", from), prefixLines("+", to)...)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+"+b[j])
+	}
+	return lines
+}
@@ -0,0 +1,149 @@
+package helm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testManifest = `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: apps
+---
+# Source: web/templates/empty.yaml
+# nothing rendered
+---
+# Source: web/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: web-creds
+data:
+  password: aHVudGVyMg==
+stringData:
+  token: plain-token
+`
+
+func TestSplitManifest(t *testing.T) {
+	docs := SplitManifest(testManifest)
+	if len(docs) != 2 {
+		t.Fatalf("SplitManifest() returned %d documents, want 2: %+v", len(docs), docs)
+	}
+
+	want := []Document{
+		{Source: "web/templates/service.yaml", Kind: "Service", Namespace: "apps", Name: "web"},
+		{Source: "web/templates/secret.yaml", Kind: "Secret", Name: "web-creds"},
+	}
+	for i := range docs {
+		content := docs[i].Content
+		docs[i].Content = ""
+		if !strings.HasPrefix(content, "# Source: ") || !strings.HasSuffix(content, "\n") {
+			t.Errorf("document %d content = %q", i, content)
+		}
+	}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("SplitManifest() = %+v, want %+v", docs, want)
+	}
+	if key := want[0].Key(); key != "Service/apps/web" {
+		t.Errorf("Key() = %q", key)
+	}
+	if docs := SplitManifest(""); len(docs) != 0 {
+		t.Errorf("SplitManifest(\"\") = %+v", docs)
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	redacted := RedactSecrets(testManifest)
+	for _, secret := range []string{"aHVudGVyMg==", "plain-token"} {
+		if strings.Contains(redacted, secret) {
+			t.Errorf("RedactSecrets() leaks %q:\n%s", secret, redacted)
+		}
+	}
+	for _, kept := range []string{"kind: Service", "password: '********'", "token: '********'",
+		"# Source: web/templates/secret.yaml"} {
+		if !strings.Contains(redacted, kept) {
+			t.Errorf("RedactSecrets() lost %q:\n%s", kept, redacted)
+		}
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     []string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", []string{" a", " b"}},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", []string{" a", "-b", "+B", " c"}},
+		{"appended", "a\n", "a\nb\n", []string{" a", "+b"}},
+		{"removed", "a\nb\nc\n", "a\nc\n", []string{" a", "-b", " c"}},
+		{"disjoint", "a\n", "b\n", []string{"-a", "+b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineDiff(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lineDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffRedactsSecrets(t *testing.T) {
+	from := &Release{Version: 1, Manifest: testManifest}
+	to := &Release{Version: 2, Manifest: strings.Replace(testManifest, "plain-token", "new-token", 1)}
+
+	// The only change is a Secret value, which redaction hides.
+	if diff := Diff(from, to, true); len(diff.Resources) != 0 {
+		t.Errorf("redacted Diff() resources = %+v, want none", diff.Resources)
+	}
+	diff := Diff(from, to, false)
+	if len(diff.Resources) != 1 || diff.Resources[0].Change != "changed" || diff.Resources[0].Name != "web-creds" {
+		t.Fatalf("Diff() resources = %+v", diff.Resources)
+	}
+}
+
+func TestRedactValues(t *testing.T) {
+	values := map[string]interface{}{
+		"replicas": float64(2),
+		"auth":     map[string]interface{}{"password": "hunter2", "enabled": true},
+		"hosts":    []interface{}{"a.example.com", map[string]interface{}{"token": "t0k3n"}},
+		"unset":    nil,
+	}
+	want := map[string]interface{}{
+		"replicas": redactedValue,
+		"auth":     map[string]interface{}{"password": redactedValue, "enabled": redactedValue},
+		"hosts":    []interface{}{redactedValue, map[string]interface{}{"token": redactedValue}},
+		"unset":    nil,
+	}
+	if got := RedactValues(values); !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactValues() = %v, want %v", got, want)
+	}
+	if values["auth"].(map[string]interface{})["password"] != "hunter2" {
+		t.Error("RedactValues() modified its input")
+	}
+	if got := RedactValues(nil); got != nil {
+		t.Errorf("RedactValues(nil) = %v, want nil", got)
+	}
+}
+
+func TestDiffRedactsValues(t *testing.T) {
+	from := &Release{Version: 1, Config: map[string]interface{}{"password": "old-secret"}}
+	to := &Release{Version: 2, Config: map[string]interface{}{"password": "new-secret"}}
+
+	diff := Diff(from, to, true)
+	if len(diff.Values) != 1 || diff.Values[0].Path != "password" {
+		t.Fatalf("redacted Diff() values = %+v, want the password change", diff.Values)
+	}
+	if diff.Values[0].Old != redactedValue || diff.Values[0].New != redactedValue {
+		t.Errorf("redacted Diff() values = %+v, want masked old and new values", diff.Values)
+	}
+
+	diff = Diff(from, to, false)
+	if len(diff.Values) != 1 || diff.Values[0].Old != "old-secret" || diff.Values[0].New != "new-secret" {
+		t.Errorf("Diff() values = %+v, want the plain values", diff.Values)
+	}
+}
@@ -0,0 +1,233 @@
+// Package helm reads Helm 3 releases from the Secrets the Helm storage driver writes, without
+// depending on the Helm SDK.
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// SecretType is the type of the Secrets holding Helm 3 releases.
+	SecretType = "helm.sh/release.v1"
+
+	releaseKey = "release"
+)
+
+// ErrReleaseNotFound is returned when no Secret holds the requested release or revision.
+var ErrReleaseNotFound = errors.New("release not found")
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// Release is the subset of a Helm release record that Kubrowser shows.
+type Release struct {
+	Name      string                 `json:"name"`
+	Namespace string                 `json:"namespace"`
+	Version   int                    `json:"version"`
+	Info      Info                   `json:"info"`
+	Chart     Chart                  `json:"chart"`
+	Config    map[string]interface{} `json:"config"`
+	Manifest  string                 `json:"manifest"`
+	Hooks     []Hook                 `json:"hooks"`
+}
+
+// Info describes the state of a release revision.
+type Info struct {
+	FirstDeployed time.Time `json:"first_deployed"`
+	LastDeployed  time.Time `json:"last_deployed"`
+	Deleted       time.Time `json:"deleted"`
+	Description   string    `json:"description"`
+	Status        string    `json:"status"`
+	Notes         string    `json:"notes"`
+}
+
+// Chart holds the chart metadata and default values of a release.
+type Chart struct {
+	Metadata ChartMetadata          `json:"metadata"`
+	Values   map[string]interface{} `json:"values"`
+}
+
+// ChartMetadata is the Chart.yaml of the chart a release was installed from.
+type ChartMetadata struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	AppVersion  string `json:"appVersion"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// Hook is a Helm hook rendered for a release.
+type Hook struct {
+	Name   string   `json:"name"`
+	Kind   string   `json:"kind"`
+	Path   string   `json:"path"`
+	Events []string `json:"events"`
+}
+
+// ChartRef returns the chart as name-version, as helm list shows it.
+func (r *Release) ChartRef() string {
+	return r.Chart.Metadata.Name + "-" + r.Chart.Metadata.Version
+}
+
+// ComputedValues returns the user-supplied values merged over the chart defaults, like
+// helm get values --all.
+func (r *Release) ComputedValues() map[string]interface{} {
+	return mergeValues(r.Chart.Values, r.Config)
+}
+
+// Decode decodes the release field of a Helm release Secret: base64, optionally gzip, then JSON.
+func Decode(data []byte) (*Release, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+
+	if bytes.HasPrefix(decoded, gzipMagic) {
+		reader, gzErr := gzip.NewReader(bytes.NewReader(decoded))
+		if gzErr != nil {
+			return nil, fmt.Errorf("failed to decompress release: %w", gzErr)
+		}
+		defer reader.Close()
+		if decoded, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("failed to decompress release: %w", err)
+		}
+	}
+
+	var release Release
+	if err := json.Unmarshal(decoded, &release); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal release: %w", err)
+	}
+	return &release, nil
+}
+
+// ListReleases returns the latest revision of every release in namespace (empty for all namespaces),
+// sorted by namespace and name.
+func ListReleases(ctx context.Context, client kubernetes.Interface, namespace string) ([]*Release, error) {
+	revisions, err := listRevisions(ctx, client, namespace, labels.Set{"owner": "helm"})
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]*Release)
+	for _, rel := range revisions {
+		key := rel.Namespace + "/" + rel.Name
+		if current, ok := latest[key]; !ok || rel.Version > current.Version {
+			latest[key] = rel
+		}
+	}
+
+	result := make([]*Release, 0, len(latest))
+	for _, rel := range latest {
+		result = append(result, rel)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// History returns every stored revision of a release, oldest first.
+func History(ctx context.Context, client kubernetes.Interface, namespace, name string) ([]*Release, error) {
+	revisions, err := listRevisions(ctx, client, namespace, labels.Set{"owner": "helm", "name": name})
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%w: %s/%s", ErrReleaseNotFound, namespace, name)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Version < revisions[j].Version })
+	return revisions, nil
+}
+
+// GetRevision returns a single revision of a release.
+func GetRevision(ctx context.Context, client kubernetes.Interface, namespace, name string, revision int) (*Release, error) {
+	revisions, err := listRevisions(ctx, client, namespace, labels.Set{
+		"owner":   "helm",
+		"name":    name,
+		"version": strconv.Itoa(revision),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%w: %s/%s revision %d", ErrReleaseNotFound, namespace, name, revision)
+	}
+	return revisions[0], nil
+}
+
+func listRevisions(ctx context.Context, client kubernetes.Interface, namespace string, selector labels.Set) ([]*Release, error) {
+	list, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(selector).String(),
+		FieldSelector: "type=" + SecretType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release secrets: %w", err)
+	}
+
+	result := make([]*Release, 0, len(list.Items))
+	for i := range list.Items {
+		rel, decodeErr := decodeSecret(&list.Items[i])
+		if decodeErr != nil {
+			// One corrupt record should not hide the others.
+			continue
+		}
+		result = append(result, rel)
+	}
+	return result, nil
+}
+
+func decodeSecret(secret *v1.Secret) (*Release, error) {
+	data, ok := secret.Data[releaseKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no release data", secret.Namespace, secret.Name)
+	}
+	rel, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	if rel.Namespace == "" {
+		rel.Namespace = secret.Namespace
+	}
+	return rel, nil
+}
+
+// mergeValues merges overrides into a copy of base. Nested maps are merged recursively and a nil
+// override deletes the key, as in Helm.
+func mergeValues(base, overrides map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base))
+	for key, value := range base {
+		result[key] = value
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		if overrideMap, ok := value.(map[string]interface{}); ok {
+			if baseMap, isMap := result[key].(map[string]interface{}); isMap {
+				result[key] = mergeValues(baseMap, overrideMap)
+				continue
+			}
+		}
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+const releaseJSON = `{"name":"web","namespace":"apps","version":3,"info":{"status":"deployed"},` +
+	`"chart":{"metadata":{"name":"nginx","version":"1.2.3"},"values":{"replicas":1}},` +
+	`"config":{"replicas":2},"manifest":"kind: Service\n"}`
+
+func encodeRelease(t *testing.T, compress bool) []byte {
+	t.Helper()
+	data := []byte(releaseJSON)
+	if compress {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		data = buf.Bytes()
+	}
+	return []byte(base64.StdEncoding.EncodeToString(data))
+}
+
+func TestDecode(t *testing.T) {
+	for _, compress := range []bool{true, false} {
+		rel, err := Decode(encodeRelease(t, compress))
+		if err != nil {
+			t.Fatalf("Decode(gzip=%v): %v", compress, err)
+		}
+		if rel.Name != "web" || rel.Namespace != "apps" || rel.Version != 3 || rel.Info.Status != "deployed" {
+			t.Errorf("Decode(gzip=%v) = %+v", compress, rel)
+		}
+		if rel.ChartRef() != "nginx-1.2.3" {
+			t.Errorf("ChartRef() = %q", rel.ChartRef())
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"not base64": []byte("%%%"),
+		"not gzip":   []byte(base64.StdEncoding.EncodeToString([]byte{0x1f, 0x8b, 0x08, 0x00})),
+		"not json":   []byte(base64.StdEncoding.EncodeToString([]byte("release"))),
+		"empty":      nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode(data); err == nil {
+				t.Error("Decode succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]interface{}{
+		"replicas": 1,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+		"debug":    true,
+	}
+	overrides := map[string]interface{}{
+		"replicas": 3,
+		"image":    map[string]interface{}{"tag": "1.26"},
+		"debug":    nil,
+		"extra":    "yes",
+	}
+	want := map[string]interface{}{
+		"replicas": 3,
+		"image":    map[string]interface{}{"repository": "nginx", "tag": "1.26"},
+		"extra":    "yes",
+	}
+	if got := mergeValues(base, overrides); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeValues() = %v, want %v", got, want)
+	}
+	if base["replicas"] != 1 || base["image"].(map[string]interface{})["tag"] != "1.25" {
+		t.Errorf("mergeValues modified the base values: %v", base)
+	}
+
+	// A map replaces a scalar and vice versa.
+	got := mergeValues(map[string]interface{}{"a": "x", "b": map[string]interface{}{"c": 1}},
+		map[string]interface{}{"a": map[string]interface{}{"d": 2}, "b": "y"})
+	want = map[string]interface{}{"a": map[string]interface{}{"d": 2}, "b": "y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeValues() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,399 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// PodNodeIndex indexes pods by the node they are scheduled on.
+	PodNodeIndex = "byNode"
+	// EventObjectIndex indexes events by namespace/kind/name of the involved object.
+	EventObjectIndex = "byInvolvedObject"
+)
+
+// ResourceCache serves pods, nodes, namespaces and events from shared informers
+// so that list endpoints don't hit the API server on every request.
+type ResourceCache struct {
+	factory    informers.SharedInformerFactory
+	pods       cache.SharedIndexInformer
+	nodes      cache.SharedIndexInformer
+	namespaces cache.SharedIndexInformer
+	events     cache.SharedIndexInformer
+	podDeltas  *DeltaRing
+	nodeDeltas *DeltaRing
+	mu         sync.RWMutex
+	synced     map[string]bool
+}
+
+// NewResourceCache creates a new informer-backed cache. Informers are not started until Start is called.
+func NewResourceCache(client kubernetes.Interface, resync time.Duration) (*ResourceCache, error) {
+	factory := informers.NewSharedInformerFactory(client, resync)
+
+	rc := &ResourceCache{
+		factory:    factory,
+		pods:       factory.Core().V1().Pods().Informer(),
+		nodes:      factory.Core().V1().Nodes().Informer(),
+		namespaces: factory.Core().V1().Namespaces().Informer(),
+		events:     factory.Core().V1().Events().Informer(),
+		synced: map[string]bool{
+			"pods":       false,
+			"nodes":      false,
+			"namespaces": false,
+			"events":     false,
+		},
+	}
+
+	if err := rc.pods.AddIndexers(cache.Indexers{PodNodeIndex: podNodeIndexFunc}); err != nil {
+		return nil, fmt.Errorf("failed to add pod indexer: %w", err)
+	}
+	if err := rc.events.AddIndexers(cache.Indexers{EventObjectIndex: eventObjectIndexFunc}); err != nil {
+		return nil, fmt.Errorf("failed to add event indexer: %w", err)
+	}
+
+	var err error
+	if rc.podDeltas, err = NewDeltaRing(rc.pods, deltaRingSize); err != nil {
+		return nil, err
+	}
+	if rc.nodeDeltas, err = NewDeltaRing(rc.nodes, deltaRingSize); err != nil {
+		return nil, err
+	}
+
+	return rc, nil
+}
+
+// Start starts all informers and waits for their caches to sync in the background.
+func (rc *ResourceCache) Start(ctx context.Context) {
+	rc.factory.Start(ctx.Done())
+
+	informersByKind := map[string]cache.SharedIndexInformer{
+		"pods":       rc.pods,
+		"nodes":      rc.nodes,
+		"namespaces": rc.namespaces,
+		"events":     rc.events,
+	}
+	for kind, informer := range informersByKind {
+		go func(kind string, informer cache.SharedIndexInformer) {
+			if cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+				rc.mu.Lock()
+				rc.synced[kind] = true
+				rc.mu.Unlock()
+			}
+		}(kind, informer)
+	}
+}
+
+// HasSynced reports whether every informer has completed its initial list.
+func (rc *ResourceCache) HasSynced() bool {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	for _, ok := range rc.synced {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncStatus returns the sync state of each informer keyed by resource kind.
+func (rc *ResourceCache) SyncStatus() map[string]bool {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	status := make(map[string]bool, len(rc.synced))
+	for kind, ok := range rc.synced {
+		status[kind] = ok
+	}
+	return status
+}
+
+// IsSynced reports whether the informer for a single kind has completed its initial list.
+func (rc *ResourceCache) IsSynced(kind string) bool {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.synced[kind]
+}
+
+// ListPods returns cached pods in a namespace (metav1.NamespaceAll for every namespace)
+// matching the given label and field selectors, sorted by namespace and name.
+func (rc *ResourceCache) ListPods(namespace, labelSelector, fieldSelector string) ([]*v1.Pod, error) {
+	labelSel, fieldSel, err := parseSelectors(labelSelector, fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := rc.byNamespace(rc.pods.GetIndexer(), namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Narrow the candidate set with the node index when the field selector pins a node.
+	if nodeName, ok := fieldSel.RequiresExactMatch("spec.nodeName"); ok {
+		objs, err = rc.pods.GetIndexer().ByIndex(PodNodeIndex, nodeName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pods := make([]*v1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		pod, ok := obj.(*v1.Pod)
+		if !ok {
+			continue
+		}
+		if namespace != "" && pod.Namespace != namespace {
+			continue
+		}
+		if !labelSel.Matches(labels.Set(pod.Labels)) || !fieldSel.Matches(PodFields(pod)) {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+// ListNodes returns cached nodes matching the given label and field selectors, sorted by name.
+func (rc *ResourceCache) ListNodes(labelSelector, fieldSelector string) ([]*v1.Node, error) {
+	labelSel, fieldSel, err := parseSelectors(labelSelector, fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	objs := rc.nodes.GetIndexer().List()
+	nodes := make([]*v1.Node, 0, len(objs))
+	for _, obj := range objs {
+		node, ok := obj.(*v1.Node)
+		if !ok {
+			continue
+		}
+		if !labelSel.Matches(labels.Set(node.Labels)) || !fieldSel.Matches(NodeFields(node)) {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, nil
+}
+
+// ListNamespaces returns cached namespaces matching the given label selector, sorted by name.
+func (rc *ResourceCache) ListNamespaces(labelSelector string) ([]*v1.Namespace, error) {
+	labelSel, _, err := parseSelectors(labelSelector, "")
+	if err != nil {
+		return nil, err
+	}
+
+	objs := rc.namespaces.GetIndexer().List()
+	namespaces := make([]*v1.Namespace, 0, len(objs))
+	for _, obj := range objs {
+		ns, ok := obj.(*v1.Namespace)
+		if !ok {
+			continue
+		}
+		if !labelSel.Matches(labels.Set(ns.Labels)) {
+			continue
+		}
+		namespaces = append(namespaces, ns)
+	}
+
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Name < namespaces[j].Name })
+	return namespaces, nil
+}
+
+// ListEvents returns cached events in a namespace (metav1.NamespaceAll for every namespace).
+func (rc *ResourceCache) ListEvents(namespace string) ([]*v1.Event, error) {
+	objs, err := rc.byNamespace(rc.events.GetIndexer(), namespace)
+	if err != nil {
+		return nil, err
+	}
+	return toEvents(objs), nil
+}
+
+// ListEventsFor returns cached events whose involved object matches namespace, kind and name.
+func (rc *ResourceCache) ListEventsFor(namespace, kind, name string) ([]*v1.Event, error) {
+	objs, err := rc.events.GetIndexer().ByIndex(EventObjectIndex, eventObjectKey(namespace, kind, name))
+	if err != nil {
+		return nil, err
+	}
+	return toEvents(objs), nil
+}
+
+// PodInformer returns the shared pod informer so callers can register event handlers.
+func (rc *ResourceCache) PodInformer() cache.SharedIndexInformer {
+	return rc.pods
+}
+
+// NodeInformer returns the shared node informer so callers can register event handlers.
+func (rc *ResourceCache) NodeInformer() cache.SharedIndexInformer {
+	return rc.nodes
+}
+
+// PodDeltas returns the recent pod deltas, for resuming watch streams.
+func (rc *ResourceCache) PodDeltas() *DeltaRing {
+	return rc.podDeltas
+}
+
+// NodeDeltas returns the recent node deltas, for resuming watch streams.
+func (rc *ResourceCache) NodeDeltas() *DeltaRing {
+	return rc.nodeDeltas
+}
+
+// PodFields returns the field set used to evaluate pod field selectors.
+func PodFields(pod *v1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":           pod.Name,
+		"metadata.namespace":      pod.Namespace,
+		"spec.nodeName":           pod.Spec.NodeName,
+		"spec.restartPolicy":      string(pod.Spec.RestartPolicy),
+		"spec.schedulerName":      pod.Spec.SchedulerName,
+		"spec.serviceAccountName": pod.Spec.ServiceAccountName,
+		"status.phase":            string(pod.Status.Phase),
+		"status.podIP":            pod.Status.PodIP,
+	}
+}
+
+// NodeFields returns the field set used to evaluate node field selectors.
+func NodeFields(node *v1.Node) fields.Set {
+	return fields.Set{
+		"metadata.name":      node.Name,
+		"spec.unschedulable": fmt.Sprintf("%t", node.Spec.Unschedulable),
+	}
+}
+
+// ValidateSelectors reports whether the label and field selectors are syntactically valid.
+func ValidateSelectors(labelSelector, fieldSelector string) error {
+	_, _, err := parseSelectors(labelSelector, fieldSelector)
+	return err
+}
+
+// ValidateFieldSelector reports an error if fieldSelector uses a field that PodFields (kind "pods")
+// or NodeFields (kind "nodes") does not set, as such a selector would match nothing in the cache.
+func ValidateFieldSelector(kind, fieldSelector string) error {
+	_, fieldSel, err := parseSelectors("", fieldSelector)
+	if err != nil {
+		return err
+	}
+
+	var supported fields.Set
+	switch kind {
+	case "pods":
+		supported = PodFields(&v1.Pod{})
+	case "nodes":
+		supported = NodeFields(&v1.Node{})
+	default:
+		return fmt.Errorf("field selectors are not supported for %s", kind)
+	}
+	for _, requirement := range fieldSel.Requirements() {
+		if _, ok := supported[requirement.Field]; !ok {
+			return fmt.Errorf("unsupported field selector for %s: %s", kind, requirement.Field)
+		}
+	}
+	return nil
+}
+
+// ObjectSelector matches pods and nodes against a namespace and label and field selectors, as the
+// list methods of the cache do, so informer events can be filtered the same way.
+type ObjectSelector struct {
+	namespace string
+	labels    labels.Selector
+	fields    fields.Selector
+}
+
+// NewObjectSelector parses the label and field selectors. An empty namespace matches every namespace.
+func NewObjectSelector(namespace, labelSelector, fieldSelector string) (*ObjectSelector, error) {
+	labelSel, fieldSel, err := parseSelectors(labelSelector, fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectSelector{namespace: namespace, labels: labelSel, fields: fieldSel}, nil
+}
+
+// Matches reports whether obj is a pod or node selected by s.
+func (s *ObjectSelector) Matches(obj interface{}) bool {
+	switch o := obj.(type) {
+	case *v1.Pod:
+		return (s.namespace == "" || o.Namespace == s.namespace) &&
+			s.labels.Matches(labels.Set(o.Labels)) && s.fields.Matches(PodFields(o))
+	case *v1.Node:
+		return s.labels.Matches(labels.Set(o.Labels)) && s.fields.Matches(NodeFields(o))
+	}
+	return false
+}
+
+func (rc *ResourceCache) byNamespace(indexer cache.Indexer, namespace string) ([]interface{}, error) {
+	if namespace == "" {
+		return indexer.List(), nil
+	}
+	return indexer.ByIndex(cache.NamespaceIndex, namespace)
+}
+
+func parseSelectors(labelSelector, fieldSelector string) (labels.Selector, fields.Selector, error) {
+	labelSel := labels.Everything()
+	if labelSelector != "" {
+		parsed, err := labels.Parse(labelSelector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid label selector: %w", err)
+		}
+		labelSel = parsed
+	}
+
+	fieldSel := fields.Everything()
+	if fieldSelector != "" {
+		parsed, err := fields.ParseSelector(fieldSelector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid field selector: %w", err)
+		}
+		fieldSel = parsed
+	}
+
+	return labelSel, fieldSel, nil
+}
+
+func podNodeIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return nil, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+func eventObjectIndexFunc(obj interface{}) ([]string, error) {
+	event, ok := obj.(*v1.Event)
+	if !ok {
+		return nil, nil
+	}
+	ref := event.InvolvedObject
+	return []string{eventObjectKey(ref.Namespace, ref.Kind, ref.Name)}, nil
+}
+
+func eventObjectKey(namespace, kind, name string) string {
+	return fmt.Sprintf("%s/%s/%s", namespace, kind, name)
+}
+
+func toEvents(objs []interface{}) []*v1.Event {
+	events := make([]*v1.Event, 0, len(objs))
+	for _, obj := range objs {
+		if event, ok := obj.(*v1.Event); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
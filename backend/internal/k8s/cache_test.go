@@ -0,0 +1,28 @@
+package k8s
+
+import "testing"
+
+func TestValidateFieldSelector(t *testing.T) {
+	tests := []struct {
+		name          string
+		kind          string
+		fieldSelector string
+		wantErr       bool
+	}{
+		{name: "empty", kind: "nodes", fieldSelector: ""},
+		{name: "pod node", kind: "pods", fieldSelector: "spec.nodeName=node-1,status.phase!=Succeeded"},
+		{name: "node unschedulable", kind: "nodes", fieldSelector: "spec.unschedulable=true"},
+		{name: "node phase", kind: "nodes", fieldSelector: "status.phase=Running", wantErr: true},
+		{name: "pod unknown field", kind: "pods", fieldSelector: "spec.hostname=web", wantErr: true},
+		{name: "invalid syntax", kind: "pods", fieldSelector: "spec.nodeName", wantErr: true},
+		{name: "unsupported kind", kind: "events", fieldSelector: "metadata.name=web", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFieldSelector(tt.kind, tt.fieldSelector)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFieldSelector(%q, %q) error = %v, wantErr %v", tt.kind, tt.fieldSelector, err, tt.wantErr)
+			}
+		})
+	}
+}
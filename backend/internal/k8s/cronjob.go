@@ -0,0 +1,72 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CronJobInstantiateAnnotation marks Jobs created from a CronJob by hand, as kubectl create job --from does.
+	CronJobInstantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+
+	// maxJobNamePrefix leaves room for the "-manual-" suffix and the random characters the API server
+	// appends to a generated name within the 63 character name limit.
+	maxJobNamePrefix = 42
+)
+
+// TriggerCronJob creates a Job from a CronJob's jobTemplate, owned by the CronJob so it shows up in
+// its history and is cleaned up with it.
+func TriggerCronJob(ctx context.Context, client kubernetes.Interface, namespace, name string) (*batchv1.Job, error) {
+	cronJob, err := client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := make(map[string]string, len(cronJob.Spec.JobTemplate.Annotations)+1)
+	for k, v := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+	annotations[CronJobInstantiateAnnotation] = "manual"
+
+	labels := make(map[string]string, len(cronJob.Spec.JobTemplate.Labels))
+	for k, v := range cronJob.Spec.JobTemplate.Labels {
+		labels[k] = v
+	}
+
+	prefix := cronJob.Name
+	if len(prefix) > maxJobNamePrefix {
+		prefix = prefix[:maxJobNamePrefix]
+	}
+	controller := true
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			// A generated name can't collide with another Job triggered in the same second.
+			GenerateName: prefix + "-manual-",
+			Namespace:    namespace,
+			Labels:       labels,
+			Annotations:  annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: batchv1.SchemeGroupVersion.String(),
+				Kind:       "CronJob",
+				Name:       cronJob.Name,
+				UID:        cronJob.UID,
+				Controller: &controller,
+			}},
+		},
+		Spec: *cronJob.Spec.JobTemplate.Spec.DeepCopy(),
+	}
+
+	return client.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+}
+
+// SetCronJobSuspended suspends (true) or resumes (false) a CronJob.
+func SetCronJobSuspended(ctx context.Context, client kubernetes.Interface, namespace, name string, suspend bool) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"suspend":%t}}`, suspend))
+	_, err := client.BatchV1().CronJobs(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,220 @@
+package k8s
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// deltaRingSize is the number of recent deltas kept per informer for watch resumption.
+	deltaRingSize = 1024
+	// deltaSubscriberBuffer is the number of deltas queued for a subscriber before it is dropped.
+	deltaSubscriberBuffer = 256
+)
+
+// Delta is an informer notification recorded by a DeltaRing. Old is the previous state of a
+// modified object. ResourceVersion is 0 if the object's version is not a number.
+type Delta struct {
+	Type            watch.EventType
+	Old             interface{}
+	Object          interface{}
+	ResourceVersion uint64
+}
+
+// DeltaRing keeps the most recent deltas of an informer in resourceVersion order and fans them out
+// to subscribers, so a watch client can resume from the last version it received instead of
+// receiving a fresh snapshot. The ring holds every delta newer than its floor.
+type DeltaRing struct {
+	informer    cache.SharedIndexInformer
+	size        int
+	mu          sync.Mutex
+	entries     []Delta
+	floor       uint64
+	floorSet    bool
+	recorded    bool
+	subscribers map[*DeltaSubscription]struct{}
+}
+
+// DeltaSubscription receives the deltas a DeltaRing records after Subscribe.
+type DeltaSubscription struct {
+	ring    *DeltaRing
+	deltas  chan Delta
+	version uint64
+}
+
+// NewDeltaRing registers a ring of up to size deltas on informer. It must be called before the
+// informer starts, so that every delta after the initial list is recorded.
+func NewDeltaRing(informer cache.SharedIndexInformer, size int) (*DeltaRing, error) {
+	r := &DeltaRing{
+		informer:    informer,
+		size:        size,
+		subscribers: make(map[*DeltaSubscription]struct{}),
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			// The initial list is what clients get as a snapshot.
+			if !isInInitialList {
+				r.record(Delta{Type: watch.Added, Object: obj})
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Resyncs deliver unchanged objects.
+			if version := objectResourceVersion(newObj); version == "" || version != objectResourceVersion(oldObj) {
+				r.record(Delta{Type: watch.Modified, Old: oldObj, Object: newObj})
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if _, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				// The object was deleted while the informer relisted, at a version nobody knows.
+				r.mu.Lock()
+				r.reset()
+				r.mu.Unlock()
+				return
+			}
+			r.record(Delta{Type: watch.Deleted, Object: obj})
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to register delta ring: %w", err)
+	}
+	return r, nil
+}
+
+// Subscribe returns a subscription to the deltas recorded from now on. If resourceVersion is not
+// older than the ring, the deltas after it are returned as well and resumed is true; otherwise the
+// caller has to send a snapshot first.
+func (r *DeltaRing) Subscribe(resourceVersion string) (sub *DeltaSubscription, replay []Delta, resumed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub = &DeltaSubscription{ring: r, deltas: make(chan Delta, deltaSubscriberBuffer)}
+	if len(r.entries) > 0 {
+		sub.version = r.entries[len(r.entries)-1].ResourceVersion
+	}
+	r.subscribers[sub] = struct{}{}
+
+	version, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil || !r.ensureFloor() || version < r.floor {
+		return sub, nil, false
+	}
+	for _, d := range r.entries {
+		if d.ResourceVersion > version {
+			replay = append(replay, d)
+		}
+	}
+	return sub, replay, true
+}
+
+// Version returns the version of the last delta recorded before the subscription, or 0. The cache
+// reflects every delta up to it, so a snapshot taken after Subscribe can be resumed from it.
+func (s *DeltaSubscription) Version() uint64 {
+	return s.version
+}
+
+// Deltas returns the channel of recorded deltas. It is closed when the subscriber falls behind by
+// more than its buffer or the ring is reset, and the caller should subscribe again from the last
+// version it handled.
+func (s *DeltaSubscription) Deltas() <-chan Delta {
+	return s.deltas
+}
+
+// Close stops the subscription.
+func (s *DeltaSubscription) Close() {
+	s.ring.mu.Lock()
+	defer s.ring.mu.Unlock()
+	s.ring.drop(s)
+}
+
+// record appends d to the ring and sends it to the subscribers. Deltas whose version isn't a
+// number are sent but not kept, as they can't be resumed from.
+func (r *DeltaRing) record(d Delta) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	first := !r.recorded
+	r.recorded = true
+
+	version, err := strconv.ParseUint(objectResourceVersion(d.Object), 10, 64)
+	switch {
+	case err != nil:
+		r.entries = nil
+		r.setFloor()
+	case len(r.entries) > 0 && version <= r.entries[len(r.entries)-1].ResourceVersion:
+		// A relist replays objects out of order, so subscribers start over from a snapshot.
+		r.reset()
+		return
+	default:
+		d.ResourceVersion = version
+		if first {
+			// This is the first delta after the initial list, so the ring is complete from here.
+			r.floor, r.floorSet = version-1, true
+		}
+		if len(r.entries) == r.size {
+			if r.entries[0].ResourceVersion > r.floor {
+				r.floor = r.entries[0].ResourceVersion
+			}
+			r.entries = r.entries[1:]
+		}
+		r.entries = append(r.entries, d)
+	}
+
+	for sub := range r.subscribers {
+		select {
+		case sub.deltas <- d:
+		default:
+			// A blocked subscriber must not hold back the informer or the other subscribers.
+			r.drop(sub)
+		}
+	}
+}
+
+// ensureFloor sets the floor from the informer's version if nothing has been recorded since it
+// synced; every later delta is newer. Reports whether the ring can be resumed from.
+func (r *DeltaRing) ensureFloor() bool {
+	if !r.floorSet {
+		if !r.informer.HasSynced() {
+			return false
+		}
+		r.setFloor()
+	}
+	return r.floor != math.MaxUint64
+}
+
+// reset forgets every recorded delta and drops the subscribers, so that clients resume from a
+// snapshot of the relisted cache.
+func (r *DeltaRing) reset() {
+	r.entries = nil
+	r.setFloor()
+	for sub := range r.subscribers {
+		r.drop(sub)
+	}
+}
+
+func (r *DeltaRing) setFloor() {
+	version, err := strconv.ParseUint(r.informer.LastSyncResourceVersion(), 10, 64)
+	if err != nil {
+		version = math.MaxUint64
+	}
+	r.floor, r.floorSet = version, true
+}
+
+func (r *DeltaRing) drop(sub *DeltaSubscription) {
+	if _, ok := r.subscribers[sub]; ok {
+		delete(r.subscribers, sub)
+		close(sub.deltas)
+	}
+}
+
+func objectResourceVersion(obj interface{}) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetResourceVersion()
+}
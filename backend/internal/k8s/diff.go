@@ -0,0 +1,132 @@
+package k8s
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Field change operations reported by DiffObjects.
+const (
+	DiffAdd     = "add"
+	DiffRemove  = "remove"
+	DiffReplace = "replace"
+)
+
+// diffIgnoredFields change on every write and are left out of diffs.
+var diffIgnoredFields = [][]string{
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "generation"},
+}
+
+// FieldChange is a single difference between two objects. Path uses the JSONPath-like dotted form
+// of kubectl explain, with list indexes in brackets.
+type FieldChange struct {
+	Path string      `json:"path"`
+	Op   string      `json:"op"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// StripManagedFields removes metadata.managedFields, which is noise when viewing or editing an object.
+func StripManagedFields(obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+}
+
+// DiffObjects returns the field changes that turn live into desired, sorted by path. live may be nil
+// for an object that does not exist yet.
+func DiffObjects(live, desired *unstructured.Unstructured) []FieldChange {
+	var from, to map[string]interface{}
+	if live != nil {
+		from = live.DeepCopy().Object
+	}
+	if desired != nil {
+		to = desired.DeepCopy().Object
+	}
+	for _, field := range diffIgnoredFields {
+		unstructured.RemoveNestedField(from, field...)
+		unstructured.RemoveNestedField(to, field...)
+	}
+
+	return DiffValues(from, to)
+}
+
+// DiffValues returns the changes that turn one JSON-like value tree into another, sorted by path.
+func DiffValues(from, to map[string]interface{}) []FieldChange {
+	changes := make([]FieldChange, 0)
+	diffValues("", from, to, &changes)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffValues(path string, from, to interface{}, changes *[]FieldChange) {
+	switch fromValue := from.(type) {
+	case map[string]interface{}:
+		if toValue, ok := to.(map[string]interface{}); ok {
+			diffMaps(path, fromValue, toValue, changes)
+			return
+		}
+	case []interface{}:
+		if toValue, ok := to.([]interface{}); ok {
+			diffLists(path, fromValue, toValue, changes)
+			return
+		}
+	}
+
+	if reflect.DeepEqual(from, to) {
+		return
+	}
+	switch {
+	case from == nil:
+		*changes = append(*changes, FieldChange{Path: path, Op: DiffAdd, New: to})
+	case to == nil:
+		*changes = append(*changes, FieldChange{Path: path, Op: DiffRemove, Old: from})
+	default:
+		*changes = append(*changes, FieldChange{Path: path, Op: DiffReplace, Old: from, New: to})
+	}
+}
+
+func diffMaps(path string, from, to map[string]interface{}, changes *[]FieldChange) {
+	for key, fromValue := range from {
+		toValue, ok := to[key]
+		if !ok {
+			*changes = append(*changes, FieldChange{Path: joinFieldPath(path, key), Op: DiffRemove, Old: fromValue})
+			continue
+		}
+		diffValues(joinFieldPath(path, key), fromValue, toValue, changes)
+	}
+	for key, toValue := range to {
+		if _, ok := from[key]; !ok {
+			*changes = append(*changes, FieldChange{Path: joinFieldPath(path, key), Op: DiffAdd, New: toValue})
+		}
+	}
+}
+
+func diffLists(path string, from, to []interface{}, changes *[]FieldChange) {
+	for i := 0; i < len(from) || i < len(to); i++ {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(to):
+			*changes = append(*changes, FieldChange{Path: itemPath, Op: DiffRemove, Old: from[i]})
+		case i >= len(from):
+			*changes = append(*changes, FieldChange{Path: itemPath, Op: DiffAdd, New: to[i]})
+		default:
+			diffValues(itemPath, from[i], to[i], changes)
+		}
+	}
+}
+
+// joinFieldPath appends key to path, quoting keys that contain dots such as annotation names.
+func joinFieldPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
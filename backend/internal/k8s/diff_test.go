@@ -0,0 +1,124 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDiffValues(t *testing.T) {
+	tests := []struct {
+		name string
+		from map[string]interface{}
+		to   map[string]interface{}
+		want []FieldChange
+	}{
+		{
+			name: "equal",
+			from: map[string]interface{}{"a": int64(1), "b": []interface{}{"x"}},
+			to:   map[string]interface{}{"a": int64(1), "b": []interface{}{"x"}},
+			want: []FieldChange{},
+		},
+		{
+			name: "add, remove and replace",
+			from: map[string]interface{}{"keep": "v", "old": "gone", "n": int64(1)},
+			to:   map[string]interface{}{"keep": "v", "new": "here", "n": int64(2)},
+			want: []FieldChange{
+				{Path: "n", Op: DiffReplace, Old: int64(1), New: int64(2)},
+				{Path: "new", Op: DiffAdd, New: "here"},
+				{Path: "old", Op: DiffRemove, Old: "gone"},
+			},
+		},
+		{
+			name: "nested maps",
+			from: map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+			to:   map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(3)}},
+			want: []FieldChange{{Path: "spec.replicas", Op: DiffReplace, Old: int64(1), New: int64(3)}},
+		},
+		{
+			name: "lists by index",
+			from: map[string]interface{}{"args": []interface{}{"a", "b", "c"}},
+			to:   map[string]interface{}{"args": []interface{}{"a", "B"}},
+			want: []FieldChange{
+				{Path: "args[1]", Op: DiffReplace, Old: "b", New: "B"},
+				{Path: "args[2]", Op: DiffRemove, Old: "c"},
+			},
+		},
+		{
+			name: "list grows",
+			from: map[string]interface{}{"args": []interface{}{"a"}},
+			to:   map[string]interface{}{"args": []interface{}{"a", "b"}},
+			want: []FieldChange{{Path: "args[1]", Op: DiffAdd, New: "b"}},
+		},
+		{
+			name: "type change",
+			from: map[string]interface{}{"v": map[string]interface{}{"a": "b"}},
+			to:   map[string]interface{}{"v": "flat"},
+			want: []FieldChange{{Path: "v", Op: DiffReplace, Old: map[string]interface{}{"a": "b"}, New: "flat"}},
+		},
+		{
+			name: "dotted keys are quoted",
+			from: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{}}},
+			to: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{
+				"kubrowser.io/owner": "alice",
+			}}},
+			want: []FieldChange{{Path: `metadata.annotations["kubrowser.io/owner"]`, Op: DiffAdd, New: "alice"}},
+		},
+		{
+			name: "from nothing",
+			from: nil,
+			to:   map[string]interface{}{"kind": "ConfigMap"},
+			want: []FieldChange{{Path: "kind", Op: DiffAdd, New: "ConfigMap"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffValues(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffValues() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffObjectsIgnoresServerFields(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"resourceVersion": "1",
+			"generation":      int64(1),
+			"managedFields":   []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"data": map[string]interface{}{"key": "old"},
+	}}
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"resourceVersion": "2",
+			"generation":      int64(2),
+		},
+		"data": map[string]interface{}{"key": "new"},
+	}}
+
+	want := []FieldChange{{Path: "data.key", Op: DiffReplace, Old: "old", New: "new"}}
+	if got := DiffObjects(live, desired); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffObjects() = %#v, want %#v", got, want)
+	}
+	if _, ok := live.Object["metadata"].(map[string]interface{})["managedFields"]; !ok {
+		t.Error("DiffObjects modified the live object")
+	}
+}
+
+func TestDiffObjectsCreate(t *testing.T) {
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "ConfigMap",
+		"metadata": map[string]interface{}{"name": "new", "resourceVersion": ""},
+	}}
+	want := []FieldChange{
+		{Path: "kind", Op: DiffAdd, New: "ConfigMap"},
+		{Path: "metadata", Op: DiffAdd, New: map[string]interface{}{"name": "new"}},
+	}
+	if got := DiffObjects(nil, desired); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffObjects(nil, desired) = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,241 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+	// evictionRetryInterval is how long to wait before retrying an eviction blocked by a PodDisruptionBudget.
+	evictionRetryInterval = 5 * time.Second
+)
+
+// Drain event types reported through the progress callback.
+const (
+	DrainEventCordoned = "cordoned"
+	DrainEventSkipped  = "skipped"
+	DrainEventEvicting = "evicting"
+	DrainEventBlocked  = "blocked"
+	DrainEventEvicted  = "evicted"
+	DrainEventDeleted  = "deleted"
+	DrainEventError    = "error"
+	DrainEventDone     = "done"
+)
+
+// DrainOptions controls how a node is drained, mirroring the kubectl drain flags.
+type DrainOptions struct {
+	IgnoreDaemonSets   bool
+	DeleteEmptyDirData bool
+	Force              bool
+	// GracePeriodSeconds overrides the pod's termination grace period when non-nil.
+	GracePeriodSeconds *int64
+	Timeout            time.Duration
+}
+
+// DrainEvent reports progress of a drain for a single pod or for the node as a whole.
+type DrainEvent struct {
+	Type      string    `json:"type"`
+	Namespace string    `json:"namespace,omitempty"`
+	Pod       string    `json:"pod,omitempty"`
+	Message   string    `json:"message"`
+	Attempt   int       `json:"attempt,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// DrainProgress receives drain events. It may be called from multiple goroutines, but never concurrently.
+type DrainProgress func(event DrainEvent)
+
+// SetNodeUnschedulable cordons (true) or uncordons (false) a node by patching spec.unschedulable.
+func SetNodeUnschedulable(ctx context.Context, client kubernetes.Interface, name string, unschedulable bool) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable))
+	_, err := client.CoreV1().Nodes().Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// DrainNode cordons a node and evicts its pods through the Eviction API, retrying evictions
+// that are blocked by a PodDisruptionBudget until the timeout expires.
+func DrainNode(ctx context.Context, client kubernetes.Interface, name string, opts DrainOptions, progress DrainProgress) error {
+	var mu sync.Mutex
+	report := func(event DrainEvent) {
+		event.Time = time.Now()
+		mu.Lock()
+		defer mu.Unlock()
+		progress(event)
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	if err := SetNodeUnschedulable(ctx, client, name, true); err != nil {
+		return fmt.Errorf("failed to cordon node: %w", err)
+	}
+	report(DrainEvent{Type: DrainEventCordoned, Message: fmt.Sprintf("node %s cordoned", name)})
+
+	podList, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", name).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods on node: %w", err)
+	}
+
+	toEvict, err := filterDrainablePods(podList.Items, opts, report)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(toEvict))
+	for _, pod := range toEvict {
+		wg.Add(1)
+		go func(pod *v1.Pod) {
+			defer wg.Done()
+			if evictErr := evictPod(ctx, client, pod, opts, report); evictErr != nil {
+				report(DrainEvent{Type: DrainEventError, Namespace: pod.Namespace, Pod: pod.Name, Message: evictErr.Error()})
+				errCh <- evictErr
+			}
+		}(pod)
+	}
+	wg.Wait()
+	close(errCh)
+
+	failed := 0
+	for range errCh {
+		failed++
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to evict %d of %d pods", failed, len(toEvict))
+	}
+
+	report(DrainEvent{Type: DrainEventDone, Message: fmt.Sprintf("node %s drained", name)})
+	return nil
+}
+
+// filterDrainablePods returns the pods that must be evicted, reporting the ones skipped. Like kubectl,
+// it refuses to start if any pod can't be evicted safely with the given options.
+func filterDrainablePods(pods []v1.Pod, opts DrainOptions, report DrainProgress) ([]*v1.Pod, error) {
+	toEvict := make([]*v1.Pod, 0, len(pods))
+	problems := make([]string, 0)
+
+	for i := range pods {
+		pod := &pods[i]
+		if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+			report(DrainEvent{Type: DrainEventSkipped, Namespace: pod.Namespace, Pod: pod.Name, Message: "mirror pod"})
+			continue
+		}
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			toEvict = append(toEvict, pod)
+			continue
+		}
+
+		controller := metav1.GetControllerOf(pod)
+		if controller != nil && controller.Kind == "DaemonSet" {
+			if opts.IgnoreDaemonSets {
+				report(DrainEvent{Type: DrainEventSkipped, Namespace: pod.Namespace, Pod: pod.Name, Message: "managed by DaemonSet"})
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("%s/%s is managed by a DaemonSet", pod.Namespace, pod.Name))
+			continue
+		}
+		if controller == nil && !opts.Force {
+			problems = append(problems, fmt.Sprintf("%s/%s is not managed by a controller", pod.Namespace, pod.Name))
+			continue
+		}
+		if hasEmptyDir(pod) && !opts.DeleteEmptyDirData {
+			problems = append(problems, fmt.Sprintf("%s/%s uses emptyDir data", pod.Namespace, pod.Name))
+			continue
+		}
+
+		toEvict = append(toEvict, pod)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("cannot drain node: %s", strings.Join(problems, "; "))
+	}
+	return toEvict, nil
+}
+
+// evictPod evicts a single pod, retrying while a PodDisruptionBudget blocks it, and waits for it to be deleted.
+func evictPod(ctx context.Context, client kubernetes.Interface, pod *v1.Pod, opts DrainOptions, report DrainProgress) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: opts.GracePeriodSeconds,
+		},
+	}
+
+	for attempt := 1; ; attempt++ {
+		report(DrainEvent{Type: DrainEventEvicting, Namespace: pod.Namespace, Pod: pod.Name, Message: "evicting pod", Attempt: attempt})
+
+		err := client.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+		if err == nil {
+			break
+		}
+		if errors.IsNotFound(err) {
+			report(DrainEvent{Type: DrainEventDeleted, Namespace: pod.Namespace, Pod: pod.Name, Message: "pod already gone"})
+			return nil
+		}
+		if !errors.IsTooManyRequests(err) {
+			return fmt.Errorf("eviction failed: %w", err)
+		}
+
+		// 429 means the eviction would violate a PodDisruptionBudget.
+		report(DrainEvent{
+			Type:      DrainEventBlocked,
+			Namespace: pod.Namespace,
+			Pod:       pod.Name,
+			Message:   fmt.Sprintf("blocked by PodDisruptionBudget, retrying in %v", evictionRetryInterval),
+			Attempt:   attempt,
+		})
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for PodDisruptionBudget: %w", ctx.Err())
+		case <-time.After(evictionRetryInterval):
+		}
+	}
+
+	report(DrainEvent{Type: DrainEventEvicted, Namespace: pod.Namespace, Pod: pod.Name, Message: "eviction accepted, waiting for deletion"})
+
+	err := wait.PollUntilContextCancel(ctx, 2*time.Second, true, func(ctx context.Context) (bool, error) {
+		current, getErr := client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if errors.IsNotFound(getErr) || (getErr == nil && current.UID != pod.UID) {
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for pod deletion: %w", err)
+	}
+
+	report(DrainEvent{Type: DrainEventDeleted, Namespace: pod.Namespace, Pod: pod.Name, Message: "pod deleted"})
+	return nil
+}
+
+func hasEmptyDir(pod *v1.Pod) bool {
+	for i := range pod.Spec.Volumes {
+		if pod.Spec.Volumes[i].EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
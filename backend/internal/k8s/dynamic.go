@@ -0,0 +1,289 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// CoreGroup is the name used in URLs for the legacy core API group, whose real name is empty.
+const CoreGroup = "core"
+
+// FieldManager is the field manager recorded for changes applied through Kubrowser.
+const FieldManager = "kubrowser"
+
+// tableAcceptHeader asks the API server for the server-side Table format used by kubectl get.
+const tableAcceptHeader = "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"
+
+// ErrResourceNotFound is returned when discovery does not know the requested resource.
+var ErrResourceNotFound = errors.New("resource not found in API discovery")
+
+// APIResource describes a resource served by the API server.
+type APIResource struct {
+	Group      string   `json:"group"`
+	Version    string   `json:"version"`
+	Resource   string   `json:"resource"`
+	Kind       string   `json:"kind"`
+	Namespaced bool     `json:"namespaced"`
+	Verbs      []string `json:"verbs"`
+	ShortNames []string `json:"shortNames,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// GroupVersionResource returns the resource's GVR.
+func (r APIResource) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// Supports reports whether the resource supports a verb such as list or delete.
+func (r APIResource) Supports(verb string) bool {
+	for _, v := range r.Verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceBrowser reads and deletes arbitrary resources, including custom resources, using API
+// discovery and the dynamic client.
+type ResourceBrowser struct {
+	discovery discovery.CachedDiscoveryInterface
+	dynamic   dynamic.Interface
+	rest      rest.Interface
+}
+
+// NewResourceBrowser creates a resource browser from a REST config.
+func NewResourceBrowser(config *rest.Config) (*ResourceBrowser, error) {
+	if config == nil {
+		return nil, fmt.Errorf("rest config is required")
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	return &ResourceBrowser{
+		discovery: memory.NewMemCacheClient(discoveryClient),
+		dynamic:   dynamicClient,
+		rest:      discoveryClient.RESTClient(),
+	}, nil
+}
+
+// Dynamic returns the underlying dynamic client.
+func (rb *ResourceBrowser) Dynamic() dynamic.Interface {
+	return rb.dynamic
+}
+
+// APIResources returns the preferred version of every resource the API server serves, excluding
+// subresources. Groups that fail discovery (for example an unavailable aggregated API) are skipped.
+func (rb *ResourceBrowser) APIResources() ([]APIResource, error) {
+	lists, err := rb.discovery.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("failed to discover API resources: %w", err)
+	}
+
+	result := make([]APIResource, 0)
+	for _, list := range lists {
+		gv, parseErr := schema.ParseGroupVersion(list.GroupVersion)
+		if parseErr != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			result = append(result, APIResource{
+				Group:      gv.Group,
+				Version:    gv.Version,
+				Resource:   r.Name,
+				Kind:       r.Kind,
+				Namespaced: r.Namespaced,
+				Verbs:      r.Verbs,
+				ShortNames: r.ShortNames,
+				Categories: r.Categories,
+			})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Group != result[j].Group {
+			return result[i].Group < result[j].Group
+		}
+		return result[i].Resource < result[j].Resource
+	})
+	return result, nil
+}
+
+// Resolve looks up a resource by group, version and plural name. Discovery is refreshed once
+// before giving up, so CRDs installed after startup are found.
+func (rb *ResourceBrowser) Resolve(group, version, resource string) (APIResource, error) {
+	if group == CoreGroup {
+		group = ""
+	}
+	gv := schema.GroupVersion{Group: group, Version: version}.String()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		list, err := rb.discovery.ServerResourcesForGroupVersion(gv)
+		if err == nil {
+			for _, r := range list.APIResources {
+				if r.Name == resource {
+					return APIResource{
+						Group:      group,
+						Version:    version,
+						Resource:   r.Name,
+						Kind:       r.Kind,
+						Namespaced: r.Namespaced,
+						Verbs:      r.Verbs,
+						ShortNames: r.ShortNames,
+						Categories: r.Categories,
+					}, nil
+				}
+			}
+		}
+		// The cache answers ErrCacheNotFound for a group version it hasn't seen since its last
+		// refresh, such as one served by a CRD installed after startup.
+		if err != nil && !apierrors.IsNotFound(err) && !errors.Is(err, memory.ErrCacheNotFound) {
+			return APIResource{}, fmt.Errorf("failed to discover %s: %w", gv, err)
+		}
+		rb.discovery.Invalidate()
+	}
+	return APIResource{}, fmt.Errorf("%w: %s %s", ErrResourceNotFound, gv, resource)
+}
+
+// List lists objects of a resource. namespace is ignored for cluster-scoped resources.
+func (rb *ResourceBrowser) List(ctx context.Context, r APIResource, namespace string,
+	opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return rb.resourceInterface(r, namespace).List(ctx, opts)
+}
+
+// Get returns a single object.
+func (rb *ResourceBrowser) Get(ctx context.Context, r APIResource, namespace, name string) (*unstructured.Unstructured, error) {
+	return rb.resourceInterface(r, namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// Delete deletes a single object.
+func (rb *ResourceBrowser) Delete(ctx context.Context, r APIResource, namespace, name string, opts metav1.DeleteOptions) error {
+	return rb.resourceInterface(r, namespace).Delete(ctx, name, opts)
+}
+
+// Apply applies obj with server-side apply under the kubrowser field manager. With dryRun the
+// server computes the result without persisting it. force takes ownership of conflicting fields.
+func (rb *ResourceBrowser) Apply(ctx context.Context, r APIResource, namespace string, obj *unstructured.Unstructured,
+	dryRun, force bool) (*unstructured.Unstructured, error) {
+	opts := metav1.ApplyOptions{FieldManager: FieldManager, Force: force}
+	if dryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	return rb.resourceInterface(r, namespace).Apply(ctx, obj.GetName(), obj, opts)
+}
+
+// ApplyConflict is a field owned by another manager that blocked a server-side apply.
+type ApplyConflict struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ApplyConflicts extracts the field manager conflicts from a server-side apply error. It returns
+// nil if err is not an apply conflict.
+func ApplyConflicts(err error) []ApplyConflict {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || !apierrors.IsConflict(err) {
+		return nil
+	}
+
+	details := status.Status().Details
+	if details == nil {
+		return nil
+	}
+	conflicts := make([]ApplyConflict, 0, len(details.Causes))
+	for _, cause := range details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			conflicts = append(conflicts, ApplyConflict{Field: cause.Field, Message: cause.Message})
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return conflicts
+}
+
+// ListTable lists objects in the server-side Table format, so columns match kubectl get.
+func (rb *ResourceBrowser) ListTable(ctx context.Context, r APIResource, namespace string, opts metav1.ListOptions) (*metav1.Table, error) {
+	req := rb.rest.Get().AbsPath(resourcePath(r, namespace, "")...).SetHeader("Accept", tableAcceptHeader)
+	if opts.LabelSelector != "" {
+		req = req.Param("labelSelector", opts.LabelSelector)
+	}
+	if opts.FieldSelector != "" {
+		req = req.Param("fieldSelector", opts.FieldSelector)
+	}
+	if opts.Limit > 0 {
+		req = req.Param("limit", fmt.Sprint(opts.Limit))
+	}
+	if opts.Continue != "" {
+		req = req.Param("continue", opts.Continue)
+	}
+	return doTable(ctx, req)
+}
+
+// GetTable returns a single object in the server-side Table format.
+func (rb *ResourceBrowser) GetTable(ctx context.Context, r APIResource, namespace, name string) (*metav1.Table, error) {
+	req := rb.rest.Get().AbsPath(resourcePath(r, namespace, name)...).SetHeader("Accept", tableAcceptHeader)
+	return doTable(ctx, req)
+}
+
+func (rb *ResourceBrowser) resourceInterface(r APIResource, namespace string) dynamic.ResourceInterface {
+	if r.Namespaced {
+		return rb.dynamic.Resource(r.GroupVersionResource()).Namespace(namespace)
+	}
+	return rb.dynamic.Resource(r.GroupVersionResource())
+}
+
+// resourcePath builds the REST path of a resource collection or object.
+func resourcePath(r APIResource, namespace, name string) []string {
+	segments := []string{"/apis", r.Group, r.Version}
+	if r.Group == "" {
+		segments = []string{"/api", r.Version}
+	}
+	if r.Namespaced && namespace != "" {
+		segments = append(segments, "namespaces", namespace)
+	}
+	segments = append(segments, r.Resource)
+	if name != "" {
+		segments = append(segments, name)
+	}
+	return segments
+}
+
+func doTable(ctx context.Context, req *rest.Request) (*metav1.Table, error) {
+	data, err := req.DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var table metav1.Table
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal table: %w", err)
+	}
+	if table.Kind != "Table" {
+		return nil, fmt.Errorf("server did not return a table for this resource")
+	}
+	return &table, nil
+}
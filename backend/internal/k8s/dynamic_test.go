@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestResourceBrowserResolve(t *testing.T) {
+	fake := &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod", Namespaced: true}}},
+	}}
+	rb := &ResourceBrowser{discovery: memory.NewMemCacheClient(&fakediscovery.FakeDiscovery{Fake: fake})}
+
+	pods, err := rb.Resolve(CoreGroup, "v1", "pods")
+	if err != nil {
+		t.Fatalf("Resolve(pods) error = %v", err)
+	}
+	if pods.Group != "" || pods.Kind != "Pod" || !pods.Namespaced {
+		t.Errorf("Resolve(pods) = %+v", pods)
+	}
+
+	if _, err := rb.Resolve("cert-manager.io", "v1", "certificates"); !errors.Is(err, ErrResourceNotFound) {
+		t.Fatalf("Resolve(unknown group) error = %v, want ErrResourceNotFound", err)
+	}
+	if _, err := rb.Resolve(CoreGroup, "v1", "widgets"); !errors.Is(err, ErrResourceNotFound) {
+		t.Fatalf("Resolve(unknown resource) error = %v, want ErrResourceNotFound", err)
+	}
+
+	// A CRD installed after the cache was filled is found once discovery is refreshed.
+	fake.Resources = append(fake.Resources, &metav1.APIResourceList{
+		GroupVersion: "cert-manager.io/v1",
+		APIResources: []metav1.APIResource{{Name: "certificates", Kind: "Certificate", Namespaced: true}},
+	})
+	certificates, err := rb.Resolve("cert-manager.io", "v1", "certificates")
+	if err != nil {
+		t.Fatalf("Resolve(certificates) error = %v", err)
+	}
+	if certificates.Kind != "Certificate" || certificates.Group != "cert-manager.io" {
+		t.Errorf("Resolve(certificates) = %+v", certificates)
+	}
+}
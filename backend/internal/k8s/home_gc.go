@@ -0,0 +1,201 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// Reasons a home volume is collected.
+const (
+	OrphanReasonRetention  = "retention"
+	OrphanReasonNotAllowed = "not-allowed"
+)
+
+// homeSnapshotPVCUIDLabel records the UID of the PVC a home volume snapshot was taken of, so a
+// snapshot of an earlier volume with the same name is never mistaken for one of the current volume.
+const homeSnapshotPVCUIDLabel = "kubrowser.io/pvc-uid"
+
+var volumeSnapshotGVR = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}
+
+// HomeVolumeGCOptions selects which home volumes are orphaned and how they are removed.
+type HomeVolumeGCOptions struct {
+	// Retention is how long a volume may go unused; zero disables the check.
+	Retention time.Duration
+	// AllowedUsers is the login allowlist; volumes of users not in it are orphaned. Empty disables the check.
+	AllowedUsers []string
+	// Snapshot takes a VolumeSnapshot before deleting, when the snapshot API is served.
+	Snapshot      bool
+	SnapshotClass string
+}
+
+// OrphanedHomeVolume is a home volume selected for garbage collection.
+type OrphanedHomeVolume struct {
+	HomeVolume
+	Reason string `json:"reason"`
+	// Snapshot names the VolumeSnapshot taken, or to be taken, before deletion.
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+// FindOrphanedHomeVolumes returns the home volumes that are unused for longer than the retention
+// window or belong to users removed from the allowlist. Volumes mounted by a session are never orphaned,
+// and a volume without a last-used time is stamped with the current time instead of being orphaned.
+func (pm *PodManager) FindOrphanedHomeVolumes(ctx context.Context, opts HomeVolumeGCOptions) ([]OrphanedHomeVolume, error) {
+	volumes, err := pm.ListHomeVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[string]bool, len(opts.AllowedUsers))
+	for _, user := range opts.AllowedUsers {
+		allowed[sessionUsername(user)] = true
+	}
+
+	now := time.Now()
+	var orphaned []OrphanedHomeVolume
+	for _, volume := range volumes {
+		if volume.ActivePod != "" {
+			continue
+		}
+
+		reason := ""
+		switch {
+		case len(allowed) > 0 && !allowed[volume.Username]:
+			reason = OrphanReasonNotAllowed
+		case opts.Retention > 0 && volume.LastUsed == nil:
+			// A volume that was never stamped, such as one created before the annotation existed,
+			// may have been in use until now. Its retention window starts when it is first seen.
+			pm.touchHomePVC(ctx, volume.PVC, now)
+			continue
+		case opts.Retention > 0 && now.Sub(*volume.LastUsed) > opts.Retention:
+			reason = OrphanReasonRetention
+		default:
+			continue
+		}
+
+		orphan := OrphanedHomeVolume{HomeVolume: volume, Reason: reason}
+		if opts.Snapshot {
+			orphan.Snapshot = homeSnapshotName(volume)
+		}
+		orphaned = append(orphaned, orphan)
+	}
+	return orphaned, nil
+}
+
+// CollectHomeVolume deletes an orphaned home volume. When a snapshot was requested it is created
+// first, and the PVC is kept until the snapshot is ready to use; deleted is false in that case and
+// a later call completes the collection.
+func (pm *PodManager) CollectHomeVolume(ctx context.Context, orphan OrphanedHomeVolume, opts HomeVolumeGCOptions) (deleted bool, err error) {
+	if orphan.Snapshot != "" {
+		available, err := pm.volumeSnapshotsAvailable()
+		if err != nil {
+			return false, err
+		}
+		if available {
+			ready, err := pm.ensureHomeSnapshot(ctx, orphan.HomeVolume, orphan.Snapshot, opts.SnapshotClass)
+			if err != nil || !ready {
+				return false, err
+			}
+		}
+	}
+
+	// A session may have started since the volume was found; never delete a mounted volume.
+	pods, err := pm.ListPods(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list session pods: %w", err)
+	}
+	for i := range pods {
+		if podMountsClaim(&pods[i], orphan.PVC) {
+			return false, fmt.Errorf("%w: %s", ErrHomeVolumeInUse, pods[i].Name)
+		}
+	}
+
+	// Only delete the claim that was found, not one recreated under the same name since.
+	deleteOpts := metav1.DeleteOptions{}
+	if orphan.UID != "" {
+		uid := types.UID(orphan.UID)
+		deleteOpts.Preconditions = &metav1.Preconditions{UID: &uid}
+	}
+	err = pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Delete(ctx, orphan.PVC, deleteOpts)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to delete home volume: %w", err)
+	}
+	return true, nil
+}
+
+// volumeSnapshotsAvailable reports whether the cluster serves the snapshot.storage.k8s.io/v1 API.
+func (pm *PodManager) volumeSnapshotsAvailable() (bool, error) {
+	_, err := pm.client.Discovery().ServerResourcesForGroupVersion(volumeSnapshotGVR.GroupVersion().String())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to discover the volume snapshot API: %w", err)
+	}
+	return true, nil
+}
+
+// homeSnapshotName returns the name of the snapshot taken of a home volume before deletion. It
+// includes the PVC UID, as a user's home PVC is recreated under the same name when they return.
+func homeSnapshotName(volume HomeVolume) string {
+	return fmt.Sprintf("%s-%s", volume.PVC, volume.UID)
+}
+
+// ensureHomeSnapshot creates a VolumeSnapshot of a home volume if it doesn't exist and reports
+// whether it is ready to use. An existing snapshot must have been taken of this very PVC.
+func (pm *PodManager) ensureHomeSnapshot(ctx context.Context, volume HomeVolume, snapshotName, snapshotClass string) (bool, error) {
+	if volume.UID == "" {
+		return false, fmt.Errorf("home volume %s has no UID", volume.PVC)
+	}
+	client, err := dynamic.NewForConfig(pm.config)
+	if err != nil {
+		return false, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	snapshots := client.Resource(volumeSnapshotGVR).Namespace(pm.namespace)
+
+	snapshot, err := snapshots.Get(ctx, snapshotName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		spec := map[string]interface{}{
+			"source": map[string]interface{}{"persistentVolumeClaimName": volume.PVC},
+		}
+		if snapshotClass != "" {
+			spec["volumeSnapshotClassName"] = snapshotClass
+		}
+		snapshot = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": volumeSnapshotGVR.GroupVersion().String(),
+			"kind":       "VolumeSnapshot",
+			"metadata": map[string]interface{}{
+				"name":      snapshotName,
+				"namespace": pm.namespace,
+				"labels": map[string]interface{}{
+					"app":                   "kubrowser",
+					"managed-by":            "kubrowser-backend",
+					homeSnapshotPVCUIDLabel: volume.UID,
+				},
+			},
+			"spec": spec,
+		}}
+		snapshot, err = snapshots.Create(ctx, snapshot, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to snapshot home volume: %w", err)
+	}
+
+	source, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	if source != volume.PVC || snapshot.GetLabels()[homeSnapshotPVCUIDLabel] != volume.UID {
+		return false, fmt.Errorf("snapshot %s exists but was not taken of home volume %s (uid %s)", snapshotName, volume.PVC, volume.UID)
+	}
+
+	if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found && message != "" {
+		return false, fmt.Errorf("snapshot %s failed: %s", snapshotName, message)
+	}
+	ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	return ready, nil
+}
@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func homePVC(username string, created time.Time, lastUsed string) *v1.PersistentVolumeClaim {
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              HomePVCName(username),
+			Namespace:         "default",
+			Labels:            map[string]string{"app": "kubrowser", "managed-by": "kubrowser-backend", "username": username},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+	if lastUsed != "" {
+		pvc.Annotations = map[string]string{HomeLastUsedAnnotation: lastUsed}
+	}
+	return pvc
+}
+
+func TestFindOrphanedHomeVolumes(t *testing.T) {
+	old := time.Now().Add(-90 * 24 * time.Hour)
+	recent := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	client := fake.NewSimpleClientset(
+		homePVC("alice", old, old.Format(time.RFC3339)),
+		homePVC("bob", old, recent),
+		homePVC("carol", old, ""),
+		homePVC("dave", old, recent),
+	)
+	pm := &PodManager{client: client, namespace: "default"}
+
+	orphaned, err := pm.FindOrphanedHomeVolumes(context.Background(), HomeVolumeGCOptions{
+		Retention:    30 * 24 * time.Hour,
+		AllowedUsers: []string{"alice", "bob", "carol"},
+	})
+	if err != nil {
+		t.Fatalf("FindOrphanedHomeVolumes() error = %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, orphan := range orphaned {
+		got[orphan.Username] = orphan.Reason
+	}
+	want := map[string]string{"alice": OrphanReasonRetention, "dave": OrphanReasonNotAllowed}
+	if len(got) != len(want) {
+		t.Fatalf("orphaned = %v, want %v", got, want)
+	}
+	for username, reason := range want {
+		if got[username] != reason {
+			t.Errorf("orphaned[%s] = %q, want %q", username, got[username], reason)
+		}
+	}
+
+	// A volume without a last-used time is stamped, so its retention window starts now.
+	pvc, err := client.CoreV1().PersistentVolumeClaims("default").Get(context.Background(), HomePVCName("carol"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := time.Parse(time.RFC3339, pvc.Annotations[HomeLastUsedAnnotation]); err != nil {
+		t.Errorf("last-used annotation = %q, want a timestamp", pvc.Annotations[HomeLastUsedAnnotation])
+	}
+}
@@ -0,0 +1,487 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Home volume modes.
+const (
+	// HomeVolumePersistent keeps the home directory on a PersistentVolumeClaim that outlives sessions.
+	HomeVolumePersistent = "persistent"
+	// HomeVolumeEphemeral keeps the home directory on an emptyDir that is lost when the pod goes away.
+	HomeVolumeEphemeral = "ephemeral"
+)
+
+// HomeMaxSizeAnnotation records the size up to which a home volume may be expanded automatically.
+const HomeMaxSizeAnnotation = "kubrowser.io/max-size"
+
+// homeExpandThreshold is the fraction of capacity in use at which a home volume is expanded.
+const homeExpandThreshold = 0.9
+
+// HomeVolumeConfig describes how a user's home directory is provisioned. In an override, empty
+// fields inherit the default.
+type HomeVolumeConfig struct {
+	Mode string
+	// StorageClass is the StorageClass of the PVC; empty uses the cluster default.
+	StorageClass string
+	Size         string
+	AccessMode   string
+	// MaxSize is the size up to which a full volume is expanded automatically; empty disables expansion.
+	MaxSize string
+}
+
+// homeVolumeSpec is a validated HomeVolumeConfig.
+type homeVolumeSpec struct {
+	ephemeral    bool
+	storageClass string
+	size         resource.Quantity
+	accessMode   v1.PersistentVolumeAccessMode
+	maxSize      *resource.Quantity
+}
+
+// HomeVolumePolicy resolves the home volume of a user from defaults and per-user and per-role overrides.
+// A user override takes precedence over a role override.
+type HomeVolumePolicy struct {
+	defaults HomeVolumeConfig
+	users    map[string]HomeVolumeConfig
+	roles    map[string]HomeVolumeConfig
+}
+
+// DefaultHomeVolumeConfig is a 1Gi ReadWriteOnce volume of the default StorageClass.
+func DefaultHomeVolumeConfig() HomeVolumeConfig {
+	return HomeVolumeConfig{
+		Mode:       HomeVolumePersistent,
+		Size:       "1Gi",
+		AccessMode: string(v1.ReadWriteOnce),
+	}
+}
+
+// NewHomeVolumePolicy creates a policy, validating the defaults and every override so that
+// misconfiguration is reported at startup rather than when a user starts a session.
+func NewHomeVolumePolicy(defaults HomeVolumeConfig, users, roles map[string]HomeVolumeConfig) (*HomeVolumePolicy, error) {
+	policy := &HomeVolumePolicy{
+		defaults: defaults,
+		users:    make(map[string]HomeVolumeConfig, len(users)),
+		roles:    make(map[string]HomeVolumeConfig, len(roles)),
+	}
+	if _, err := parseHomeVolumeSpec(defaults); err != nil {
+		return nil, fmt.Errorf("invalid home volume config: %w", err)
+	}
+	for role, override := range roles {
+		policy.roles[strings.ToLower(role)] = override
+		if _, err := parseHomeVolumeSpec(mergeHomeVolumeConfig(defaults, override)); err != nil {
+			return nil, fmt.Errorf("invalid home volume override for role %s: %w", role, err)
+		}
+	}
+	for user, override := range users {
+		policy.users[strings.ToLower(user)] = override
+		if _, err := parseHomeVolumeSpec(mergeHomeVolumeConfig(defaults, override)); err != nil {
+			return nil, fmt.Errorf("invalid home volume override for user %s: %w", user, err)
+		}
+	}
+	return policy, nil
+}
+
+// ParseHomeVolumeOverrides parses overrides keyed by username or role, each of the form
+// "size:5Gi;storageClass:fast;mode:ephemeral". Keys are mode, storageClass, size, accessMode and maxSize.
+func ParseHomeVolumeOverrides(values map[string]string) (map[string]HomeVolumeConfig, error) {
+	result := make(map[string]HomeVolumeConfig, len(values))
+	for name, value := range values {
+		override, err := parseHomeVolumeOverride(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid home volume override for %s: %w", name, err)
+		}
+		result[name] = override
+	}
+	return result, nil
+}
+
+func parseHomeVolumeOverride(value string) (HomeVolumeConfig, error) {
+	var override HomeVolumeConfig
+	for _, field := range strings.Split(value, ";") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(field, ":")
+		if !ok {
+			return override, fmt.Errorf("expected key:value, got %q", field)
+		}
+		val = strings.TrimSpace(val)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "mode":
+			override.Mode = val
+		case "storageclass", "class":
+			override.StorageClass = val
+		case "size":
+			override.Size = val
+		case "accessmode":
+			override.AccessMode = val
+		case "maxsize":
+			override.MaxSize = val
+		default:
+			return override, fmt.Errorf("unknown home volume setting %q", key)
+		}
+	}
+	return override, nil
+}
+
+// For returns the home volume configuration of a user with the given role.
+func (p *HomeVolumePolicy) For(username, role string) HomeVolumeConfig {
+	if p == nil {
+		return DefaultHomeVolumeConfig()
+	}
+	cfg := p.defaults
+	if override, ok := p.roles[strings.ToLower(role)]; ok {
+		cfg = mergeHomeVolumeConfig(cfg, override)
+	}
+	if override, ok := p.users[strings.ToLower(username)]; ok {
+		cfg = mergeHomeVolumeConfig(cfg, override)
+	}
+	return cfg
+}
+
+// spec returns the validated home volume of a user. The policy was validated when it was created.
+func (p *HomeVolumePolicy) spec(username, role string) homeVolumeSpec {
+	spec, err := parseHomeVolumeSpec(p.For(username, role))
+	if err != nil {
+		spec, _ = parseHomeVolumeSpec(DefaultHomeVolumeConfig())
+	}
+	return spec
+}
+
+func mergeHomeVolumeConfig(base, override HomeVolumeConfig) HomeVolumeConfig {
+	if override.Mode != "" {
+		base.Mode = override.Mode
+	}
+	if override.StorageClass != "" {
+		base.StorageClass = override.StorageClass
+	}
+	if override.Size != "" {
+		base.Size = override.Size
+	}
+	if override.AccessMode != "" {
+		base.AccessMode = override.AccessMode
+	}
+	if override.MaxSize != "" {
+		base.MaxSize = override.MaxSize
+	}
+	return base
+}
+
+func parseHomeVolumeSpec(cfg HomeVolumeConfig) (homeVolumeSpec, error) {
+	var spec homeVolumeSpec
+
+	switch strings.ToLower(cfg.Mode) {
+	case "", HomeVolumePersistent:
+	case HomeVolumeEphemeral:
+		spec.ephemeral = true
+	default:
+		return spec, fmt.Errorf("mode must be %s or %s, got %q", HomeVolumePersistent, HomeVolumeEphemeral, cfg.Mode)
+	}
+
+	size, err := resource.ParseQuantity(cfg.Size)
+	if err != nil || size.Sign() <= 0 {
+		return spec, fmt.Errorf("invalid size %q", cfg.Size)
+	}
+	spec.size = size
+
+	switch mode := v1.PersistentVolumeAccessMode(cfg.AccessMode); mode {
+	case "":
+		spec.accessMode = v1.ReadWriteOnce
+	case v1.ReadWriteOnce, v1.ReadWriteOncePod, v1.ReadWriteMany, v1.ReadOnlyMany:
+		spec.accessMode = mode
+	default:
+		return spec, fmt.Errorf("invalid access mode %q", cfg.AccessMode)
+	}
+
+	if cfg.MaxSize != "" {
+		maxSize, err := resource.ParseQuantity(cfg.MaxSize)
+		if err != nil {
+			return spec, fmt.Errorf("invalid max size %q", cfg.MaxSize)
+		}
+		if maxSize.Cmp(size) < 0 {
+			return spec, fmt.Errorf("max size %s is smaller than size %s", cfg.MaxSize, cfg.Size)
+		}
+		spec.maxSize = &maxSize
+	}
+
+	spec.storageClass = cfg.StorageClass
+	return spec, nil
+}
+
+// homeVolumeSource returns the pod volume backing a user's home directory.
+func homeVolumeSource(spec homeVolumeSpec, pvcName string) v1.VolumeSource {
+	if spec.ephemeral {
+		size := spec.size.DeepCopy()
+		return v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{SizeLimit: &size}}
+	}
+	return v1.VolumeSource{
+		PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: pvcName},
+	}
+}
+
+// setHomeMaxSize records the automatic expansion limit on a home PVC, removing it when expansion is
+// disabled. Failures are ignored; the PVC keeps its previous limit.
+func (pm *PodManager) setHomeMaxSize(ctx context.Context, pvc *v1.PersistentVolumeClaim, maxSize *resource.Quantity) {
+	value := "null"
+	if maxSize != nil {
+		if pvc.Annotations[HomeMaxSizeAnnotation] == maxSize.String() {
+			return
+		}
+		value = strconv.Quote(maxSize.String())
+	} else if _, ok := pvc.Annotations[HomeMaxSizeAnnotation]; !ok {
+		return
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%s}}}`, HomeMaxSizeAnnotation, value))
+	_, _ = pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Patch(ctx, pvc.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+}
+
+// homePVCPendingReason explains why a home PVC is not bound yet. It returns false once the PVC is
+// bound, or while it is only waiting for the session pod to be scheduled.
+func (pm *PodManager) homePVCPendingReason(ctx context.Context, pvcName string) (string, bool) {
+	pvc, err := pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil || pvc.Status.Phase != v1.ClaimPending {
+		return "", false
+	}
+
+	className := ""
+	if pvc.Spec.StorageClassName != nil {
+		className = *pvc.Spec.StorageClassName
+	}
+	if className == "" {
+		return "no StorageClass set and the cluster has no default StorageClass", true
+	}
+
+	class, err := pm.client.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Sprintf("StorageClass %q not found", className), true
+	}
+
+	events, err := pm.client.CoreV1().Events(pm.namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": "PersistentVolumeClaim", "involvedObject.name": pvcName}.String(),
+	})
+	if err == nil && len(events.Items) > 0 {
+		sort.Slice(events.Items, func(i, j int) bool {
+			return eventTime(&events.Items[i]).After(eventTime(&events.Items[j]))
+		})
+		latest := events.Items[0]
+		if latest.Type == v1.EventTypeWarning {
+			return fmt.Sprintf("%s: %s", latest.Reason, latest.Message), true
+		}
+		if latest.Reason == "WaitForFirstConsumer" ||
+			(class.VolumeBindingMode != nil && *class.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer) {
+			return "", false
+		}
+		return latest.Message, true
+	}
+
+	if class.VolumeBindingMode != nil && *class.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
+		return "", false
+	}
+	return fmt.Sprintf("waiting for a volume from StorageClass %q (is a provisioner running?)", className), true
+}
+
+func eventTime(event *v1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// ExpandFullHomeVolumes grows the home PVCs of running sessions that are nearly full, up to the max
+// size recorded on each PVC, when their StorageClass allows expansion. Volume usage is measured with
+// df in the terminal container of each session, through the pods/exec access sessions already need.
+func (pm *PodManager) ExpandFullHomeVolumes(ctx context.Context) error {
+	pods, err := pm.ListPods(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		pvcName, mountPath, ok := homeVolumeMount(pod)
+		if !ok {
+			continue
+		}
+		used, capacity, err := pm.homeVolumeUsage(ctx, pod, mountPath)
+		if err != nil {
+			fmt.Printf("Home volume expander: failed to measure %s in pod %s: %v\n", pvcName, pod.Name, err)
+			continue
+		}
+		if capacity == 0 || float64(used)/float64(capacity) < homeExpandThreshold {
+			continue
+		}
+		pm.expandFullHomePVC(ctx, pvcName)
+	}
+	return nil
+}
+
+// homeVolumeMount returns the home PVC of a session pod and where its terminal container mounts it.
+// Ephemeral homes have no PVC to expand.
+func homeVolumeMount(pod *v1.Pod) (pvcName, mountPath string, ok bool) {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.Name == homeVolumeName && vol.PersistentVolumeClaim != nil &&
+			strings.HasPrefix(vol.PersistentVolumeClaim.ClaimName, homePVCPrefix) {
+			pvcName = vol.PersistentVolumeClaim.ClaimName
+		}
+	}
+	if pvcName == "" {
+		return "", "", false
+	}
+
+	container := TerminalContainerName(pod)
+	for _, c := range pod.Spec.Containers {
+		if c.Name != container {
+			continue
+		}
+		for _, mount := range c.VolumeMounts {
+			if mount.Name == homeVolumeName {
+				return pvcName, mount.MountPath, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// homeVolumeUsage runs df on mountPath in the terminal container of pod and returns the bytes used
+// and the capacity of the filesystem.
+func (pm *PodManager) homeVolumeUsage(ctx context.Context, pod *v1.Pod, mountPath string) (used, capacity uint64, err error) {
+	req := pm.client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: TerminalContainerName(pod),
+			// By absolute path, so a df earlier on the user's PATH isn't run.
+			Command: []string{"/bin/df", "-Pk", mountPath},
+			Stdout:  true,
+			Stderr:  true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(pm.config, "POST", req.URL())
+	if err != nil {
+		return 0, 0, err
+	}
+	// The container belongs to the user, so its output is capped rather than trusted to be short.
+	stdout := &cappedBuffer{limit: maxDFOutput}
+	stderr := &cappedBuffer{limit: maxDFOutput}
+	if err := executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: stdout, Stderr: stderr}); err != nil {
+		return 0, 0, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseDFOutput(stdout.String())
+}
+
+// maxDFOutput is the most output kept from df; a single filesystem takes two short lines.
+const maxDFOutput = 4 * 1024
+
+// errOutputTooLarge is returned by a cappedBuffer that is written more than its limit.
+var errOutputTooLarge = errors.New("output too large")
+
+// cappedBuffer is a bytes.Buffer that fails writes beyond limit bytes, which ends the exec stream.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errOutputTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// parseDFOutput parses the output of df -Pk for a single filesystem into bytes used and capacity.
+func parseDFOutput(output string) (used, capacity uint64, err error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		return 0, 0, fmt.Errorf("unexpected df output: %q", output)
+	}
+	// Filesystem 1024-blocks Used Available Capacity Mounted-on
+	fields := strings.Fields(lines[1])
+	if len(fields) < 6 {
+		return 0, 0, fmt.Errorf("unexpected df output: %q", output)
+	}
+	blocks, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid df size %q: %w", fields[1], err)
+	}
+	usedBlocks, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid df usage %q: %w", fields[2], err)
+	}
+	return usedBlocks * 1024, blocks * 1024, nil
+}
+
+// expandFullHomePVC doubles the size of a home PVC, capped at its max size annotation.
+func (pm *PodManager) expandFullHomePVC(ctx context.Context, pvcName string) {
+	pvc, err := pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil || pvcResizing(pvc) {
+		return
+	}
+	maxSize, err := resource.ParseQuantity(pvc.Annotations[HomeMaxSizeAnnotation])
+	if err != nil {
+		return
+	}
+
+	current := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if current.Cmp(maxSize) >= 0 {
+		return
+	}
+	size := *resource.NewQuantity(current.Value()*2, resource.BinarySI)
+	if size.Cmp(maxSize) > 0 {
+		size = maxSize
+	}
+
+	if _, err := pm.expandPVC(ctx, pvc, size); err != nil {
+		fmt.Printf("Home volume expander: failed to expand %s: %v\n", pvcName, err)
+		return
+	}
+	fmt.Printf("Home volume expander: expanded %s from %s to %s\n", pvcName, current.String(), size.String())
+}
+
+// StartHomeVolumeExpander starts a background goroutine that expands nearly full home volumes.
+func (pm *PodManager) StartHomeVolumeExpander(ctx context.Context, checkInterval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		fmt.Printf("Home volume expander started (Interval: %v)\n", checkInterval)
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := pm.ExpandFullHomeVolumes(ctx); err != nil {
+					fmt.Printf("Home volume expander error: %v\n", err)
+				}
+			case <-ctx.Done():
+				fmt.Println("Home volume expander stopped")
+				return
+			}
+		}
+	}()
+}
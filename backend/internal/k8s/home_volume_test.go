@@ -0,0 +1,165 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestParseHomeVolumeSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     HomeVolumeConfig
+		wantErr bool
+		check   func(t *testing.T, spec homeVolumeSpec)
+	}{
+		{
+			name: "defaults",
+			cfg:  DefaultHomeVolumeConfig(),
+			check: func(t *testing.T, spec homeVolumeSpec) {
+				if spec.ephemeral || spec.size.String() != "1Gi" || spec.accessMode != v1.ReadWriteOnce || spec.maxSize != nil {
+					t.Errorf("spec = %+v", spec)
+				}
+			},
+		},
+		{
+			name: "ephemeral, case insensitive",
+			cfg:  HomeVolumeConfig{Mode: "Ephemeral", Size: "500Mi"},
+			check: func(t *testing.T, spec homeVolumeSpec) {
+				if !spec.ephemeral || spec.accessMode != v1.ReadWriteOnce {
+					t.Errorf("spec = %+v", spec)
+				}
+			},
+		},
+		{
+			name: "class, access mode and max size",
+			cfg:  HomeVolumeConfig{StorageClass: "fast", Size: "1Gi", AccessMode: "ReadWriteMany", MaxSize: "10Gi"},
+			check: func(t *testing.T, spec homeVolumeSpec) {
+				if spec.storageClass != "fast" || spec.accessMode != v1.ReadWriteMany || spec.maxSize == nil || spec.maxSize.String() != "10Gi" {
+					t.Errorf("spec = %+v", spec)
+				}
+			},
+		},
+		{name: "unknown mode", cfg: HomeVolumeConfig{Mode: "tmpfs", Size: "1Gi"}, wantErr: true},
+		{name: "missing size", cfg: HomeVolumeConfig{}, wantErr: true},
+		{name: "invalid size", cfg: HomeVolumeConfig{Size: "lots"}, wantErr: true},
+		{name: "zero size", cfg: HomeVolumeConfig{Size: "0"}, wantErr: true},
+		{name: "invalid access mode", cfg: HomeVolumeConfig{Size: "1Gi", AccessMode: "RWO"}, wantErr: true},
+		{name: "invalid max size", cfg: HomeVolumeConfig{Size: "1Gi", MaxSize: "big"}, wantErr: true},
+		{name: "max size below size", cfg: HomeVolumeConfig{Size: "5Gi", MaxSize: "1Gi"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := parseHomeVolumeSpec(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHomeVolumeSpec() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && tt.check != nil {
+				tt.check(t, spec)
+			}
+		})
+	}
+}
+
+func TestParseHomeVolumeOverrides(t *testing.T) {
+	got, err := ParseHomeVolumeOverrides(map[string]string{
+		"alice": "size:5Gi; storageClass:fast ;maxSize:20Gi",
+		"admin": "mode:ephemeral;class:local;accessMode:ReadWriteOncePod;",
+		"empty": "",
+	})
+	if err != nil {
+		t.Fatalf("ParseHomeVolumeOverrides: %v", err)
+	}
+	want := map[string]HomeVolumeConfig{
+		"alice": {Size: "5Gi", StorageClass: "fast", MaxSize: "20Gi"},
+		"admin": {Mode: "ephemeral", StorageClass: "local", AccessMode: "ReadWriteOncePod"},
+		"empty": {},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseHomeVolumeOverrides() = %+v, want %+v", got, want)
+	}
+
+	for _, value := range []string{"size=5Gi", "color:blue"} {
+		if _, err := ParseHomeVolumeOverrides(map[string]string{"bob": value}); err == nil {
+			t.Errorf("ParseHomeVolumeOverrides(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestHomeVolumePolicyFor(t *testing.T) {
+	policy, err := NewHomeVolumePolicy(DefaultHomeVolumeConfig(),
+		map[string]HomeVolumeConfig{"Alice": {Size: "10Gi"}},
+		map[string]HomeVolumeConfig{"admin": {Size: "5Gi", StorageClass: "fast"}})
+	if err != nil {
+		t.Fatalf("NewHomeVolumePolicy: %v", err)
+	}
+
+	tests := []struct {
+		user, role string
+		want       HomeVolumeConfig
+	}{
+		{"bob", "viewer", DefaultHomeVolumeConfig()},
+		{"bob", "Admin", HomeVolumeConfig{Mode: HomeVolumePersistent, Size: "5Gi", StorageClass: "fast", AccessMode: "ReadWriteOnce"}},
+		// The user override wins over the role override; unset fields are inherited.
+		{"alice", "admin", HomeVolumeConfig{Mode: HomeVolumePersistent, Size: "10Gi", StorageClass: "fast", AccessMode: "ReadWriteOnce"}},
+	}
+	for _, tt := range tests {
+		if got := policy.For(tt.user, tt.role); got != tt.want {
+			t.Errorf("For(%s, %s) = %+v, want %+v", tt.user, tt.role, got, tt.want)
+		}
+	}
+
+	var nilPolicy *HomeVolumePolicy
+	if got := nilPolicy.For("bob", "admin"); got != DefaultHomeVolumeConfig() {
+		t.Errorf("nil policy For() = %+v", got)
+	}
+
+	if _, err := NewHomeVolumePolicy(DefaultHomeVolumeConfig(), map[string]HomeVolumeConfig{"bob": {MaxSize: "1Mi"}}, nil); err == nil {
+		t.Error("NewHomeVolumePolicy accepted an invalid user override")
+	}
+}
+
+func TestParseDFOutput(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       string
+		wantUsed     uint64
+		wantCapacity uint64
+		wantErr      bool
+	}{
+		{
+			name:         "single filesystem",
+			output:       "Filesystem     1024-blocks   Used Available Capacity Mounted on\n/dev/sdb           1030828 950000     64052      94% /home/alice\n",
+			wantUsed:     950000 * 1024,
+			wantCapacity: 1030828 * 1024,
+		},
+		{name: "header only", output: "Filesystem 1024-blocks Used Available Capacity Mounted on\n", wantErr: true},
+		{name: "truncated", output: "Filesystem 1024-blocks Used\n/dev/sdb 1030828 950000\n", wantErr: true},
+		{name: "not a number", output: "Filesystem 1024-blocks Used Available Capacity Mounted on\n/dev/sdb - 950000 64052 94% /home\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			used, capacity, err := parseDFOutput(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDFOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if used != tt.wantUsed || capacity != tt.wantCapacity {
+				t.Errorf("parseDFOutput() = %d, %d, want %d, %d", used, capacity, tt.wantUsed, tt.wantCapacity)
+			}
+		})
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	b := &cappedBuffer{limit: 8}
+	if _, err := b.Write([]byte("12345678")); err != nil {
+		t.Fatalf("write within limit: %v", err)
+	}
+	if _, err := b.Write([]byte("9")); err != errOutputTooLarge {
+		t.Fatalf("write beyond limit: got %v, want %v", err, errOutputTooLarge)
+	}
+	if b.String() != "12345678" {
+		t.Errorf("kept %q, want %q", b.String(), "12345678")
+	}
+}
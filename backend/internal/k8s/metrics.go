@@ -0,0 +1,172 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// ErrMetricsUnavailable is returned when the metrics.k8s.io API is not served, usually because
+// metrics-server is not installed.
+var ErrMetricsUnavailable = errors.New("metrics API is not available")
+
+// Usage holds CPU and memory usage of a node, pod or container.
+type Usage struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// PodUsage holds the usage of a pod and each of its containers.
+type PodUsage struct {
+	Namespace  string
+	Name       string
+	Total      Usage
+	Containers map[string]Usage
+}
+
+// nodeMetricsList is the subset of metrics.k8s.io/v1beta1 NodeMetricsList we use.
+type nodeMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Usage rawUsage `json:"usage"`
+	} `json:"items"`
+}
+
+// podMetricsList is the subset of metrics.k8s.io/v1beta1 PodMetricsList we use.
+type podMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Containers []struct {
+			Name  string   `json:"name"`
+			Usage rawUsage `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+type rawUsage struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
+
+// MetricsClient reads node and pod usage from the metrics.k8s.io/v1beta1 API.
+type MetricsClient struct {
+	client *rest.RESTClient
+}
+
+// NewMetricsClient creates a metrics client from a REST config.
+func NewMetricsClient(config *rest.Config) (*MetricsClient, error) {
+	if config == nil {
+		return nil, fmt.Errorf("rest config is required")
+	}
+
+	metricsConfig := *config
+	metricsConfig.GroupVersion = &schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}
+	metricsConfig.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	metricsConfig.APIPath = "/apis"
+
+	client, err := rest.RESTClientFor(&metricsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics client: %w", err)
+	}
+	return &MetricsClient{client: client}, nil
+}
+
+// NodeMetrics returns the current usage of every node keyed by node name.
+func (mc *MetricsClient) NodeMetrics(ctx context.Context) (map[string]Usage, error) {
+	data, err := mc.client.Get().Resource("nodes").DoRaw(ctx)
+	if err != nil {
+		return nil, metricsError(err)
+	}
+
+	var list nodeMetricsList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal node metrics: %w", err)
+	}
+
+	result := make(map[string]Usage, len(list.Items))
+	for _, item := range list.Items {
+		result[item.Metadata.Name] = parseUsage(item.Usage)
+	}
+	return result, nil
+}
+
+// PodMetrics returns the current usage of pods in a namespace (empty for all namespaces)
+// keyed by "namespace/name".
+func (mc *MetricsClient) PodMetrics(ctx context.Context, namespace string) (map[string]PodUsage, error) {
+	req := mc.client.Get().Resource("pods")
+	if namespace != "" {
+		req = req.Namespace(namespace)
+	}
+	data, err := req.DoRaw(ctx)
+	if err != nil {
+		return nil, metricsError(err)
+	}
+
+	var list podMetricsList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pod metrics: %w", err)
+	}
+
+	result := make(map[string]PodUsage, len(list.Items))
+	for _, item := range list.Items {
+		usage := PodUsage{
+			Namespace:  item.Metadata.Namespace,
+			Name:       item.Metadata.Name,
+			Containers: make(map[string]Usage, len(item.Containers)),
+		}
+		for _, container := range item.Containers {
+			containerUsage := parseUsage(container.Usage)
+			usage.Containers[container.Name] = containerUsage
+			usage.Total.CPU.Add(containerUsage.CPU)
+			usage.Total.Memory.Add(containerUsage.Memory)
+		}
+		result[PodKey(item.Metadata.Namespace, item.Metadata.Name)] = usage
+	}
+	return result, nil
+}
+
+// PodKey returns the "namespace/name" key used by PodMetrics.
+func PodKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// FormatCPU formats CPU usage in cores with two decimals.
+func FormatCPU(q *resource.Quantity) string {
+	return fmt.Sprintf("%.2f", float64(q.MilliValue())/1000.0)
+}
+
+// FormatMemoryGB formats memory usage in GB with two decimals.
+func FormatMemoryGB(q *resource.Quantity) string {
+	return fmt.Sprintf("%.2f GB", float64(q.Value())/(1024*1024*1024))
+}
+
+func parseUsage(raw rawUsage) Usage {
+	var usage Usage
+	if cpu, err := resource.ParseQuantity(raw.CPU); err == nil {
+		usage.CPU = cpu
+	}
+	if memory, err := resource.ParseQuantity(raw.Memory); err == nil {
+		usage.Memory = memory
+	}
+	return usage
+}
+
+// metricsError maps "API not served" errors to ErrMetricsUnavailable.
+func metricsError(err error) error {
+	if apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) {
+		return fmt.Errorf("%w: %v", ErrMetricsUnavailable, err)
+	}
+	return fmt.Errorf("failed to fetch metrics: %w", err)
+}
@@ -2,14 +2,15 @@ package k8s
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -30,6 +31,23 @@ type PodManager struct {
 	image          string
 	serviceAccount string
 	limits         ResourceLimits
+	homeVolumes    *HomeVolumePolicy
+	template       *PodTemplate
+	profiles       *Profiles
+	resources      *ResourcePolicy
+	// createMu serializes the budget check and creation of pods.
+	createMu sync.Mutex
+}
+
+// PodOptions customizes a user's session pod.
+type PodOptions struct {
+	// Profile, if not nil, overrides the image, resources, env and template of the pod.
+	Profile *Profile
+	// CPU and Memory, if set, resize the terminal container within the user's resource policy.
+	CPU    string
+	Memory string
+	// Replace recreates the pod even if the user already has a matching one. The home volume is kept.
+	Replace bool
 }
 
 // ResourceLimits holds CPU and memory limits.
@@ -38,8 +56,9 @@ type ResourceLimits struct {
 	Memory string
 }
 
-// NewPodManager creates a new PodManager instance.
-func NewPodManager(kubeconfigPath, kubeconfigContent, namespace, image, serviceAccount string, limits ResourceLimits) (*PodManager, error) {
+// NewPodManager creates a new PodManager instance. A nil homeVolumes gives every user the default home volume.
+func NewPodManager(kubeconfigPath, kubeconfigContent, namespace, image, serviceAccount string, limits ResourceLimits,
+	homeVolumes *HomeVolumePolicy) (*PodManager, error) {
 	var config *rest.Config
 	var err error
 
@@ -73,9 +92,25 @@ func NewPodManager(kubeconfigPath, kubeconfigContent, namespace, image, serviceA
 		image:          image,
 		serviceAccount: serviceAccount,
 		limits:         limits,
+		homeVolumes:    homeVolumes,
 	}, nil
 }
 
+// SetPodTemplate sets the template session pods are built from; nil restores the built-in pod.
+func (pm *PodManager) SetPodTemplate(template *PodTemplate) {
+	pm.template = template
+}
+
+// SetProfiles sets the terminal profiles users choose from; nil disables profiles.
+func (pm *PodManager) SetProfiles(profiles *Profiles) {
+	pm.profiles = profiles
+}
+
+// Profiles returns the terminal profiles, or nil when profiles are not used.
+func (pm *PodManager) Profiles() *Profiles {
+	return pm.profiles
+}
+
 // StatusCallback is called to report pod creation status updates.
 type StatusCallback func(message string)
 
@@ -126,6 +161,17 @@ func sanitizeUsername(username string) string {
 	return username
 }
 
+// sessionUsername returns the sanitized username used in pod and PVC names and labels, truncated so
+// that "kubrowser-{username}" fits the 63 character limit.
+func sessionUsername(username string) string {
+	sanitized := sanitizeUsername(username)
+	// Leave room for the "kubrowser-" prefix, which is 10 characters.
+	if maxUsernameLen := 63 - 10; len(sanitized) > maxUsernameLen {
+		sanitized = sanitized[:maxUsernameLen]
+	}
+	return sanitized
+}
+
 func isAlphanumeric(r rune) bool {
 	return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
 }
@@ -133,43 +179,58 @@ func isAlphanumeric(r rune) bool {
 // CreatePod creates a new pod with kubectl installed.
 // The pod will be automatically cleaned up after the specified timeout.
 func (pm *PodManager) CreatePod(ctx context.Context, sessionID string) (*v1.Pod, error) {
-	return pm.CreatePodWithStatus(ctx, sessionID, "", time.Now(), nil)
+	return pm.CreatePodWithStatus(ctx, sessionID, "", "", PodOptions{}, time.Now(), nil)
 }
 
 // CreatePodWithStatus creates a new pod with kubectl installed and reports status updates.
-// username is sanitized and included in the pod name for easier management.
+// username is sanitized and included in the pod name for easier management; username and role select
+// the user's home volume and resources.
 // Pod name format: kubrowser-{username}
-// Note: This creates one pod per username. If a pod already exists for this user, it will be deleted first.
-func (pm *PodManager) CreatePodWithStatus(ctx context.Context, sessionID, username string,
+// Note: This creates one pod per username. If a pod already exists for this user, it is reused when
+// it runs the same profile and opts.Replace is not set, and deleted first otherwise.
+// Returns an error wrapping ErrBudgetExceeded when the pod would exceed the Kubrowser budget.
+func (pm *PodManager) CreatePodWithStatus(ctx context.Context, sessionID, username, role string, opts PodOptions,
 	startTime time.Time, statusCallback StatusCallback) (*v1.Pod, error) {
+	profile := opts.Profile
+
 	// Sanitize username for Kubernetes naming requirements.
-	sanitizedUsername := sanitizeUsername(username)
+	sanitizedUsername := sessionUsername(username)
 
 	// Generate pod name: kubrowser-{username}.
 	podName := fmt.Sprintf("kubrowser-%s", sanitizedUsername)
 
-	// Ensure pod name doesn't exceed 63 characters (Kubernetes limit).
-	if len(podName) > 63 {
-		// Truncate username if needed (leave room for "kubrowser-" prefix which is 10 chars).
-		maxUsernameLen := 63 - 10
-		if maxUsernameLen > 0 && len(sanitizedUsername) > maxUsernameLen {
-			sanitizedUsername = sanitizedUsername[:maxUsernameLen]
-			podName = fmt.Sprintf("kubrowser-%s", sanitizedUsername)
+	resources, err := pm.resourcePolicy().Resolve(username, role, profile, opts.CPU, opts.Memory)
+	if err != nil {
+		if statusCallback != nil {
+			statusCallback(fmt.Sprintf("\r\x1b[K\x1b[31m[✗] %v\x1b[0m\r\n", err))
 		}
+		return nil, err
 	}
 
-	// Create PVC for user's home directory if it doesn't exist.
-	pvcName := fmt.Sprintf("kubrowser-home-%s", sanitizedUsername)
-	if err := pm.ensureHomePVC(ctx, pvcName, sanitizedUsername, statusCallback); err != nil {
-		if statusCallback != nil {
-			statusCallback(fmt.Sprintf("\r\x1b[K\x1b[31m[✗] Failed to create home storage: %v\x1b[0m\r\n", err))
-		}
-		return nil, fmt.Errorf("failed to create home PVC: %w", err)
+	// Ephemeral homes need no PVC.
+	pvcName := HomePVCName(sanitizedUsername)
+	homeVolume := pm.homeVolumes.spec(username, role)
+	if homeVolume.ephemeral {
+		pvcName = ""
 	}
 
 	// Check if a pod with this name already exists and reuse it if possible.
+	profileName := ""
+	if profile != nil {
+		profileName = profile.Name
+	}
 	existingPod, err := pm.FindExistingPod(ctx, username)
-	if err == nil && existingPod != nil {
+	switch {
+	case err != nil || existingPod == nil:
+	case opts.Replace:
+		if statusCallback != nil {
+			statusCallback("\r\x1b[K\x1b[33m[ ] Replacing existing session pod...\x1b[0m\r\n")
+		}
+	case existingPod.Labels[ProfileLabel] != profileName:
+		if statusCallback != nil {
+			statusCallback(fmt.Sprintf("\r\x1b[K\x1b[33m[!] Existing session uses another profile, replacing it with %s\x1b[0m\r\n", profileName))
+		}
+	default:
 		if statusCallback != nil {
 			statusCallback(fmt.Sprintf("\r\x1b[K\x1b[32m[✓] Found existing session for %s\x1b[0m\r\n", sanitizedUsername))
 		}
@@ -178,6 +239,49 @@ func (pm *PodManager) CreatePodWithStatus(ctx context.Context, sessionID, userna
 		return existingPod, nil
 	}
 
+	image := pm.image
+	if profile != nil && profile.Image != "" {
+		image = profile.Image
+	}
+
+	pod, err := pm.buildPod(profile, PodTemplateData{
+		User:      username,
+		Username:  sanitizedUsername,
+		SessionID: sessionID,
+		HomePVC:   pvcName,
+		Namespace: pm.namespace,
+		Image:     image,
+		Role:      role,
+		Profile:   profileName,
+	}, homeVolumeSource(homeVolume, pvcName), resources)
+	if err != nil {
+		if statusCallback != nil {
+			statusCallback(fmt.Sprintf("\r\x1b[K\x1b[31m[✗] Invalid pod template: %v\x1b[0m\r\n", err))
+		}
+		return nil, err
+	}
+
+	// Refuse before tearing down an existing pod if the new one would not fit the budget.
+	if err := pm.checkBudget(ctx, pod); err != nil {
+		if statusCallback != nil {
+			statusCallback(fmt.Sprintf("\r\x1b[K\x1b[31m[✗] %v\x1b[0m\r\n", err))
+		}
+		return nil, err
+	}
+
+	// Create the PVC for the user's home directory only once the session is known to start, so a
+	// refused session doesn't provision or grow storage.
+	if homeVolume.ephemeral {
+		if statusCallback != nil {
+			statusCallback("\r\x1b[K\x1b[32m[✓] Using ephemeral home storage (not kept between sessions)\x1b[0m\r\n")
+		}
+	} else if err := pm.ensureHomePVC(ctx, pvcName, sanitizedUsername, homeVolume, statusCallback); err != nil {
+		if statusCallback != nil {
+			statusCallback(fmt.Sprintf("\r\x1b[K\x1b[31m[✗] Failed to create home storage: %v\x1b[0m\r\n", err))
+		}
+		return nil, fmt.Errorf("failed to create home PVC: %w", err)
+	}
+
 	// Check if a pod with this name already exists and wait for it to be fully deleted.
 
 	existingPod, err = pm.client.CoreV1().Pods(pm.namespace).Get(ctx, podName, metav1.GetOptions{})
@@ -231,82 +335,24 @@ func (pm *PodManager) CreatePodWithStatus(ctx context.Context, sessionID, userna
 		}
 	}
 
-	cpuQuantity, err := resource.ParseQuantity(pm.limits.CPU)
-	if err != nil {
-		return nil, fmt.Errorf("invalid CPU limit: %w", err)
-	}
-
-	memoryQuantity, err := resource.ParseQuantity(pm.limits.Memory)
-	if err != nil {
-		return nil, fmt.Errorf("invalid memory limit: %w", err)
-	}
-
-	pod := &v1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      podName,
-			Namespace: pm.namespace,
-			Labels: map[string]string{
-				"app":        "kubrowser",
-				"session-id": sessionID,
-				"username":   sanitizedUsername,
-				"managed-by": "kubrowser-backend",
-			},
-			Annotations: map[string]string{
-				HeartbeatAnnotation: time.Now().Format(time.RFC3339),
-			},
-		},
-		Spec: v1.PodSpec{
-			Hostname:           "kubrowser",
-			ServiceAccountName: pm.serviceAccount,
-			Containers: []v1.Container{
-				{
-					Name:            "terminal",
-					Image:           pm.image,
-					ImagePullPolicy: v1.PullIfNotPresent,
-					// The entrypoint.sh in the custom image handles user creation.
-					Env: []v1.EnvVar{
-						{
-							Name:  "KUBROWSER_USER",
-							Value: sanitizedUsername,
-						},
-					},
-					Resources: v1.ResourceRequirements{
-						Limits: v1.ResourceList{
-							v1.ResourceCPU:    cpuQuantity,
-							v1.ResourceMemory: memoryQuantity,
-						},
-						Requests: v1.ResourceList{
-							v1.ResourceCPU:    cpuQuantity,
-							v1.ResourceMemory: memoryQuantity,
-						},
-					},
-					VolumeMounts: []v1.VolumeMount{
-						{
-							Name:      "home",
-							MountPath: fmt.Sprintf("/home/%s", sanitizedUsername),
-						},
-					},
-				},
-			},
-			Volumes: []v1.Volume{
-				{
-					Name: "home",
-					VolumeSource: v1.VolumeSource{
-						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
-							ClaimName: fmt.Sprintf("kubrowser-home-%s", sanitizedUsername),
-						},
-					},
-				},
-			},
-			RestartPolicy: v1.RestartPolicyNever,
-		},
-	}
-
 	if statusCallback != nil {
 		statusCallback("\r\x1b[K\x1b[33m[ ] Creating pod...\x1b[0m")
 	}
 
-	createdPod, err := pm.client.CoreV1().Pods(pm.namespace).Create(ctx, pod, metav1.CreateOptions{})
+	// Check the budget again, as other sessions may have started while the old pod terminated.
+	pm.createMu.Lock()
+	err = pm.checkBudget(ctx, pod)
+	var createdPod *v1.Pod
+	if err == nil {
+		createdPod, err = pm.client.CoreV1().Pods(pm.namespace).Create(ctx, pod, metav1.CreateOptions{})
+	}
+	pm.createMu.Unlock()
+	if stderrors.Is(err, ErrBudgetExceeded) {
+		if statusCallback != nil {
+			statusCallback(fmt.Sprintf("\r\x1b[K\x1b[31m[✗] %v\x1b[0m\r\n", err))
+		}
+		return nil, err
+	}
 	if err != nil {
 		if statusCallback != nil {
 			statusCallback("\r\x1b[K\x1b[31m[✗] Failed to create pod\x1b[0m\r\n")
@@ -321,7 +367,7 @@ func (pm *PodManager) CreatePodWithStatus(ctx context.Context, sessionID, userna
 	}
 
 	// Wait for pod to be ready.
-	if err := pm.waitForPodReady(ctx, podName, startTime, statusCallback); err != nil {
+	if err := pm.waitForPodReady(ctx, podName, pvcName, startTime, statusCallback); err != nil {
 		if statusCallback != nil {
 			statusCallback("\r\x1b[K\x1b[31m[✗] Pod failed to become ready\x1b[0m\r\n")
 		}
@@ -338,9 +384,11 @@ func (pm *PodManager) CreatePodWithStatus(ctx context.Context, sessionID, userna
 	return createdPod, nil
 }
 
-// waitForPodReady waits for the pod to be in Ready state.
-func (pm *PodManager) waitForPodReady(ctx context.Context, podName string, startTime time.Time, statusCallback StatusCallback) error {
+// waitForPodReady waits for the pod to be in Ready state. While the pod is pending, a home PVC
+// (pvcName, empty for ephemeral homes) that cannot be bound is reported through statusCallback.
+func (pm *PodManager) waitForPodReady(ctx context.Context, podName, pvcName string, startTime time.Time, statusCallback StatusCallback) error {
 	lastPhase := ""
+	lastVolumeReason := ""
 	return wait.PollUntilContextTimeout(ctx, 2*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
 		pod, err := pm.client.CoreV1().Pods(pm.namespace).Get(ctx, podName, metav1.GetOptions{})
 		if err != nil {
@@ -369,6 +417,13 @@ func (pm *PodManager) waitForPodReady(ctx context.Context, podName string, start
 						pod.Status.Phase, elapsed.Round(time.Millisecond)))
 				}
 			}
+
+			if pod.Status.Phase == v1.PodPending && pvcName != "" {
+				if reason, pending := pm.homePVCPendingReason(ctx, pvcName); pending && reason != lastVolumeReason {
+					lastVolumeReason = reason
+					statusCallback(fmt.Sprintf("\r\x1b[K\x1b[33m[!] Home storage is still Pending: %s\x1b[0m\r\n", reason))
+				}
+			}
 		}
 
 		// Check if pod is running first.
@@ -390,6 +445,17 @@ func (pm *PodManager) waitForPodReady(ctx context.Context, podName string, start
 // DeletePod deletes a pod by name.
 // Returns nil if pod is already deleted (not found).
 func (pm *PodManager) DeletePod(ctx context.Context, podName string) error {
+	// Record when the user's home was last used before the heartbeat goes away with the pod.
+	if pod, getErr := pm.GetPod(ctx, podName); getErr == nil {
+		if username := pod.Labels["username"]; username != "" {
+			lastUsed := time.Now()
+			if heartbeat, parseErr := time.Parse(time.RFC3339, pod.Annotations[HeartbeatAnnotation]); parseErr == nil {
+				lastUsed = heartbeat
+			}
+			pm.touchHomePVC(ctx, HomePVCName(username), lastUsed)
+		}
+	}
+
 	deletePolicy := metav1.DeletePropagationForeground
 	err := pm.client.CoreV1().Pods(pm.namespace).Delete(ctx, podName, metav1.DeleteOptions{
 		PropagationPolicy: &deletePolicy,
@@ -407,11 +473,23 @@ func (pm *PodManager) DeletePod(ctx context.Context, podName string) error {
 }
 
 // ensureHomePVC creates a PersistentVolumeClaim for the user's home directory if it doesn't exist.
-func (pm *PodManager) ensureHomePVC(ctx context.Context, pvcName, username string, statusCallback StatusCallback) error {
+// An existing PVC smaller than the configured size is expanded when its StorageClass allows it.
+func (pm *PodManager) ensureHomePVC(ctx context.Context, pvcName, username string, spec homeVolumeSpec, statusCallback StatusCallback) error {
 	// Check if PVC already exists.
-	_, err := pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	existing, err := pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Get(ctx, pvcName, metav1.GetOptions{})
 	if err == nil {
 		// PVC already exists.
+		pm.touchHomePVC(ctx, pvcName, time.Now())
+		pm.setHomeMaxSize(ctx, existing, spec.maxSize)
+		if current := existing.Spec.Resources.Requests[v1.ResourceStorage]; current.Cmp(spec.size) < 0 {
+			if _, expandErr := pm.expandPVC(ctx, existing, spec.size); expandErr != nil {
+				if statusCallback != nil {
+					statusCallback(fmt.Sprintf("\r\x1b[K\x1b[33m[!] Could not grow home storage to %s: %v\x1b[0m\r\n", spec.size.String(), expandErr))
+				}
+			} else if statusCallback != nil {
+				statusCallback(fmt.Sprintf("\r\x1b[K\x1b[32m[✓] Home storage expanded to %s\x1b[0m\r\n", spec.size.String()))
+			}
+		}
 		if statusCallback != nil {
 			statusCallback(fmt.Sprintf("\r\x1b[K\x1b[32m[✓] Home storage ready for %s\x1b[0m\r\n", username))
 		}
@@ -426,7 +504,6 @@ func (pm *PodManager) ensureHomePVC(ctx context.Context, pvcName, username strin
 	}
 
 	// Create PVC.
-	storageSize := resource.MustParse("1Gi")
 	pvc := &v1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pvcName,
@@ -436,18 +513,27 @@ func (pm *PodManager) ensureHomePVC(ctx context.Context, pvcName, username strin
 				"username":   username,
 				"managed-by": "kubrowser-backend",
 			},
+			Annotations: map[string]string{
+				HomeLastUsedAnnotation: time.Now().Format(time.RFC3339),
+			},
 		},
 		Spec: v1.PersistentVolumeClaimSpec{
 			AccessModes: []v1.PersistentVolumeAccessMode{
-				v1.ReadWriteOnce,
+				spec.accessMode,
 			},
 			Resources: v1.ResourceRequirements{
 				Requests: v1.ResourceList{
-					v1.ResourceStorage: storageSize,
+					v1.ResourceStorage: spec.size,
 				},
 			},
 		},
 	}
+	if spec.storageClass != "" {
+		pvc.Spec.StorageClassName = &spec.storageClass
+	}
+	if spec.maxSize != nil {
+		pvc.Annotations[HomeMaxSizeAnnotation] = spec.maxSize.String()
+	}
 
 	_, err = pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Create(ctx, pvc, metav1.CreateOptions{})
 	if err != nil {
@@ -508,17 +594,8 @@ func (pm *PodManager) IsPodReady(ctx context.Context, podName string) (bool, err
 // FindExistingPod checks for an existing running pod for the given username.
 // Returns the pod if found and running, nil otherwise.
 func (pm *PodManager) FindExistingPod(ctx context.Context, username string) (*v1.Pod, error) {
-	sanitizedUsername := sanitizeUsername(username)
-	podName := fmt.Sprintf("kubrowser-%s", sanitizedUsername)
-
 	// Ensure pod name consistency with creation logic.
-	if len(podName) > 63 {
-		maxUsernameLen := 63 - 10
-		if maxUsernameLen > 0 && len(sanitizedUsername) > maxUsernameLen {
-			sanitizedUsername = sanitizedUsername[:maxUsernameLen]
-			podName = fmt.Sprintf("kubrowser-%s", sanitizedUsername)
-		}
-	}
+	podName := fmt.Sprintf("kubrowser-%s", sessionUsername(username))
 
 	pod, err := pm.client.CoreV1().Pods(pm.namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
@@ -639,3 +716,8 @@ func (pm *PodManager) GetClient() kubernetes.Interface {
 func (pm *PodManager) GetConfig() *rest.Config {
 	return pm.config
 }
+
+// Namespace returns the namespace of session pods, home volumes, pod templates and profiles.
+func (pm *PodManager) Namespace() string {
+	return pm.namespace
+}
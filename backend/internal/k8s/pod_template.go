@@ -0,0 +1,337 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultTerminalContainer is the container the terminal attaches to unless a template names another.
+	DefaultTerminalContainer = "terminal"
+
+	// TerminalContainerAnnotation names the container the terminal attaches to.
+	TerminalContainerAnnotation = "kubrowser.io/terminal-container"
+
+	// DefaultPodTemplateKey is the ConfigMap key holding the pod template.
+	DefaultPodTemplateKey = "pod.yaml"
+
+	homeVolumeName = "home"
+)
+
+// PodTemplateData holds the variables available to a pod template.
+type PodTemplateData struct {
+	// User is the login of the user as authenticated.
+	User string
+	// Username is the sanitized username used in pod names, labels and the home directory path.
+	Username  string
+	SessionID string
+	// HomePVC is the name of the home PersistentVolumeClaim, empty for ephemeral homes.
+	HomePVC   string
+	Namespace string
+	Image     string
+	Role      string
+	// Profile is the name of the terminal profile, empty when profiles are not used.
+	Profile string
+}
+
+// PodTemplateSource locates a pod template: a file, or a key of a ConfigMap given as "name" or
+// "namespace/name". Both empty means no template.
+type PodTemplateSource struct {
+	File      string
+	ConfigMap string
+	Key       string
+}
+
+// PodTemplate is a Go text/template producing a Pod manifest, or just its spec, in YAML or JSON.
+// The rendered pod is strategically merged over Kubrowser's defaults, and the fields Kubrowser
+// relies on (name, labels, heartbeat, user, resources and home volume) are merged over the result.
+type PodTemplate struct {
+	source string
+	tmpl   *template.Template
+}
+
+// LoadPodTemplate reads and validates the pod template at src. It returns nil when src is empty.
+func LoadPodTemplate(ctx context.Context, client kubernetes.Interface, namespace string, src PodTemplateSource) (*PodTemplate, error) {
+	if src.Key == "" {
+		src.Key = DefaultPodTemplateKey
+	}
+	source, data, err := readConfigSource(ctx, client, namespace, src.File, src.ConfigMap, src.Key)
+	if err != nil || source == "" {
+		return nil, err
+	}
+	return ParsePodTemplate(source, data)
+}
+
+// readConfigSource reads a file, or a key of a ConfigMap given as "name" or "namespace/name", and
+// returns a description of where it came from. It returns an empty source when neither is set.
+func readConfigSource(ctx context.Context, client kubernetes.Interface, namespace, file, configMap, key string) (string, string, error) {
+	switch {
+	case file != "" && configMap != "":
+		return "", "", fmt.Errorf("file %s and ConfigMap %s are mutually exclusive", file, configMap)
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		return file, string(data), nil
+	case configMap != "":
+		name := configMap
+		if ns, n, ok := strings.Cut(name, "/"); ok {
+			namespace, name = ns, n
+		}
+		cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get ConfigMap %s/%s: %w", namespace, name, err)
+		}
+		data, ok := cm.Data[key]
+		if !ok {
+			return "", "", fmt.Errorf("ConfigMap %s/%s has no key %q", namespace, name, key)
+		}
+		return fmt.Sprintf("configmap/%s/%s[%s]", namespace, name, key), data, nil
+	default:
+		return "", "", nil
+	}
+}
+
+// ParsePodTemplate parses a pod template and validates it by rendering it with sample data.
+func ParsePodTemplate(source, text string) (*PodTemplate, error) {
+	tmpl, err := template.New(source).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pod template %s: %w", source, err)
+	}
+	t := &PodTemplate{source: source, tmpl: tmpl}
+
+	sample := PodTemplateData{
+		User:      "example-user",
+		Username:  "example-user",
+		SessionID: "00000000000000000000000000000000",
+		HomePVC:   HomePVCName("example-user"),
+		Namespace: "default",
+		Image:     "example.com/terminal:latest",
+		Role:      "operator",
+	}
+	pod, err := t.render(sample)
+	if err != nil {
+		return nil, err
+	}
+	if pod.Name != "" || pod.GenerateName != "" {
+		return nil, fmt.Errorf("invalid pod template %s: metadata.name is set by Kubrowser", source)
+	}
+	for _, vol := range pod.Spec.Volumes {
+		if vol.Name == homeVolumeName {
+			return nil, fmt.Errorf("invalid pod template %s: volume name %q is reserved for the home directory", source, homeVolumeName)
+		}
+	}
+
+	home := v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}
+	if _, err := mergePodLayers(pod, sample, "", nil, home, v1.ResourceRequirements{}); err != nil {
+		return nil, fmt.Errorf("invalid pod template %s: %w", source, err)
+	}
+	return t, nil
+}
+
+// render executes the template and decodes the result as a Pod or PodSpec. Unknown fields are rejected.
+func (t *PodTemplate) render(data PodTemplateData) (*v1.Pod, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render pod template %s: %w", t.source, err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(buf.Bytes(), &raw); err != nil {
+		return nil, fmt.Errorf("pod template %s is not valid YAML: %w", t.source, err)
+	}
+
+	pod := &v1.Pod{}
+	_, hasSpec := raw["spec"]
+	_, hasMetadata := raw["metadata"]
+	if !hasSpec && !hasMetadata {
+		if err := yaml.UnmarshalStrict(buf.Bytes(), &pod.Spec); err != nil {
+			return nil, fmt.Errorf("pod template %s is not a valid PodSpec: %w", t.source, err)
+		}
+		return pod, nil
+	}
+
+	if err := yaml.UnmarshalStrict(buf.Bytes(), pod); err != nil {
+		return nil, fmt.Errorf("pod template %s is not a valid Pod: %w", t.source, err)
+	}
+	if pod.Kind != "" && pod.Kind != "Pod" {
+		return nil, fmt.Errorf("pod template %s has kind %s, expected Pod", t.source, pod.Kind)
+	}
+	pod.TypeMeta = metav1.TypeMeta{}
+	return pod, nil
+}
+
+// TerminalContainerName returns the name of the container the terminal attaches to.
+func TerminalContainerName(pod *v1.Pod) string {
+	if name := pod.Annotations[TerminalContainerAnnotation]; name != "" {
+		return name
+	}
+	return DefaultTerminalContainer
+}
+
+// buildPod renders the session pod of a user from the profile's template, or the configured
+// template if the profile has none.
+func (pm *PodManager) buildPod(profile *Profile, data PodTemplateData, home v1.VolumeSource,
+	resources v1.ResourceRequirements) (*v1.Pod, error) {
+	tmpl := pm.template
+	if profile != nil && profile.template != nil {
+		tmpl = profile.template
+	}
+
+	templated := &v1.Pod{}
+	if tmpl != nil {
+		var err error
+		if templated, err = tmpl.render(data); err != nil {
+			return nil, err
+		}
+	}
+	return mergePodLayers(templated, data, pm.serviceAccount, profile.envVars(), home, resources)
+}
+
+// mergePodLayers strategically merges the templated pod over Kubrowser's defaults, then the
+// required Kubrowser fields and profile environment over the result, and checks the terminal
+// container is present.
+func mergePodLayers(templated *v1.Pod, data PodTemplateData, serviceAccount string, env []v1.EnvVar,
+	home v1.VolumeSource, resources v1.ResourceRequirements) (*v1.Pod, error) {
+	container := TerminalContainerName(templated)
+
+	defaults := &v1.Pod{
+		Spec: v1.PodSpec{
+			Hostname:           "kubrowser",
+			ServiceAccountName: serviceAccount,
+			Containers: []v1.Container{
+				{
+					Name:            container,
+					Image:           data.Image,
+					ImagePullPolicy: v1.PullIfNotPresent,
+				},
+			},
+			RestartPolicy: v1.RestartPolicyNever,
+		},
+	}
+
+	required := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("kubrowser-%s", data.Username),
+			Namespace: data.Namespace,
+			Labels: map[string]string{
+				"app":        "kubrowser",
+				"session-id": data.SessionID,
+				"username":   data.Username,
+				"managed-by": "kubrowser-backend",
+			},
+			Annotations: map[string]string{
+				HeartbeatAnnotation:         time.Now().Format(time.RFC3339),
+				TerminalContainerAnnotation: container,
+			},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: container,
+					// The entrypoint.sh in the custom image handles user creation.
+					Env: append(env, v1.EnvVar{
+						Name:  "KUBROWSER_USER",
+						Value: data.Username,
+					}),
+					Resources: resources,
+					VolumeMounts: []v1.VolumeMount{
+						{
+							Name:      homeVolumeName,
+							MountPath: fmt.Sprintf("/home/%s", data.Username),
+						},
+					},
+				},
+			},
+			Volumes: []v1.Volume{
+				{
+					Name:         homeVolumeName,
+					VolumeSource: home,
+				},
+			},
+		},
+	}
+
+	if data.Profile != "" {
+		required.Labels[ProfileLabel] = data.Profile
+	}
+
+	merged := defaults
+	for _, layer := range []*v1.Pod{templated, required} {
+		var err error
+		if merged, err = strategicMergePod(merged, layer); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range merged.Spec.Containers {
+		if merged.Spec.Containers[i].Name == container {
+			if merged.Spec.Containers[i].Image == "" {
+				return nil, fmt.Errorf("terminal container %q has no image", container)
+			}
+			return merged, nil
+		}
+	}
+	return nil, fmt.Errorf("terminal container %q not found", container)
+}
+
+func strategicMergePod(base, overlay *v1.Pod) (*v1.Pod, error) {
+	baseJSON, err := json.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	overlayJSON, err := json.Marshal(overlay)
+	if err != nil {
+		return nil, err
+	}
+	// Unset fields without omitempty, such as an empty containers list, marshal as null, which a
+	// strategic merge patch would treat as a deletion.
+	var overlayMap map[string]interface{}
+	if err := json.Unmarshal(overlayJSON, &overlayMap); err != nil {
+		return nil, err
+	}
+	if overlayJSON, err = json.Marshal(dropNulls(overlayMap)); err != nil {
+		return nil, err
+	}
+	mergedJSON, err := strategicpatch.StrategicMergePatch(baseJSON, overlayJSON, v1.Pod{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge pod template: %w", err)
+	}
+	merged := &v1.Pod{}
+	if err := json.Unmarshal(mergedJSON, merged); err != nil {
+		return nil, fmt.Errorf("failed to decode merged pod: %w", err)
+	}
+	return merged, nil
+}
+
+// dropNulls removes null values from a decoded JSON object, recursively.
+func dropNulls(obj map[string]interface{}) map[string]interface{} {
+	for key, value := range obj {
+		switch v := value.(type) {
+		case nil:
+			delete(obj, key)
+		case map[string]interface{}:
+			dropNulls(v)
+		case []interface{}:
+			for _, item := range v {
+				if m, ok := item.(map[string]interface{}); ok {
+					dropNulls(m)
+				}
+			}
+		}
+	}
+	return obj
+}
@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func renderTestPod(t *testing.T, text string) *v1.Pod {
+	t.Helper()
+	tmpl, err := ParsePodTemplate("test", text)
+	if err != nil {
+		t.Fatalf("ParsePodTemplate: %v", err)
+	}
+	pm := &PodManager{template: tmpl, serviceAccount: "kubectl-pod"}
+	data := PodTemplateData{
+		User:      "Alice",
+		Username:  "alice",
+		SessionID: "abc",
+		HomePVC:   HomePVCName("alice"),
+		Namespace: "kubrowser",
+		Image:     "bitnami/kubectl:latest",
+	}
+	home := v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}
+	pod, err := pm.buildPod(nil, data, home, v1.ResourceRequirements{})
+	if err != nil {
+		t.Fatalf("buildPod: %v", err)
+	}
+	return pod
+}
+
+func findContainer(pod *v1.Pod, name string) *v1.Container {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
+func TestPodTemplateTolerationsOnly(t *testing.T) {
+	pod := renderTestPod(t, `
+nodeSelector:
+  pool: terminals
+tolerations:
+- key: dedicated
+  operator: Equal
+  value: terminals
+  effect: NoSchedule
+`)
+
+	terminal := findContainer(pod, DefaultTerminalContainer)
+	if terminal == nil {
+		t.Fatalf("terminal container missing: %+v", pod.Spec.Containers)
+	}
+	if terminal.Image != "bitnami/kubectl:latest" {
+		t.Errorf("image = %q, want the default image", terminal.Image)
+	}
+	if pod.Spec.NodeSelector["pool"] != "terminals" {
+		t.Errorf("nodeSelector = %v", pod.Spec.NodeSelector)
+	}
+	if len(pod.Spec.Tolerations) != 1 || pod.Spec.Tolerations[0].Key != "dedicated" {
+		t.Errorf("tolerations = %+v", pod.Spec.Tolerations)
+	}
+	if pod.Name != "kubrowser-alice" || pod.Labels["username"] != "alice" {
+		t.Errorf("name = %q, labels = %v", pod.Name, pod.Labels)
+	}
+	if pod.Spec.ServiceAccountName != "kubectl-pod" {
+		t.Errorf("serviceAccountName = %q", pod.Spec.ServiceAccountName)
+	}
+}
+
+func TestPodTemplateSidecarOnly(t *testing.T) {
+	pod := renderTestPod(t, `
+spec:
+  containers:
+  - name: proxy
+    image: example.com/proxy:1.0
+`)
+
+	if len(pod.Spec.Containers) != 2 {
+		t.Fatalf("containers = %+v, want terminal and proxy", pod.Spec.Containers)
+	}
+	terminal := findContainer(pod, DefaultTerminalContainer)
+	if terminal == nil || terminal.Image != "bitnami/kubectl:latest" {
+		t.Fatalf("terminal container = %+v", terminal)
+	}
+	if len(terminal.VolumeMounts) != 1 || terminal.VolumeMounts[0].MountPath != "/home/alice" {
+		t.Errorf("terminal mounts = %+v", terminal.VolumeMounts)
+	}
+	if sidecar := findContainer(pod, "proxy"); sidecar == nil || sidecar.Image != "example.com/proxy:1.0" {
+		t.Errorf("sidecar = %+v", sidecar)
+	}
+	if TerminalContainerName(pod) != DefaultTerminalContainer {
+		t.Errorf("terminal container annotation = %q", TerminalContainerName(pod))
+	}
+}
+
+func TestPodTemplateRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "reserved home volume",
+			text: `
+volumes:
+- name: home
+  emptyDir: {}
+`,
+			want: "reserved for the home directory",
+		},
+		{
+			name: "metadata name",
+			text: `
+metadata:
+  name: my-pod
+spec:
+  containers:
+  - name: terminal
+`,
+			want: "metadata.name is set by Kubrowser",
+		},
+		{
+			name: "unknown field",
+			text: `
+nodeSelectr:
+  pool: terminals
+`,
+			want: "not a valid PodSpec",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePodTemplate("test", tt.text)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ParsePodTemplate error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
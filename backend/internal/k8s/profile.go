@@ -0,0 +1,163 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubrowser/kubrowser-backend/internal/auth"
+)
+
+const (
+	// ProfileLabel records the terminal profile a session pod was started with.
+	ProfileLabel = "kubrowser.io/profile"
+
+	// DefaultProfilesKey is the ConfigMap key holding the profile definitions.
+	DefaultProfilesKey = "profiles.yaml"
+)
+
+// reservedProfileEnv lists environment variables a profile may not set.
+var reservedProfileEnv = map[string]bool{"KUBROWSER_USER": true}
+
+// Profile is a named kind of terminal a user can start, such as a minimal kubectl shell or a full
+// toolbox. Empty fields fall back to the global pod configuration.
+type Profile struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Image       string            `json:"image,omitempty"`
+	CPU         string            `json:"cpu,omitempty"`
+	Memory      string            `json:"memory,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	// MinRole is the lowest role allowed to launch the profile; empty allows every user.
+	MinRole string `json:"minRole,omitempty"`
+	// Template is an inline pod template used instead of the global one.
+	Template string `json:"template,omitempty"`
+
+	template *PodTemplate
+}
+
+// ProfileSource locates the profile definitions: a file, or a key of a ConfigMap given as "name"
+// or "namespace/name". Both empty means profiles are not used.
+type ProfileSource struct {
+	File      string
+	ConfigMap string
+	Key       string
+}
+
+// Profiles is the set of terminal profiles, one of which is the default.
+type Profiles struct {
+	Default  string     `json:"default"`
+	Profiles []*Profile `json:"profiles"`
+
+	byName map[string]*Profile
+}
+
+// LoadProfiles reads and validates the profile definitions at src. It returns nil when src is empty.
+func LoadProfiles(ctx context.Context, client kubernetes.Interface, namespace string, src ProfileSource) (*Profiles, error) {
+	if src.Key == "" {
+		src.Key = DefaultProfilesKey
+	}
+	source, data, err := readConfigSource(ctx, client, namespace, src.File, src.ConfigMap, src.Key)
+	if err != nil || source == "" {
+		return nil, err
+	}
+	return ParseProfiles(source, []byte(data))
+}
+
+// ParseProfiles parses profile definitions in YAML or JSON and validates every profile, including
+// its pod template. The default is the first profile unless set explicitly.
+func ParseProfiles(source string, data []byte) (*Profiles, error) {
+	profiles := &Profiles{}
+	if err := yaml.UnmarshalStrict(data, profiles); err != nil {
+		return nil, fmt.Errorf("invalid profiles %s: %w", source, err)
+	}
+	if len(profiles.Profiles) == 0 {
+		return nil, fmt.Errorf("invalid profiles %s: no profiles defined", source)
+	}
+
+	profiles.byName = make(map[string]*Profile, len(profiles.Profiles))
+	for _, profile := range profiles.Profiles {
+		if err := profile.validate(source); err != nil {
+			return nil, fmt.Errorf("invalid profile %q in %s: %w", profile.Name, source, err)
+		}
+		if _, dup := profiles.byName[profile.Name]; dup {
+			return nil, fmt.Errorf("invalid profiles %s: duplicate profile %q", source, profile.Name)
+		}
+		profiles.byName[profile.Name] = profile
+	}
+
+	if profiles.Default == "" {
+		profiles.Default = profiles.Profiles[0].Name
+	}
+	if _, ok := profiles.byName[profiles.Default]; !ok {
+		return nil, fmt.Errorf("invalid profiles %s: default profile %q is not defined", source, profiles.Default)
+	}
+	return profiles, nil
+}
+
+func (p *Profile) validate(source string) error {
+	// The name is used as a label value.
+	if errs := validation.IsValidLabelValue(p.Name); p.Name == "" || len(errs) > 0 {
+		return fmt.Errorf("name must be a valid label value")
+	}
+	for field, value := range map[string]string{"cpu": p.CPU, "memory": p.Memory} {
+		if value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return fmt.Errorf("invalid %s %q", field, value)
+		}
+	}
+	// A misspelled role would otherwise lock every user out of the profile at launch time.
+	if p.MinRole != "" {
+		role, ok := auth.ParseRole(p.MinRole)
+		if !ok {
+			return fmt.Errorf("unknown minRole %q", p.MinRole)
+		}
+		p.MinRole = string(role)
+	}
+	for name := range p.Env {
+		if reservedProfileEnv[name] {
+			return fmt.Errorf("environment variable %s is set by Kubrowser", name)
+		}
+	}
+	if p.Template != "" {
+		template, err := ParsePodTemplate(fmt.Sprintf("%s[%s]", source, p.Name), p.Template)
+		if err != nil {
+			return err
+		}
+		p.template = template
+	}
+	return nil
+}
+
+// Get returns the profile with the given name, or the default profile when name is empty.
+func (p *Profiles) Get(name string) (*Profile, bool) {
+	if p == nil {
+		return nil, false
+	}
+	if name == "" {
+		name = p.Default
+	}
+	profile, ok := p.byName[name]
+	return profile, ok
+}
+
+// envVars returns the profile's environment sorted by name.
+func (p *Profile) envVars() []v1.EnvVar {
+	if p == nil {
+		return nil
+	}
+	env := make([]v1.EnvVar, 0, len(p.Env))
+	for name, value := range p.Env {
+		env = append(env, v1.EnvVar{Name: name, Value: value})
+	}
+	sort.Slice(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+	return env
+}
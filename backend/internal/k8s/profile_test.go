@@ -0,0 +1,56 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProfiles(t *testing.T) {
+	profiles, err := ParseProfiles("test", []byte(`
+profiles:
+  - name: minimal
+  - name: toolbox
+    minRole: Operator
+    cpu: "2"
+`))
+	if err != nil {
+		t.Fatalf("ParseProfiles() error = %v", err)
+	}
+	if profiles.Default != "minimal" {
+		t.Errorf("Default = %q, want the first profile", profiles.Default)
+	}
+	toolbox, ok := profiles.Get("toolbox")
+	if !ok {
+		t.Fatal("Get(toolbox) not found")
+	}
+	if toolbox.MinRole != "operator" {
+		t.Errorf("MinRole = %q, want it normalized to operator", toolbox.MinRole)
+	}
+	if profile, ok := profiles.Get(""); !ok || profile.Name != "minimal" {
+		t.Errorf("Get(\"\") = %v, %v, want the default profile", profile, ok)
+	}
+}
+
+func TestParseProfilesRejected(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"none", `profiles: []`, "no profiles defined"},
+		{"unknown min role", "profiles:\n  - name: toolbox\n    minRole: superuser\n", `unknown minRole "superuser"`},
+		{"invalid name", "profiles:\n  - name: not a label\n", "valid label value"},
+		{"invalid cpu", "profiles:\n  - name: toolbox\n    cpu: lots\n", `invalid cpu "lots"`},
+		{"reserved env", "profiles:\n  - name: toolbox\n    env:\n      KUBROWSER_USER: root\n", "set by Kubrowser"},
+		{"duplicate", "profiles:\n  - name: toolbox\n  - name: toolbox\n", `duplicate profile "toolbox"`},
+		{"undefined default", "default: full\nprofiles:\n  - name: toolbox\n", `default profile "full"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseProfiles("test", []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseProfiles() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
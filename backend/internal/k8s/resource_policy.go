@@ -0,0 +1,343 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var (
+	// ErrBudgetExceeded is returned when a session pod would take Kubrowser over its cluster budget.
+	ErrBudgetExceeded = errors.New("the Kubrowser resource budget is exhausted")
+
+	// ErrResourcesNotAllowed is returned when requested resources exceed the user's policy.
+	ErrResourcesNotAllowed = errors.New("requested resources exceed your limit")
+)
+
+// ResourceConfig sets the CPU and memory of a user's terminal container. In an override, empty
+// fields inherit the default.
+type ResourceConfig struct {
+	CPU    string
+	Memory string
+	// CPURequest and MemoryRequest default to the limits.
+	CPURequest    string
+	MemoryRequest string
+	// MaxCPU and MaxMemory cap what a user may resize their pod to; empty means the limits above.
+	MaxCPU    string
+	MaxMemory string
+}
+
+// ResourceBudget caps the CPU and memory requested by all Kubrowser pods together. Empty fields are unlimited.
+type ResourceBudget struct {
+	CPU    string
+	Memory string
+}
+
+// ResourcePolicy resolves the resources of a user's pod from defaults and per-user and per-role
+// overrides, and enforces the total budget. A user override takes precedence over a role override.
+type ResourcePolicy struct {
+	defaults ResourceConfig
+	users    map[string]ResourceConfig
+	roles    map[string]ResourceConfig
+	budget   v1.ResourceList
+}
+
+// NewResourcePolicy creates a policy, validating the defaults, every override and the budget.
+func NewResourcePolicy(defaults ResourceConfig, users, roles map[string]ResourceConfig, budget ResourceBudget) (*ResourcePolicy, error) {
+	policy := &ResourcePolicy{
+		defaults: defaults,
+		users:    make(map[string]ResourceConfig, len(users)),
+		roles:    make(map[string]ResourceConfig, len(roles)),
+		budget:   v1.ResourceList{},
+	}
+	if err := validateResourceConfig(defaults); err != nil {
+		return nil, fmt.Errorf("invalid pod resources: %w", err)
+	}
+	for role, override := range roles {
+		policy.roles[strings.ToLower(role)] = override
+		if err := validateResourceConfig(mergeResourceConfig(defaults, override)); err != nil {
+			return nil, fmt.Errorf("invalid pod resources for role %s: %w", role, err)
+		}
+	}
+	for user, override := range users {
+		policy.users[strings.ToLower(user)] = override
+		if err := validateResourceConfig(mergeResourceConfig(defaults, override)); err != nil {
+			return nil, fmt.Errorf("invalid pod resources for user %s: %w", user, err)
+		}
+	}
+
+	for name, value := range map[v1.ResourceName]string{v1.ResourceCPU: budget.CPU, v1.ResourceMemory: budget.Memory} {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s budget %q", name, value)
+		}
+		policy.budget[name] = quantity
+	}
+	return policy, nil
+}
+
+// ParseResourceOverrides parses overrides keyed by username or role, each of the form
+// "cpu:2;memory:4Gi;maxCpu:4". Keys are cpu, memory, cpuRequest, memoryRequest, maxCpu and maxMemory.
+func ParseResourceOverrides(values map[string]string) (map[string]ResourceConfig, error) {
+	result := make(map[string]ResourceConfig, len(values))
+	for name, value := range values {
+		var override ResourceConfig
+		for _, field := range strings.Split(value, ";") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			key, val, ok := strings.Cut(field, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid resource override for %s: expected key:value, got %q", name, field)
+			}
+			val = strings.TrimSpace(val)
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "cpu":
+				override.CPU = val
+			case "memory":
+				override.Memory = val
+			case "cpurequest":
+				override.CPURequest = val
+			case "memoryrequest":
+				override.MemoryRequest = val
+			case "maxcpu":
+				override.MaxCPU = val
+			case "maxmemory":
+				override.MaxMemory = val
+			default:
+				return nil, fmt.Errorf("invalid resource override for %s: unknown setting %q", name, key)
+			}
+		}
+		result[name] = override
+	}
+	return result, nil
+}
+
+// For returns the resource configuration of a user with the given role.
+func (p *ResourcePolicy) For(username, role string) ResourceConfig {
+	cfg := p.defaults
+	if override, ok := p.roles[strings.ToLower(role)]; ok {
+		cfg = mergeResourceConfig(cfg, override)
+	}
+	if override, ok := p.users[strings.ToLower(username)]; ok {
+		cfg = mergeResourceConfig(cfg, override)
+	}
+	return cfg
+}
+
+// Resolve returns the resources of a user's terminal container. A profile's CPU and memory replace
+// the user's limits, capped by MaxCPU and MaxMemory when set. cpu and memory, if set, resize the
+// pod; they may not exceed the user's maximum, which defaults to the user's limits.
+func (p *ResourcePolicy) Resolve(username, role string, profile *Profile, cpu, memory string) (v1.ResourceRequirements, error) {
+	cfg := p.For(username, role)
+	if profile != nil {
+		if err := checkMax(profile.CPU, cfg.MaxCPU, "CPU"); err != nil {
+			return v1.ResourceRequirements{}, fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		if err := checkMax(profile.Memory, cfg.MaxMemory, "memory"); err != nil {
+			return v1.ResourceRequirements{}, fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		cfg = mergeResourceConfig(cfg, ResourceConfig{CPU: profile.CPU, Memory: profile.Memory})
+	}
+	return p.requirements(cfg, cpu, memory)
+}
+
+// requirements builds the container resources of cfg, resized to cpu and memory when set.
+func (p *ResourcePolicy) requirements(cfg ResourceConfig, cpu, memory string) (v1.ResourceRequirements, error) {
+	limits := v1.ResourceList{}
+	requests := v1.ResourceList{}
+	for _, r := range []struct {
+		name                        v1.ResourceName
+		limit, request, max, resize string
+	}{
+		{v1.ResourceCPU, cfg.CPU, cfg.CPURequest, cfg.MaxCPU, cpu},
+		{v1.ResourceMemory, cfg.Memory, cfg.MemoryRequest, cfg.MaxMemory, memory},
+	} {
+		limit, err := resource.ParseQuantity(r.limit)
+		if err != nil {
+			return v1.ResourceRequirements{}, fmt.Errorf("invalid %s limit %q", r.name, r.limit)
+		}
+		request := limit.DeepCopy()
+		if r.request != "" {
+			if request, err = resource.ParseQuantity(r.request); err != nil {
+				return v1.ResourceRequirements{}, fmt.Errorf("invalid %s request %q", r.name, r.request)
+			}
+		}
+		// A profile or resize may lower the limit below the configured request.
+		if request.Cmp(limit) > 0 {
+			request = limit.DeepCopy()
+		}
+		maxValue := limit.DeepCopy()
+		if r.max != "" {
+			if maxValue, err = resource.ParseQuantity(r.max); err != nil {
+				return v1.ResourceRequirements{}, fmt.Errorf("invalid max %s %q", r.name, r.max)
+			}
+		}
+
+		if r.resize != "" {
+			resized, err := resource.ParseQuantity(r.resize)
+			if err != nil || resized.Sign() <= 0 {
+				return v1.ResourceRequirements{}, fmt.Errorf("invalid %s %q", r.name, r.resize)
+			}
+			if resized.Cmp(maxValue) > 0 {
+				return v1.ResourceRequirements{}, fmt.Errorf("%w: %s %s is above your maximum of %s", ErrResourcesNotAllowed, r.name, r.resize, maxValue.String())
+			}
+			limit = resized
+			if request.Cmp(limit) > 0 {
+				request = limit.DeepCopy()
+			}
+		}
+		limits[r.name] = limit
+		requests[r.name] = request
+	}
+	return v1.ResourceRequirements{Limits: limits, Requests: requests}, nil
+}
+
+// validateResourceConfig checks every quantity parses and requests are not above limits.
+func validateResourceConfig(cfg ResourceConfig) error {
+	for _, r := range []struct{ name, limit, request, max string }{
+		{"cpu", cfg.CPU, cfg.CPURequest, cfg.MaxCPU},
+		{"memory", cfg.Memory, cfg.MemoryRequest, cfg.MaxMemory},
+	} {
+		limit, err := resource.ParseQuantity(r.limit)
+		if err != nil {
+			return fmt.Errorf("invalid %s limit %q", r.name, r.limit)
+		}
+		if r.request != "" {
+			request, err := resource.ParseQuantity(r.request)
+			if err != nil {
+				return fmt.Errorf("invalid %s request %q", r.name, r.request)
+			}
+			if request.Cmp(limit) > 0 {
+				return fmt.Errorf("%s request %s is larger than the limit %s", r.name, r.request, r.limit)
+			}
+		}
+		if r.max != "" {
+			if _, err := resource.ParseQuantity(r.max); err != nil {
+				return fmt.Errorf("invalid max %s %q", r.name, r.max)
+			}
+		}
+	}
+	return nil
+}
+
+// Max returns the largest CPU and memory a user may resize their pod to.
+func (p *ResourcePolicy) Max(username, role string) (cpu, memory string) {
+	cfg := p.For(username, role)
+	cpu, memory = cfg.MaxCPU, cfg.MaxMemory
+	if cpu == "" {
+		cpu = cfg.CPU
+	}
+	if memory == "" {
+		memory = cfg.Memory
+	}
+	return cpu, memory
+}
+
+func mergeResourceConfig(base, override ResourceConfig) ResourceConfig {
+	if override.CPU != "" {
+		base.CPU = override.CPU
+	}
+	if override.Memory != "" {
+		base.Memory = override.Memory
+	}
+	if override.CPURequest != "" {
+		base.CPURequest = override.CPURequest
+	}
+	if override.MemoryRequest != "" {
+		base.MemoryRequest = override.MemoryRequest
+	}
+	if override.MaxCPU != "" {
+		base.MaxCPU = override.MaxCPU
+	}
+	if override.MaxMemory != "" {
+		base.MaxMemory = override.MaxMemory
+	}
+	return base
+}
+
+// checkMax returns ErrResourcesNotAllowed if value is above maxValue. Empty values are not checked.
+func checkMax(value, maxValue, name string) error {
+	if value == "" || maxValue == "" {
+		return nil
+	}
+	v, err := resource.ParseQuantity(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", name, value)
+	}
+	m, err := resource.ParseQuantity(maxValue)
+	if err != nil {
+		return fmt.Errorf("invalid max %s %q", name, maxValue)
+	}
+	if v.Cmp(m) > 0 {
+		return fmt.Errorf("%w: %s %s is above your maximum of %s", ErrResourcesNotAllowed, name, value, maxValue)
+	}
+	return nil
+}
+
+// resourcePolicy returns the configured policy, or one built from the global limits.
+func (pm *PodManager) resourcePolicy() *ResourcePolicy {
+	if pm.resources != nil {
+		return pm.resources
+	}
+	return &ResourcePolicy{defaults: ResourceConfig{CPU: pm.limits.CPU, Memory: pm.limits.Memory}}
+}
+
+// SetResourcePolicy sets per-user resources and the cluster budget; nil gives every pod the global limits.
+func (pm *PodManager) SetResourcePolicy(policy *ResourcePolicy) {
+	pm.resources = policy
+}
+
+// ResourcePolicy returns the policy used to size session pods.
+func (pm *PodManager) ResourcePolicy() *ResourcePolicy {
+	return pm.resourcePolicy()
+}
+
+// checkBudget returns ErrBudgetExceeded if adding the requests of pod, sidecars included, to the
+// requests of all running Kubrowser pods, except the one pod replaces, would exceed the budget.
+func (pm *PodManager) checkBudget(ctx context.Context, pod *v1.Pod) error {
+	budget := pm.resourcePolicy().budget
+	if len(budget) == 0 {
+		return nil
+	}
+	requests, _ := PodRequestsAndLimits(pod)
+
+	pods, err := pm.ListPods(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list session pods: %w", err)
+	}
+	used := v1.ResourceList{}
+	for i := range pods {
+		running := &pods[i]
+		if running.Name == pod.Name || running.DeletionTimestamp != nil ||
+			running.Status.Phase == v1.PodSucceeded || running.Status.Phase == v1.PodFailed {
+			continue
+		}
+		reqs, _ := PodRequestsAndLimits(running)
+		addResourceList(used, reqs)
+	}
+
+	for name, limit := range budget {
+		total := used[name].DeepCopy()
+		total.Add(requests[name])
+		if total.Cmp(limit) > 0 {
+			free := limit.DeepCopy()
+			free.Sub(used[name])
+			if free.Sign() < 0 {
+				free = resource.Quantity{}
+			}
+			requested := requests[name]
+			return fmt.Errorf("%w: this session needs %s %s but only %s of the %s budget is free; try again later or choose a smaller profile",
+				ErrBudgetExceeded, requested.String(), name, free.String(), limit.String())
+		}
+	}
+	return nil
+}
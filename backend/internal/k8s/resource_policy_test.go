@@ -0,0 +1,223 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResourcePolicyResolve(t *testing.T) {
+	policy, err := NewResourcePolicy(
+		ResourceConfig{CPU: "1", Memory: "1Gi", CPURequest: "250m", MaxCPU: "2", MaxMemory: "4Gi"},
+		map[string]ResourceConfig{"Alice": {CPU: "3", MaxCPU: "4"}},
+		map[string]ResourceConfig{"operator": {Memory: "2Gi", MemoryRequest: "1Gi"}, "admin": {CPU: "2"}},
+		ResourceBudget{},
+	)
+	if err != nil {
+		t.Fatalf("NewResourcePolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		username     string
+		role         string
+		profile      *Profile
+		cpu, memory  string
+		wantRequests v1.ResourceList
+		wantLimits   v1.ResourceList
+		wantErr      error
+	}{
+		{
+			name:         "defaults",
+			username:     "bob",
+			role:         "viewer",
+			wantRequests: resources("250m", "1Gi"),
+			wantLimits:   resources("1", "1Gi"),
+		},
+		{
+			name:         "role override",
+			username:     "bob",
+			role:         "Operator",
+			wantRequests: resources("250m", "1Gi"),
+			wantLimits:   resources("1", "2Gi"),
+		},
+		{
+			name:         "user override takes precedence over role",
+			username:     "alice",
+			role:         "admin",
+			wantRequests: resources("250m", "1Gi"),
+			wantLimits:   resources("3", "1Gi"),
+		},
+		{
+			name:         "profile replaces limits",
+			username:     "bob",
+			role:         "viewer",
+			profile:      &Profile{Name: "toolbox", CPU: "2", Memory: "3Gi"},
+			wantRequests: resources("250m", "3Gi"),
+			wantLimits:   resources("2", "3Gi"),
+		},
+		{
+			name:         "profile below the request lowers it",
+			username:     "bob",
+			role:         "viewer",
+			profile:      &Profile{Name: "tiny", CPU: "100m"},
+			wantRequests: resources("100m", "1Gi"),
+			wantLimits:   resources("100m", "1Gi"),
+		},
+		{
+			name:     "profile above max",
+			username: "bob",
+			role:     "viewer",
+			profile:  &Profile{Name: "huge", CPU: "8"},
+			wantErr:  ErrResourcesNotAllowed,
+		},
+		{
+			name:         "resize within max",
+			username:     "bob",
+			role:         "viewer",
+			cpu:          "2",
+			memory:       "512Mi",
+			wantRequests: resources("250m", "512Mi"),
+			wantLimits:   resources("2", "512Mi"),
+		},
+		{
+			name:     "resize above max",
+			username: "bob",
+			role:     "viewer",
+			memory:   "8Gi",
+			wantErr:  ErrResourcesNotAllowed,
+		},
+		{
+			name:     "resize above user max",
+			username: "alice",
+			role:     "viewer",
+			cpu:      "5",
+			wantErr:  ErrResourcesNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := policy.Resolve(tt.username, tt.role, tt.profile, tt.cpu, tt.memory)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			assertResourceList(t, "requests", got.Requests, tt.wantRequests)
+			assertResourceList(t, "limits", got.Limits, tt.wantLimits)
+		})
+	}
+}
+
+func TestResourcePolicyResolveInvalidResize(t *testing.T) {
+	policy := &ResourcePolicy{defaults: ResourceConfig{CPU: "1", Memory: "1Gi"}}
+	for _, cpu := range []string{"lots", "0", "-1"} {
+		_, err := policy.Resolve("bob", "viewer", nil, cpu, "")
+		if err == nil || errors.Is(err, ErrResourcesNotAllowed) {
+			t.Errorf("Resolve(cpu=%q) error = %v, want an invalid quantity error", cpu, err)
+		}
+	}
+}
+
+func TestNewResourcePolicyRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults ResourceConfig
+		roles    map[string]ResourceConfig
+		budget   ResourceBudget
+	}{
+		{"invalid limit", ResourceConfig{CPU: "lots", Memory: "1Gi"}, nil, ResourceBudget{}},
+		{"request above limit", ResourceConfig{CPU: "1", Memory: "1Gi", CPURequest: "2"}, nil, ResourceBudget{}},
+		{"invalid override", ResourceConfig{CPU: "1", Memory: "1Gi"}, map[string]ResourceConfig{"admin": {MaxMemory: "big"}}, ResourceBudget{}},
+		{"invalid budget", ResourceConfig{CPU: "1", Memory: "1Gi"}, nil, ResourceBudget{CPU: "many"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewResourcePolicy(tt.defaults, nil, tt.roles, tt.budget); err == nil {
+				t.Error("NewResourcePolicy() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func budgetPod(name string, phase v1.PodPhase, containers ...v1.Container) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "kubrowser"}},
+		Spec:       v1.PodSpec{Containers: containers},
+		Status:     v1.PodStatus{Phase: phase},
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	terminating := budgetPod("kubrowser-carol", v1.PodRunning, container(resources("2", ""), nil))
+	now := metav1.Now()
+	terminating.DeletionTimestamp = &now
+	unrelated := budgetPod("other", v1.PodRunning, container(resources("2", ""), nil))
+	unrelated.Labels = nil
+
+	client := fake.NewSimpleClientset(
+		budgetPod("kubrowser-alice", v1.PodRunning, container(resources("1", "1Gi"), nil)),
+		budgetPod("kubrowser-bob", v1.PodRunning, container(resources("1", "1Gi"), nil)),
+		budgetPod("kubrowser-dave", v1.PodSucceeded, container(resources("2", ""), nil)),
+		terminating,
+		unrelated,
+	)
+	policy, err := NewResourcePolicy(ResourceConfig{CPU: "1", Memory: "1Gi"}, nil, nil, ResourceBudget{CPU: "3", Memory: "4Gi"})
+	if err != nil {
+		t.Fatalf("NewResourcePolicy() error = %v", err)
+	}
+	pm := &PodManager{client: client, namespace: "default", resources: policy}
+
+	tests := []struct {
+		name    string
+		pod     *v1.Pod
+		wantErr bool
+	}{
+		{
+			name: "fits",
+			pod:  budgetPod("kubrowser-erin", v1.PodPending, container(resources("1", "1Gi"), nil)),
+		},
+		{
+			name:    "over budget",
+			pod:     budgetPod("kubrowser-erin", v1.PodPending, container(resources("1500m", ""), nil)),
+			wantErr: true,
+		},
+		{
+			name: "sidecars count",
+			pod: budgetPod("kubrowser-erin", v1.PodPending,
+				container(resources("500m", "1Gi"), nil), container(resources("600m", ""), nil)),
+			wantErr: true,
+		},
+		{
+			name: "replaced pod is not counted",
+			pod:  budgetPod("kubrowser-alice", v1.PodPending, container(resources("2", "2Gi"), nil)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pm.checkBudget(context.Background(), tt.pod)
+			if tt.wantErr != errors.Is(err, ErrBudgetExceeded) {
+				t.Errorf("checkBudget() error = %v, want budget exceeded: %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkBudget() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckBudgetUnlimited(t *testing.T) {
+	pm := &PodManager{client: fake.NewSimpleClientset(), namespace: "default"}
+	pod := budgetPod("kubrowser-alice", v1.PodPending, container(resources("64", "1Ti"), nil))
+	if err := pm.checkBudget(context.Background(), pod); err != nil {
+		t.Errorf("checkBudget() error = %v, want nil without a budget", err)
+	}
+}
@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// PodRequestsAndLimits returns the effective resource requests and limits of a pod, computed the
+// way the scheduler and kubectl describe node do: the larger of the sum over app containers and
+// sidecars and the maximum over init containers, plus pod overhead. Sidecars are init containers with
+// restartPolicy Always; they keep running, so they add to the sum and to every init container after them.
+func PodRequestsAndLimits(pod *v1.Pod) (reqs, limits v1.ResourceList) {
+	reqs, limits = v1.ResourceList{}, v1.ResourceList{}
+
+	for i := range pod.Spec.Containers {
+		addResourceList(reqs, pod.Spec.Containers[i].Resources.Requests)
+		addResourceList(limits, pod.Spec.Containers[i].Resources.Limits)
+	}
+
+	sidecarReqs, sidecarLimits := v1.ResourceList{}, v1.ResourceList{}
+	initReqs, initLimits := v1.ResourceList{}, v1.ResourceList{}
+	for i := range pod.Spec.InitContainers {
+		container := &pod.Spec.InitContainers[i]
+		if container.RestartPolicy != nil && *container.RestartPolicy == v1.ContainerRestartPolicyAlways {
+			addResourceList(reqs, container.Resources.Requests)
+			addResourceList(limits, container.Resources.Limits)
+			addResourceList(sidecarReqs, container.Resources.Requests)
+			addResourceList(sidecarLimits, container.Resources.Limits)
+			maxResourceList(initReqs, sidecarReqs)
+			maxResourceList(initLimits, sidecarLimits)
+			continue
+		}
+
+		// An init container runs alongside the sidecars started before it.
+		containerReqs, containerLimits := v1.ResourceList{}, v1.ResourceList{}
+		addResourceList(containerReqs, container.Resources.Requests)
+		addResourceList(containerReqs, sidecarReqs)
+		addResourceList(containerLimits, container.Resources.Limits)
+		addResourceList(containerLimits, sidecarLimits)
+		maxResourceList(initReqs, containerReqs)
+		maxResourceList(initLimits, containerLimits)
+	}
+	maxResourceList(reqs, initReqs)
+	maxResourceList(limits, initLimits)
+
+	if pod.Spec.Overhead != nil {
+		addResourceList(reqs, pod.Spec.Overhead)
+		for name, quantity := range pod.Spec.Overhead {
+			if value, ok := limits[name]; ok {
+				value.Add(quantity)
+				limits[name] = value
+			}
+		}
+	}
+
+	return reqs, limits
+}
+
+// addResourceList adds the resources in newList to list.
+func addResourceList(list, newList v1.ResourceList) {
+	for name, quantity := range newList {
+		if value, ok := list[name]; !ok {
+			list[name] = quantity.DeepCopy()
+		} else {
+			value.Add(quantity)
+			list[name] = value
+		}
+	}
+}
+
+// maxResourceList sets list to the greater of list/newList for every resource in newList.
+func maxResourceList(list, newList v1.ResourceList) {
+	for name, quantity := range newList {
+		if value, ok := list[name]; !ok || quantity.Cmp(value) > 0 {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package k8s
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func resources(cpu, memory string) v1.ResourceList {
+	list := v1.ResourceList{}
+	if cpu != "" {
+		list[v1.ResourceCPU] = resource.MustParse(cpu)
+	}
+	if memory != "" {
+		list[v1.ResourceMemory] = resource.MustParse(memory)
+	}
+	return list
+}
+
+func container(requests, limits v1.ResourceList) v1.Container {
+	return v1.Container{Resources: v1.ResourceRequirements{Requests: requests, Limits: limits}}
+}
+
+func sidecar(requests, limits v1.ResourceList) v1.Container {
+	always := v1.ContainerRestartPolicyAlways
+	c := container(requests, limits)
+	c.RestartPolicy = &always
+	return c
+}
+
+func TestPodRequestsAndLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		spec         v1.PodSpec
+		wantRequests v1.ResourceList
+		wantLimits   v1.ResourceList
+	}{
+		{
+			name:         "empty",
+			spec:         v1.PodSpec{Containers: []v1.Container{{}}},
+			wantRequests: v1.ResourceList{},
+			wantLimits:   v1.ResourceList{},
+		},
+		{
+			name: "containers are summed",
+			spec: v1.PodSpec{Containers: []v1.Container{
+				container(resources("250m", "256Mi"), resources("500m", "512Mi")),
+				container(resources("100m", ""), resources("", "128Mi")),
+			}},
+			wantRequests: resources("350m", "256Mi"),
+			wantLimits:   resources("500m", "640Mi"),
+		},
+		{
+			name: "init containers count at their maximum",
+			spec: v1.PodSpec{
+				InitContainers: []v1.Container{
+					container(resources("2", "64Mi"), nil),
+					container(resources("500m", "1Gi"), nil),
+				},
+				Containers: []v1.Container{
+					container(resources("250m", "256Mi"), nil),
+					container(resources("250m", "256Mi"), nil),
+				},
+			},
+			wantRequests: resources("2", "1Gi"),
+			wantLimits:   v1.ResourceList{},
+		},
+		{
+			name: "sidecars add to the sum and to later init containers",
+			spec: v1.PodSpec{
+				InitContainers: []v1.Container{
+					container(resources("1", "64Mi"), nil),
+					sidecar(resources("200m", "128Mi"), resources("", "256Mi")),
+					container(resources("500m", "1Gi"), nil),
+				},
+				Containers: []v1.Container{
+					container(resources("250m", "256Mi"), resources("500m", "512Mi")),
+				},
+			},
+			// App containers and the sidecar: 450m and 384Mi. The last init container and the
+			// sidecar: 700m and 1152Mi. The first init container alone: 1 CPU.
+			wantRequests: resources("1", "1152Mi"),
+			wantLimits:   resources("500m", "768Mi"),
+		},
+		{
+			name: "overhead is added to requests and to set limits",
+			spec: v1.PodSpec{
+				Containers: []v1.Container{container(resources("100m", "100Mi"), resources("200m", ""))},
+				Overhead:   resources("50m", "20Mi"),
+			},
+			wantRequests: resources("150m", "120Mi"),
+			wantLimits:   resources("250m", ""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, limits := PodRequestsAndLimits(&v1.Pod{Spec: tt.spec})
+			assertResourceList(t, "requests", requests, tt.wantRequests)
+			assertResourceList(t, "limits", limits, tt.wantLimits)
+		})
+	}
+}
+
+func TestPodRequestsAndLimitsDoesNotAlias(t *testing.T) {
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{container(resources("100m", ""), nil)}}}
+	requests, _ := PodRequestsAndLimits(pod)
+	cpu := requests[v1.ResourceCPU]
+	cpu.Add(resource.MustParse("1"))
+	requests[v1.ResourceCPU] = cpu
+
+	if got := pod.Spec.Containers[0].Resources.Requests[v1.ResourceCPU]; got.String() != "100m" {
+		t.Errorf("container request changed to %s", got.String())
+	}
+}
+
+func assertResourceList(t *testing.T, what string, got, want v1.ResourceList) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", what, got, want)
+		return
+	}
+	for name, quantity := range want {
+		if value, ok := got[name]; !ok || value.Cmp(quantity) != 0 {
+			t.Errorf("%s[%s] = %v, want %s", what, name, got[name], quantity.String())
+		}
+	}
+}
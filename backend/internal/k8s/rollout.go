@@ -0,0 +1,345 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// KindDeployments identifies apps/v1 Deployments in rollout operations.
+	KindDeployments = "deployments"
+	// KindStatefulSets identifies apps/v1 StatefulSets in rollout operations.
+	KindStatefulSets = "statefulsets"
+
+	// RestartedAtAnnotation is the pod template annotation kubectl uses for rollout restart.
+	RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+	// DeploymentRevisionAnnotation holds the revision number of a Deployment and its ReplicaSets.
+	DeploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+var (
+	// ErrUnsupportedKind is returned when an operation is not available for a workload kind.
+	ErrUnsupportedKind = errors.New("operation not supported for this kind")
+	// ErrRevisionNotFound is returned when the requested rollback revision does not exist.
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrPaused is returned when an operation is refused because the rollout is paused.
+	ErrPaused = errors.New("rollout is paused")
+)
+
+// RolloutStatus describes the progress of a rollout, in the same terms as kubectl rollout status.
+type RolloutStatus struct {
+	Done      bool   `json:"done"`
+	Message   string `json:"message"`
+	Revision  string `json:"revision"`
+	Paused    bool   `json:"paused"`
+	Desired   int32  `json:"desired"`
+	Updated   int32  `json:"updated"`
+	Ready     int32  `json:"ready"`
+	Available int32  `json:"available"`
+}
+
+// ScaleWorkload sets the replica count of a Deployment or StatefulSet through the scale subresource.
+func ScaleWorkload(ctx context.Context, client kubernetes.Interface, kind, namespace, name string, replicas int32) error {
+	switch kind {
+	case KindDeployments:
+		scale, err := client.AppsV1().Deployments(namespace).GetScale(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		scale.Spec.Replicas = replicas
+		_, err = client.AppsV1().Deployments(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+		return err
+	case KindStatefulSets:
+		scale, err := client.AppsV1().StatefulSets(namespace).GetScale(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		scale.Spec.Replicas = replicas
+		_, err = client.AppsV1().StatefulSets(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+		return err
+	default:
+		return ErrUnsupportedKind
+	}
+}
+
+// RestartWorkload triggers a rolling restart by patching the restartedAt pod template annotation.
+func RestartWorkload(ctx context.Context, client kubernetes.Interface, kind, namespace, name string) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		RestartedAtAnnotation, time.Now().Format(time.RFC3339)))
+
+	switch kind {
+	case KindDeployments:
+		deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if deployment.Spec.Paused {
+			return ErrPaused
+		}
+		_, err = client.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+		return err
+	case KindStatefulSets:
+		_, err := client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+		return err
+	default:
+		return ErrUnsupportedKind
+	}
+}
+
+// SetDeploymentPaused pauses or resumes a Deployment rollout.
+func SetDeploymentPaused(ctx context.Context, client kubernetes.Interface, namespace, name string, paused bool) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"paused":%t}}`, paused))
+	_, err := client.AppsV1().Deployments(namespace).Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// UndoWorkload rolls a Deployment or StatefulSet back to the given revision.
+// A revision of 0 rolls back to the previous revision. Returns the revision rolled back to.
+func UndoWorkload(ctx context.Context, client kubernetes.Interface, kind, namespace, name string, revision int64) (int64, error) {
+	switch kind {
+	case KindDeployments:
+		return undoDeployment(ctx, client, namespace, name, revision)
+	case KindStatefulSets:
+		return undoStatefulSet(ctx, client, namespace, name, revision)
+	default:
+		return 0, ErrUnsupportedKind
+	}
+}
+
+func undoDeployment(ctx context.Context, client kubernetes.Interface, namespace, name string, revision int64) (int64, error) {
+	deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	if deployment.Spec.Paused {
+		return 0, ErrPaused
+	}
+
+	replicaSets, err := client.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	byRevision := make(map[int64]*appsv1.ReplicaSet)
+	revisions := make([]int64, 0)
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if !isControlledBy(rs.OwnerReferences, deployment.UID) {
+			continue
+		}
+		rev, parseErr := strconv.ParseInt(rs.Annotations[DeploymentRevisionAnnotation], 10, 64)
+		if parseErr != nil {
+			continue
+		}
+		byRevision[rev] = rs
+		revisions = append(revisions, rev)
+	}
+
+	current, _ := strconv.ParseInt(deployment.Annotations[DeploymentRevisionAnnotation], 10, 64)
+	target, err := pickRevision(revisions, current, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	template := byRevision[target].Spec.Template.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "replace", "path": "/spec/template", "value": template},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to build rollback patch: %w", err)
+	}
+
+	_, err = client.AppsV1().Deployments(namespace).Patch(ctx, name, types.JSONPatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return 0, err
+	}
+	return target, nil
+}
+
+func undoStatefulSet(ctx context.Context, client kubernetes.Interface, namespace, name string, revision int64) (int64, error) {
+	statefulSet, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	history, err := client.AppsV1().ControllerRevisions(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	byRevision := make(map[int64]*appsv1.ControllerRevision)
+	revisions := make([]int64, 0)
+	for i := range history.Items {
+		rev := &history.Items[i]
+		if !isControlledBy(rev.OwnerReferences, statefulSet.UID) {
+			continue
+		}
+		byRevision[rev.Revision] = rev
+		revisions = append(revisions, rev.Revision)
+	}
+
+	var current int64
+	for _, rev := range byRevision {
+		if rev.Name == statefulSet.Status.UpdateRevision {
+			current = rev.Revision
+		}
+	}
+	target, err := pickRevision(revisions, current, revision)
+	if err != nil {
+		return 0, err
+	}
+
+	// ControllerRevision data is a strategic merge patch replacing the pod template.
+	_, err = client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType,
+		byRevision[target].Data.Raw, metav1.PatchOptions{})
+	if err != nil {
+		return 0, err
+	}
+	return target, nil
+}
+
+// pickRevision validates the requested revision, or picks the newest revision older than current when requested is 0.
+func pickRevision(revisions []int64, current, requested int64) (int64, error) {
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i] > revisions[j] })
+
+	if requested != 0 {
+		for _, rev := range revisions {
+			if rev == requested {
+				return rev, nil
+			}
+		}
+		return 0, ErrRevisionNotFound
+	}
+
+	if current == 0 && len(revisions) > 0 {
+		current = revisions[0]
+	}
+	for _, rev := range revisions {
+		if rev < current {
+			return rev, nil
+		}
+	}
+	return 0, ErrRevisionNotFound
+}
+
+// GetRolloutStatus returns the rollout status of a Deployment or StatefulSet.
+func GetRolloutStatus(ctx context.Context, client kubernetes.Interface, kind, namespace, name string) (*RolloutStatus, error) {
+	switch kind {
+	case KindDeployments:
+		deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return deploymentRolloutStatus(deployment), nil
+	case KindStatefulSets:
+		statefulSet, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return statefulSetRolloutStatus(statefulSet), nil
+	default:
+		return nil, ErrUnsupportedKind
+	}
+}
+
+func deploymentRolloutStatus(d *appsv1.Deployment) *RolloutStatus {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+
+	status := &RolloutStatus{
+		Revision:  d.Annotations[DeploymentRevisionAnnotation],
+		Paused:    d.Spec.Paused,
+		Desired:   desired,
+		Updated:   d.Status.UpdatedReplicas,
+		Ready:     d.Status.ReadyReplicas,
+		Available: d.Status.AvailableReplicas,
+	}
+
+	if d.Generation > d.Status.ObservedGeneration {
+		status.Message = "Waiting for deployment spec update to be observed..."
+		return status
+	}
+
+	for i := range d.Status.Conditions {
+		cond := &d.Status.Conditions[i]
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			status.Message = fmt.Sprintf("deployment %q exceeded its progress deadline", d.Name)
+			return status
+		}
+	}
+
+	switch {
+	case d.Status.UpdatedReplicas < desired:
+		status.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...",
+			d.Name, d.Status.UpdatedReplicas, desired)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...",
+			d.Name, d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...",
+			d.Name, d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	default:
+		status.Done = true
+		status.Message = fmt.Sprintf("deployment %q successfully rolled out", d.Name)
+	}
+
+	if d.Spec.Paused && !status.Done {
+		status.Message += " (rollout is paused)"
+	}
+	return status
+}
+
+func statefulSetRolloutStatus(s *appsv1.StatefulSet) *RolloutStatus {
+	desired := int32(1)
+	if s.Spec.Replicas != nil {
+		desired = *s.Spec.Replicas
+	}
+
+	status := &RolloutStatus{
+		Revision:  s.Status.UpdateRevision,
+		Desired:   desired,
+		Updated:   s.Status.UpdatedReplicas,
+		Ready:     s.Status.ReadyReplicas,
+		Available: s.Status.AvailableReplicas,
+	}
+
+	switch {
+	case s.Status.ObservedGeneration == 0 || s.Generation > s.Status.ObservedGeneration:
+		status.Message = "Waiting for statefulset spec update to be observed..."
+	case s.Status.ReadyReplicas < desired:
+		status.Message = fmt.Sprintf("Waiting for %d pods to be ready...", desired-s.Status.ReadyReplicas)
+	case s.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType &&
+		s.Status.UpdateRevision != s.Status.CurrentRevision:
+		status.Message = fmt.Sprintf("waiting for statefulset rolling update to complete %d pods at revision %s...",
+			s.Status.UpdatedReplicas, s.Status.UpdateRevision)
+	default:
+		status.Done = true
+		status.Message = fmt.Sprintf("statefulset rolling update complete %d pods at revision %s...",
+			s.Status.CurrentReplicas, s.Status.CurrentRevision)
+	}
+	return status
+}
+
+// isControlledBy reports whether the controlling owner reference has the given UID.
+func isControlledBy(refs []metav1.OwnerReference, uid types.UID) bool {
+	for i := range refs {
+		if refs[i].Controller != nil && *refs[i].Controller && refs[i].UID == uid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package k8s
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPickRevision(t *testing.T) {
+	tests := []struct {
+		name      string
+		revisions []int64
+		current   int64
+		requested int64
+		want      int64
+		wantErr   error
+	}{
+		{"previous of current", []int64{1, 2, 3}, 3, 0, 2, nil},
+		{"unsorted input", []int64{3, 1, 2}, 3, 0, 2, nil},
+		{"gaps in history", []int64{2, 5, 9}, 9, 0, 5, nil},
+		// After an undo the current revision is not the newest.
+		{"current below newest", []int64{4, 5, 6}, 5, 0, 4, nil},
+		{"unknown current uses newest", []int64{1, 2, 3}, 0, 0, 2, nil},
+		{"only one revision", []int64{1}, 1, 0, 0, ErrRevisionNotFound},
+		{"no revisions", nil, 0, 0, 0, ErrRevisionNotFound},
+		{"requested exists", []int64{1, 2, 3}, 3, 1, 1, nil},
+		{"requested current", []int64{1, 2, 3}, 3, 3, 3, nil},
+		{"requested missing", []int64{1, 2, 3}, 3, 7, 0, ErrRevisionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickRevision(tt.revisions, tt.current, tt.requested)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("pickRevision() = %d, %v, want %d, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// HomeLastUsedAnnotation records when a user's home volume was last attached to a session.
+	HomeLastUsedAnnotation = "kubrowser.io/last-used"
+
+	// DefaultStorageClassAnnotation marks the cluster's default StorageClass.
+	DefaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+	homePVCPrefix   = "kubrowser-home-"
+	homePVCSelector = "app=kubrowser,managed-by=kubrowser-backend"
+)
+
+var (
+	// ErrHomeVolumeInUse is returned when deleting a home volume that a session pod still mounts.
+	ErrHomeVolumeInUse = errors.New("home volume is in use by a session")
+
+	// ErrExpansionNotAllowed is returned when the volume's StorageClass does not allow expansion.
+	ErrExpansionNotAllowed = errors.New("storage class does not allow volume expansion")
+
+	// ErrShrinkNotAllowed is returned when the requested size is not larger than the current one.
+	ErrShrinkNotAllowed = errors.New("new size must be larger than the current size")
+)
+
+// HomeVolume describes a user's home PersistentVolumeClaim.
+type HomeVolume struct {
+	Username     string     `json:"username"`
+	PVC          string     `json:"pvc"`
+	UID          string     `json:"uid"`
+	Phase        string     `json:"phase"`
+	StorageClass string     `json:"storageClass,omitempty"`
+	Requested    string     `json:"requested"`
+	Capacity     string     `json:"capacity,omitempty"`
+	AccessModes  []string   `json:"accessModes"`
+	Created      time.Time  `json:"created"`
+	LastUsed     *time.Time `json:"lastUsed,omitempty"`
+	ActivePod    string     `json:"activePod,omitempty"`
+	Resizing     bool       `json:"resizing"`
+}
+
+// HomePVCName returns the name of the home PVC of a sanitized username.
+func HomePVCName(sanitizedUsername string) string {
+	return homePVCPrefix + sanitizedUsername
+}
+
+// ListHomeVolumes returns every user home volume with its last-used time. A running session's
+// heartbeat counts as use.
+func (pm *PodManager) ListHomeVolumes(ctx context.Context) ([]HomeVolume, error) {
+	claims, err := pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).List(ctx, metav1.ListOptions{LabelSelector: homePVCSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list home volumes: %w", err)
+	}
+	pods, err := pm.ListPods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session pods: %w", err)
+	}
+
+	mountedBy := make(map[string]*v1.Pod)
+	for i := range pods {
+		for _, vol := range pods[i].Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil {
+				mountedBy[vol.PersistentVolumeClaim.ClaimName] = &pods[i]
+			}
+		}
+	}
+
+	result := make([]HomeVolume, 0, len(claims.Items))
+	for i := range claims.Items {
+		pvc := &claims.Items[i]
+		requested := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+		volume := HomeVolume{
+			Username:    pvc.Labels["username"],
+			PVC:         pvc.Name,
+			UID:         string(pvc.UID),
+			Phase:       string(pvc.Status.Phase),
+			Requested:   requested.String(),
+			AccessModes: accessModeStrings(pvc.Spec.AccessModes),
+			Created:     pvc.CreationTimestamp.Time,
+			Resizing:    pvcResizing(pvc),
+		}
+		if pvc.Spec.StorageClassName != nil {
+			volume.StorageClass = *pvc.Spec.StorageClassName
+		}
+		if capacity, ok := pvc.Status.Capacity[v1.ResourceStorage]; ok {
+			volume.Capacity = capacity.String()
+		}
+		if lastUsed, parseErr := time.Parse(time.RFC3339, pvc.Annotations[HomeLastUsedAnnotation]); parseErr == nil {
+			volume.LastUsed = &lastUsed
+		}
+		if pod, ok := mountedBy[pvc.Name]; ok {
+			volume.ActivePod = pod.Name
+			if heartbeat, parseErr := time.Parse(time.RFC3339, pod.Annotations[HeartbeatAnnotation]); parseErr == nil &&
+				(volume.LastUsed == nil || heartbeat.After(*volume.LastUsed)) {
+				volume.LastUsed = &heartbeat
+			}
+		}
+		result = append(result, volume)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Username < result[j].Username })
+	return result, nil
+}
+
+// DeleteHomeVolume deletes a user's home PVC. Unless force is set it refuses while a session pod mounts it.
+func (pm *PodManager) DeleteHomeVolume(ctx context.Context, username string, force bool) error {
+	pvcName := HomePVCName(sessionUsername(username))
+	if !force {
+		pods, err := pm.ListPodsByUsername(ctx, username)
+		if err != nil {
+			return fmt.Errorf("failed to list session pods: %w", err)
+		}
+		for i := range pods {
+			if podMountsClaim(&pods[i], pvcName) && pods[i].DeletionTimestamp == nil {
+				return fmt.Errorf("%w: %s", ErrHomeVolumeInUse, pods[i].Name)
+			}
+		}
+	}
+
+	return pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Delete(ctx, pvcName, metav1.DeleteOptions{})
+}
+
+// ExpandHomeVolume grows a user's home PVC to size. The StorageClass must allow volume expansion.
+func (pm *PodManager) ExpandHomeVolume(ctx context.Context, username string, size resource.Quantity) (*v1.PersistentVolumeClaim, error) {
+	pvcName := HomePVCName(sessionUsername(username))
+	pvc, err := pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return pm.expandPVC(ctx, pvc, size)
+}
+
+// expandPVC patches the storage request of a PVC after checking its StorageClass allows expansion.
+func (pm *PodManager) expandPVC(ctx context.Context, pvc *v1.PersistentVolumeClaim, size resource.Quantity) (*v1.PersistentVolumeClaim, error) {
+	current := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if size.Cmp(current) <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrShrinkNotAllowed, current.String())
+	}
+
+	expandable, err := pm.storageClassAllowsExpansion(ctx, pvc)
+	if err != nil {
+		return nil, err
+	}
+	if !expandable {
+		return nil, ErrExpansionNotAllowed
+	}
+
+	patch := []byte(fmt.Sprintf(`{"spec":{"resources":{"requests":{"storage":%q}}}}`, size.String()))
+	return pm.client.CoreV1().PersistentVolumeClaims(pvc.Namespace).Patch(ctx, pvc.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+}
+
+// storageClassAllowsExpansion reports whether the PVC's StorageClass allows expansion.
+func (pm *PodManager) storageClassAllowsExpansion(ctx context.Context, pvc *v1.PersistentVolumeClaim) (bool, error) {
+	className := ""
+	if pvc.Spec.StorageClassName != nil {
+		className = *pvc.Spec.StorageClassName
+	}
+	if className == "" {
+		return false, nil
+	}
+
+	class, err := pm.client.StorageV1().StorageClasses().Get(ctx, className, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get storage class: %w", err)
+	}
+	return class.AllowVolumeExpansion != nil && *class.AllowVolumeExpansion, nil
+}
+
+// touchHomePVC records the last time a home volume was used. Failures are ignored: the annotation
+// is informational and the PVC may already be gone.
+func (pm *PodManager) touchHomePVC(ctx context.Context, pvcName string, at time.Time) {
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, HomeLastUsedAnnotation, at.Format(time.RFC3339)))
+	_, _ = pm.client.CoreV1().PersistentVolumeClaims(pm.namespace).Patch(ctx, pvcName, types.MergePatchType, patch, metav1.PatchOptions{})
+}
+
+func podMountsClaim(pod *v1.Pod, claimName string) bool {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == claimName {
+			return true
+		}
+	}
+	return false
+}
+
+// pvcResizing reports whether a PVC has a pending or in-progress resize.
+func pvcResizing(pvc *v1.PersistentVolumeClaim) bool {
+	for _, cond := range pvc.Status.Conditions {
+		if (cond.Type == v1.PersistentVolumeClaimResizing || cond.Type == v1.PersistentVolumeClaimFileSystemResizePending) &&
+			cond.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func accessModeStrings(modes []v1.PersistentVolumeAccessMode) []string {
+	result := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		result = append(result, string(mode))
+	}
+	return result
+}
@@ -0,0 +1,260 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is a log severity, ordered from least to most severe.
+type Level int
+
+// Known severity levels.
+const (
+	LevelUnknown Level = iota
+	LevelTrace
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+// levelKeys are the field names that commonly hold the severity in JSON and logfmt logs.
+var levelKeys = []string{"level", "lvl", "severity", "log.level", "levelname", "loglevel"}
+
+// ParseLevel parses a severity name or a numeric level as used by pino and bunyan.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "trace", "trc":
+		return LevelTrace, nil
+	case "debug", "dbg", "d":
+		return LevelDebug, nil
+	case "info", "inf", "information", "notice", "i":
+		return LevelInfo, nil
+	case "warn", "warning", "wrn", "w":
+		return LevelWarn, nil
+	case "error", "err", "e":
+		return LevelError, nil
+	case "fatal", "critical", "crit", "panic", "dpanic", "alert", "emergency", "f":
+		return LevelFatal, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		switch {
+		case n >= 60:
+			return LevelFatal, nil
+		case n >= 50:
+			return LevelError, nil
+		case n >= 40:
+			return LevelWarn, nil
+		case n >= 30:
+			return LevelInfo, nil
+		case n >= 20:
+			return LevelDebug, nil
+		default:
+			return LevelTrace, nil
+		}
+	}
+	return LevelUnknown, fmt.Errorf("unknown log level %q", s)
+}
+
+// DetectLevel extracts the severity of a JSON or logfmt log line.
+func DetectLevel(line string) Level {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			for _, key := range levelKeys {
+				switch value := fields[key].(type) {
+				case string:
+					if level, err := ParseLevel(value); err == nil {
+						return level
+					}
+				case float64:
+					if level, err := ParseLevel(strconv.Itoa(int(value))); err == nil {
+						return level
+					}
+				}
+			}
+			return LevelUnknown
+		}
+	}
+
+	for _, key := range levelKeys {
+		value, ok := logfmtValue(trimmed, key)
+		if !ok {
+			continue
+		}
+		if level, err := ParseLevel(value); err == nil {
+			return level
+		}
+	}
+	return LevelUnknown
+}
+
+// logfmtValue returns the value of key=value in a logfmt line, with surrounding quotes removed.
+func logfmtValue(line, key string) (string, bool) {
+	prefix := key + "="
+	for offset := 0; offset < len(line); {
+		idx := strings.Index(line[offset:], prefix)
+		if idx < 0 {
+			return "", false
+		}
+		idx += offset
+		if idx > 0 && line[idx-1] != ' ' {
+			offset = idx + len(prefix)
+			continue
+		}
+
+		value := line[idx+len(prefix):]
+		if strings.HasPrefix(value, `"`) {
+			if end := strings.Index(value[1:], `"`); end >= 0 {
+				return value[1 : end+1], true
+			}
+			return strings.Trim(value, `"`), true
+		}
+		if end := strings.IndexByte(value, ' '); end >= 0 {
+			value = value[:end]
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// FilterOptions selects log lines. A line matches when it contains any include pattern (or there are
+// none), contains no exclude pattern and is at least MinLevel.
+type FilterOptions struct {
+	Include []string
+	Exclude []string
+	// Regex treats Include and Exclude as RE2 regular expressions instead of substrings.
+	Regex    bool
+	MinLevel Level
+	// Before and After are the number of context lines to emit around each match.
+	Before int
+	After  int
+}
+
+// Matcher applies FilterOptions to log lines and counts matches. It is safe for concurrent use.
+type Matcher struct {
+	opts    FilterOptions
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	matches atomic.Int64
+}
+
+// NewMatcher compiles the filter patterns.
+func NewMatcher(opts FilterOptions) (*Matcher, error) {
+	if opts.Before < 0 || opts.After < 0 {
+		return nil, fmt.Errorf("context line counts must not be negative")
+	}
+
+	m := &Matcher{opts: opts}
+	if opts.Regex {
+		for _, pattern := range opts.Include {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+			}
+			m.include = append(m.include, re)
+		}
+		for _, pattern := range opts.Exclude {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+			}
+			m.exclude = append(m.exclude, re)
+		}
+	}
+	return m, nil
+}
+
+// Active reports whether the matcher filters anything.
+func (m *Matcher) Active() bool {
+	return m != nil && (len(m.opts.Include) > 0 || len(m.opts.Exclude) > 0 || m.opts.MinLevel != LevelUnknown)
+}
+
+// Matches returns the number of matching lines seen so far.
+func (m *Matcher) Matches() int64 {
+	return m.matches.Load()
+}
+
+// Match reports whether line passes the filter.
+func (m *Matcher) Match(line string) bool {
+	if m.opts.MinLevel != LevelUnknown && DetectLevel(line) < m.opts.MinLevel {
+		return false
+	}
+	if len(m.opts.Include) > 0 && !m.contains(line, m.opts.Include, m.include) {
+		return false
+	}
+	if m.contains(line, m.opts.Exclude, m.exclude) {
+		return false
+	}
+	return true
+}
+
+func (m *Matcher) contains(line string, substrings []string, patterns []*regexp.Regexp) bool {
+	if m.opts.Regex {
+		for _, re := range patterns {
+			if re.MatchString(line) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, s := range substrings {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Window applies a Matcher to one stream of lines, keeping the state needed for context lines.
+// A Window must not be shared between streams.
+type Window struct {
+	matcher   *Matcher
+	before    []Event
+	afterLeft int
+	emitted   bool
+	skipped   bool
+}
+
+// NewWindow creates a window for a single stream.
+func (m *Matcher) NewWindow() *Window {
+	return &Window{matcher: m}
+}
+
+// Process feeds a line event through the filter and returns the events to emit: buffered context
+// before a match, the match itself or trailing context. gap reports that lines were skipped since
+// the last emitted event, where grep would print a "--" separator.
+func (w *Window) Process(ev Event) (out []Event, gap bool) {
+	if w.matcher.Match(ev.Line) {
+		ev.Matches = w.matcher.matches.Add(1)
+		gap = w.emitted && w.skipped
+		out = append(w.before, ev)
+		w.before = nil
+		w.afterLeft = w.matcher.opts.After
+		w.emitted, w.skipped = true, false
+		return out, gap
+	}
+
+	ev.Context = true
+	if w.afterLeft > 0 {
+		w.afterLeft--
+		return []Event{ev}, false
+	}
+
+	if w.matcher.opts.Before > 0 {
+		if len(w.before) == w.matcher.opts.Before {
+			w.before = w.before[1:]
+			w.skipped = true
+		}
+		w.before = append(w.before, ev)
+		return nil, false
+	}
+	w.skipped = true
+	return nil, false
+}
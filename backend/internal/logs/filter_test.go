@@ -0,0 +1,194 @@
+package logs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectLevel(t *testing.T) {
+	tests := []struct {
+		line string
+		want Level
+	}{
+		{`{"level":"error","msg":"boom"}`, LevelError},
+		{`{"severity":"WARNING","message":"slow"}`, LevelWarn},
+		{`{"level":30,"msg":"pino info"}`, LevelInfo},
+		{`{"level":60,"msg":"pino fatal"}`, LevelFatal},
+		{`{"msg":"no level"}`, LevelUnknown},
+		{`time=2024-01-01T00:00:00Z level=debug msg="starting"`, LevelDebug},
+		{`lvl="warn" msg=x`, LevelWarn},
+		{`msg="loglevel is set" sublevel=error`, LevelUnknown},
+		{`plain text error line`, LevelUnknown},
+		{`{not json level=info`, LevelInfo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := DetectLevel(tt.line); got != tt.want {
+				t.Errorf("DetectLevel() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{"INFO", LevelInfo, false},
+		{" warning ", LevelWarn, false},
+		{"crit", LevelFatal, false},
+		{"10", LevelTrace, false},
+		{"50", LevelError, false},
+		{"verbose", LevelUnknown, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLevel(tt.in)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("ParseLevel() = %d, %v, want %d, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatcherMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		opts FilterOptions
+		line string
+		want bool
+	}{
+		{"no filter", FilterOptions{}, "anything", true},
+		{"include hit", FilterOptions{Include: []string{"GET", "POST"}}, "POST /api", true},
+		{"include miss", FilterOptions{Include: []string{"GET"}}, "POST /api", false},
+		{"exclude hit", FilterOptions{Exclude: []string{"healthz"}}, "GET /healthz", false},
+		{"exclude wins over include", FilterOptions{Include: []string{"GET"}, Exclude: []string{"healthz"}}, "GET /healthz", false},
+		{"substring is literal", FilterOptions{Include: []string{"a.c"}}, "abc", false},
+		{"regex", FilterOptions{Include: []string{`^GET /api/v\d+`}, Regex: true}, "GET /api/v2/pods", true},
+		{"regex miss", FilterOptions{Include: []string{`^GET`}, Regex: true}, "POST GET", false},
+		{"level at minimum", FilterOptions{MinLevel: LevelWarn}, `level=warn msg=x`, true},
+		{"level below minimum", FilterOptions{MinLevel: LevelWarn}, `level=info msg=x`, false},
+		{"unknown level below minimum", FilterOptions{MinLevel: LevelWarn}, `no level`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMatcher(tt.opts)
+			if err != nil {
+				t.Fatalf("NewMatcher: %v", err)
+			}
+			if got := m.Match(tt.line); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMatcherErrors(t *testing.T) {
+	for name, opts := range map[string]FilterOptions{
+		"invalid include":  {Include: []string{"("}, Regex: true},
+		"invalid exclude":  {Exclude: []string{"["}, Regex: true},
+		"negative context": {Before: -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewMatcher(opts); err == nil {
+				t.Error("NewMatcher succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestMatcherActive(t *testing.T) {
+	var nilMatcher *Matcher
+	if nilMatcher.Active() {
+		t.Error("nil matcher is active")
+	}
+	m, _ := NewMatcher(FilterOptions{Before: 2})
+	if m.Active() {
+		t.Error("context alone makes the matcher active")
+	}
+	m, _ = NewMatcher(FilterOptions{MinLevel: LevelError})
+	if !m.Active() {
+		t.Error("level filter is not active")
+	}
+}
+
+// emitted is a compact form of an emitted event: the line, and whether it is context.
+type emitted struct {
+	line    string
+	context bool
+}
+
+func runWindow(t *testing.T, opts FilterOptions, lines []string) (out []emitted, gaps []int) {
+	t.Helper()
+	m, err := NewMatcher(opts)
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+	w := m.NewWindow()
+	for _, line := range lines {
+		events, gap := w.Process(Event{Line: line})
+		if gap {
+			gaps = append(gaps, len(out))
+		}
+		for _, ev := range events {
+			out = append(out, emitted{ev.Line, ev.Context})
+		}
+	}
+	return out, gaps
+}
+
+func TestWindow(t *testing.T) {
+	lines := []string{"a", "b", "MATCH1", "c", "d", "e", "f", "MATCH2", "g"}
+	tests := []struct {
+		name     string
+		opts     FilterOptions
+		want     []emitted
+		wantGaps []int
+	}{
+		{
+			name: "matches only",
+			opts: FilterOptions{Include: []string{"MATCH"}},
+			want: []emitted{{"MATCH1", false}, {"MATCH2", false}},
+			// Lines were skipped between the matches.
+			wantGaps: []int{1},
+		},
+		{
+			name:     "before and after context",
+			opts:     FilterOptions{Include: []string{"MATCH"}, Before: 1, After: 1},
+			want:     []emitted{{"b", true}, {"MATCH1", false}, {"c", true}, {"f", true}, {"MATCH2", false}, {"g", true}},
+			wantGaps: []int{3},
+		},
+		{
+			name:     "overlapping context has no gap",
+			opts:     FilterOptions{Include: []string{"MATCH"}, Before: 2, After: 2},
+			want:     []emitted{{"a", true}, {"b", true}, {"MATCH1", false}, {"c", true}, {"d", true}, {"e", true}, {"f", true}, {"MATCH2", false}, {"g", true}},
+			wantGaps: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gaps := runWindow(t, tt.opts, lines)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("emitted %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gaps, tt.wantGaps) {
+				t.Errorf("gaps before %v, want %v", gaps, tt.wantGaps)
+			}
+		})
+	}
+}
+
+func TestWindowCountsMatches(t *testing.T) {
+	m, _ := NewMatcher(FilterOptions{Include: []string{"x"}})
+	first, second := m.NewWindow(), m.NewWindow()
+	first.Process(Event{Line: "x1"})
+	out, _ := second.Process(Event{Line: "x2"})
+	if len(out) != 1 || out[0].Matches != 2 {
+		t.Errorf("second match = %+v, want Matches 2 shared across windows", out)
+	}
+	if m.Matches() != 2 {
+		t.Errorf("Matches() = %d, want 2", m.Matches())
+	}
+}
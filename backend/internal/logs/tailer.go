@@ -0,0 +1,398 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Event types emitted by a Tailer.
+const (
+	EventLine          = "line"
+	EventSourceAdded   = "source-added"
+	EventSourceRemoved = "source-removed"
+	EventEnd           = "end"
+	EventError         = "error"
+)
+
+// DefaultMaxSources caps the number of containers a single tailer follows at once.
+const DefaultMaxSources = 50
+
+// palette holds the colors assigned to log sources, chosen to be readable on dark and light themes.
+var palette = []string{
+	"#60a5fa", "#f472b6", "#34d399", "#fbbf24", "#a78bfa", "#f87171",
+	"#22d3ee", "#a3e635", "#fb923c", "#e879f9", "#2dd4bf", "#facc15",
+}
+
+// Source identifies the container a log line came from.
+type Source struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Color     string `json:"color"`
+	Prefix    string `json:"prefix"`
+}
+
+// Event is a log line or a change in the set of followed sources.
+type Event struct {
+	Type   string    `json:"type"`
+	Source *Source   `json:"source,omitempty"`
+	Time   time.Time `json:"timestamp"`
+	Line   string    `json:"line,omitempty"`
+	// Context marks a line emitted only as context around a filter match.
+	Context bool `json:"context,omitempty"`
+	// Matches is the running number of filter matches, set on matching lines.
+	Matches int64 `json:"matches,omitempty"`
+	// Reason and ExitCode describe how a container ended, set on end events when known.
+	Reason   string `json:"reason,omitempty"`
+	ExitCode *int32 `json:"exitCode,omitempty"`
+}
+
+// TailOptions configures which pods and containers a Tailer follows.
+type TailOptions struct {
+	Namespace string
+	Selector  string
+	// Container, if set, is a regular expression matched against container names.
+	Container    string
+	IncludeInit  bool
+	TailLines    *int64
+	SinceSeconds *int64
+	MaxSources   int
+	// Filter, if active, restricts the lines emitted to matches and their context.
+	Filter *Matcher
+
+	selector         labels.Selector
+	containerPattern *regexp.Regexp
+}
+
+// Validate parses the selector and container pattern.
+func (o *TailOptions) Validate() error {
+	if o.Selector == "" {
+		return fmt.Errorf("selector is required")
+	}
+	selector, err := labels.Parse(o.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector: %w", err)
+	}
+	o.selector = selector
+
+	if o.Container != "" {
+		pattern, err := regexp.Compile(o.Container)
+		if err != nil {
+			return fmt.Errorf("invalid container pattern: %w", err)
+		}
+		o.containerPattern = pattern
+	}
+
+	if o.MaxSources <= 0 {
+		o.MaxSources = DefaultMaxSources
+	}
+	return nil
+}
+
+// sourceState tracks the log stream of one container instance.
+type sourceState struct {
+	cancel       context.CancelFunc
+	restartCount int32
+	done         bool
+}
+
+// Tailer follows the logs of every container in pods matching a label selector, picking up pods
+// that appear later and dropping pods that go away.
+type Tailer struct {
+	client  kubernetes.Interface
+	opts    TailOptions
+	mu      sync.Mutex
+	sources map[string]*sourceState
+	out     chan<- Event
+	ctx     context.Context
+	store   cache.Store
+}
+
+// NewTailer creates a tailer. opts must have been validated.
+func NewTailer(client kubernetes.Interface, opts TailOptions) *Tailer {
+	return &Tailer{
+		client:  client,
+		opts:    opts,
+		sources: make(map[string]*sourceState),
+	}
+}
+
+// Run watches matching pods and sends events to out until ctx is done.
+func (t *Tailer) Run(ctx context.Context, out chan<- Event) error {
+	if t.opts.selector == nil {
+		if err := t.opts.Validate(); err != nil {
+			return err
+		}
+	}
+	t.ctx = ctx
+	t.out = out
+
+	factory := informers.NewSharedInformerFactoryWithOptions(t.client, 0,
+		informers.WithNamespace(t.opts.Namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = t.opts.selector.String()
+		}))
+	informer := factory.Core().V1().Pods().Informer()
+	t.store = informer.GetStore()
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if pod, ok := obj.(*v1.Pod); ok {
+				t.reconcile(pod)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if pod, ok := obj.(*v1.Pod); ok {
+				t.reconcile(pod)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*v1.Pod); ok {
+				t.removePod(pod)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch pods: %w", err)
+	}
+
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+	factory.Shutdown()
+
+	t.mu.Lock()
+	for _, state := range t.sources {
+		state.cancel()
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// reconcile starts streams for containers of pod that have logs and are not being followed yet.
+func (t *Tailer) reconcile(pod *v1.Pod) {
+	if pod.DeletionTimestamp != nil && pod.Status.Phase != v1.PodRunning {
+		t.removePod(pod)
+		return
+	}
+
+	statuses := pod.Status.ContainerStatuses
+	if t.opts.IncludeInit {
+		statuses = append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), statuses...)
+	}
+
+	// Events are sent after unlocking, so a slow consumer cannot block the informer or the streams.
+	var events []Event
+	t.mu.Lock()
+	for i := range statuses {
+		status := &statuses[i]
+		if t.opts.containerPattern != nil && !t.opts.containerPattern.MatchString(status.Name) {
+			continue
+		}
+		// Only containers that have started have a log to follow.
+		if status.State.Running == nil && status.State.Terminated == nil {
+			continue
+		}
+
+		key := sourceKey(pod, status.Name)
+		state, known := t.sources[key]
+		if known && (!state.done || state.restartCount == status.RestartCount) {
+			continue
+		}
+		if !known && t.activeCount() >= t.opts.MaxSources {
+			events = append(events, Event{Type: EventError, Time: time.Now(),
+				Line: fmt.Sprintf("not following %s/%s: limit of %d sources reached", pod.Name, status.Name, t.opts.MaxSources)})
+			continue
+		}
+
+		source := NewSource(pod, status.Name)
+		streamCtx, cancel := context.WithCancel(t.ctx)
+		t.sources[key] = &sourceState{cancel: cancel, restartCount: status.RestartCount}
+		go t.follow(streamCtx, key, source, !known)
+	}
+	t.mu.Unlock()
+
+	for _, ev := range events {
+		t.send(ev)
+	}
+}
+
+// removePod stops following every container of pod.
+func (t *Tailer) removePod(pod *v1.Pod) {
+	var events []Event
+	t.mu.Lock()
+	prefix := string(pod.UID) + "/"
+	for key, state := range t.sources {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		state.cancel()
+		delete(t.sources, key)
+		container := strings.TrimPrefix(key, prefix)
+		events = append(events, Event{Type: EventSourceRemoved, Source: NewSource(pod, container), Time: time.Now()})
+	}
+	t.mu.Unlock()
+
+	for _, ev := range events {
+		t.send(ev)
+	}
+}
+
+// follow streams one container's log until it ends or is canceled.
+func (t *Tailer) follow(ctx context.Context, key string, source *Source, isNew bool) {
+	defer func() {
+		t.mu.Lock()
+		if state, ok := t.sources[key]; ok {
+			state.done = true
+		}
+		t.mu.Unlock()
+	}()
+
+	options := &v1.PodLogOptions{
+		Container:  source.Container,
+		Follow:     true,
+		Timestamps: true,
+	}
+	// Tail and since only apply to the first stream of a source; restarted containers are followed from the start.
+	if isNew {
+		options.TailLines = t.opts.TailLines
+		options.SinceSeconds = t.opts.SinceSeconds
+		t.emit(ctx, Event{Type: EventSourceAdded, Source: source, Time: time.Now()})
+	}
+
+	stream, err := t.client.CoreV1().Pods(source.Namespace).GetLogs(source.Pod, options).Stream(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			t.emit(ctx, Event{Type: EventError, Source: source, Time: time.Now(), Line: err.Error()})
+		}
+		return
+	}
+	defer stream.Close()
+
+	var window *Window
+	if t.opts.Filter.Active() {
+		window = t.opts.Filter.NewWindow()
+	}
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		ts, line := SplitTimestamp(scanner.Text())
+		ev := Event{Type: EventLine, Source: source, Time: ts, Line: line}
+		if window == nil {
+			if !t.emit(ctx, ev) {
+				return
+			}
+			continue
+		}
+
+		filtered, _ := window.Process(ev)
+		for _, out := range filtered {
+			if !t.emit(ctx, out) {
+				return
+			}
+		}
+	}
+
+	// The kubelet closes a followed stream when the container exits.
+	if ctx.Err() == nil {
+		end := Event{Type: EventEnd, Source: source, Time: time.Now()}
+		if obj, ok, err := t.store.GetByKey(source.Namespace + "/" + source.Pod); err == nil && ok {
+			if pod, isPod := obj.(*v1.Pod); isPod {
+				end.Reason, end.ExitCode = TerminationDetails(pod, source.Container)
+			}
+		}
+		t.emit(ctx, end)
+	}
+}
+
+// TerminationDetails returns the reason and exit code of a terminated container, if pod reports them.
+func TerminationDetails(pod *v1.Pod, container string) (string, *int32) {
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for i := range statuses {
+		if statuses[i].Name != container {
+			continue
+		}
+		terminated := statuses[i].State.Terminated
+		if terminated == nil {
+			terminated = statuses[i].LastTerminationState.Terminated
+		}
+		if terminated == nil {
+			return "", nil
+		}
+		exitCode := terminated.ExitCode
+		return terminated.Reason, &exitCode
+	}
+	return "", nil
+}
+
+// send emits an event from an informer handler, giving up once the tailer is stopped.
+func (t *Tailer) send(ev Event) {
+	select {
+	case t.out <- ev:
+	case <-t.ctx.Done():
+	}
+}
+
+// emit sends an event from a stream goroutine, blocking until it is consumed or ctx ends.
+func (t *Tailer) emit(ctx context.Context, ev Event) bool {
+	select {
+	case t.out <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (t *Tailer) activeCount() int {
+	count := 0
+	for _, state := range t.sources {
+		if !state.done {
+			count++
+		}
+	}
+	return count
+}
+
+// SplitTimestamp splits the RFC3339 timestamp prefix added by the kubelet from a log line.
+// If the line has no timestamp the current time is returned.
+func SplitTimestamp(text string) (time.Time, string) {
+	if ts, rest, ok := strings.Cut(text, " "); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return parsed, rest
+		}
+	}
+	return time.Now(), text
+}
+
+func sourceKey(pod *v1.Pod, container string) string {
+	return string(pod.UID) + "/" + container
+}
+
+// NewSource describes a container of pod as a log source with a stable color.
+func NewSource(pod *v1.Pod, container string) *Source {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(pod.Name + "/" + container))
+	return &Source{
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		Container: container,
+		Color:     palette[h.Sum32()%uint32(len(palette))],
+		Prefix:    fmt.Sprintf("%s %s", pod.Name, container),
+	}
+}
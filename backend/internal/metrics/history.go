@@ -0,0 +1,252 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kubrowser/kubrowser-backend/internal/k8s"
+)
+
+// Series kinds.
+const (
+	KindNode = "node"
+	KindPod  = "pod"
+)
+
+// Point is a single usage sample.
+type Point struct {
+	Time          time.Time `json:"time"`
+	CPUMillicores int64     `json:"cpuMillicores"`
+	MemoryBytes   int64     `json:"memoryBytes"`
+}
+
+// ring is a fixed-size circular buffer of points, oldest first.
+type ring struct {
+	points []Point
+	start  int
+	size   int
+}
+
+func newRing(capacity int) *ring {
+	return &ring{points: make([]Point, capacity)}
+}
+
+func (r *ring) add(p Point) {
+	idx := (r.start + r.size) % len(r.points)
+	r.points[idx] = p
+	if r.size < len(r.points) {
+		r.size++
+	} else {
+		r.start = (r.start + 1) % len(r.points)
+	}
+}
+
+func (r *ring) last() (Point, bool) {
+	if r.size == 0 {
+		return Point{}, false
+	}
+	return r.points[(r.start+r.size-1)%len(r.points)], true
+}
+
+// since returns the points at or after t, oldest first.
+func (r *ring) since(t time.Time) []Point {
+	result := make([]Point, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		p := r.points[(r.start+i)%len(r.points)]
+		if !p.Time.Before(t) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Sampler periodically scrapes node and pod metrics into bounded in-memory ring buffers.
+type Sampler struct {
+	logger    *logrus.Logger
+	client    *k8s.MetricsClient
+	interval  time.Duration
+	retention time.Duration
+	capacity  int
+	mu        sync.RWMutex
+	series    map[string]*ring
+	stopChan  chan struct{}
+}
+
+// NewSampler creates a sampler that keeps retention worth of samples taken every interval. Both
+// durations must be positive.
+func NewSampler(logger *logrus.Logger, client *k8s.MetricsClient, interval, retention time.Duration) (*Sampler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("metrics sample interval must be positive, got %v", interval)
+	}
+	if retention <= 0 {
+		return nil, fmt.Errorf("metrics retention must be positive, got %v", retention)
+	}
+	capacity := int(retention / interval)
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Sampler{
+		logger:    logger,
+		client:    client,
+		interval:  interval,
+		retention: retention,
+		capacity:  capacity,
+		series:    make(map[string]*ring),
+		stopChan:  make(chan struct{}),
+	}, nil
+}
+
+// Start runs the sampling loop until Stop is called or ctx is done.
+func (s *Sampler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.sample(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			s.sample(ctx)
+		case <-s.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the sampling loop.
+func (s *Sampler) Stop() {
+	close(s.stopChan)
+}
+
+// Retention returns how far back history is kept.
+func (s *Sampler) Retention() time.Duration {
+	return s.retention
+}
+
+// sample scrapes one round of node and pod metrics.
+func (s *Sampler) sample(ctx context.Context) {
+	if s.client == nil {
+		return
+	}
+
+	sampleCtx, cancel := context.WithTimeout(ctx, s.interval)
+	defer cancel()
+	now := time.Now()
+
+	nodes, err := s.client.NodeMetrics(sampleCtx)
+	if err != nil {
+		s.logSampleError(err, "node")
+	}
+	for name, u := range nodes {
+		s.record(seriesKey(KindNode, name), Point{Time: now, CPUMillicores: u.CPU.MilliValue(), MemoryBytes: u.Memory.Value()})
+	}
+
+	pods, err := s.client.PodMetrics(sampleCtx, "")
+	if err != nil {
+		s.logSampleError(err, "pod")
+	}
+	for key, u := range pods {
+		s.record(seriesKey(KindPod, key), Point{Time: now, CPUMillicores: u.Total.CPU.MilliValue(), MemoryBytes: u.Total.Memory.Value()})
+	}
+
+	s.prune(now)
+}
+
+func (s *Sampler) logSampleError(err error, kind string) {
+	if errors.Is(err, k8s.ErrMetricsUnavailable) {
+		s.logger.WithError(err).Debugf("Skipping %s metrics sample", kind)
+		return
+	}
+	s.logger.WithError(err).Warnf("Failed to sample %s metrics", kind)
+}
+
+func (s *Sampler) record(key string, p Point) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.series[key]
+	if !ok {
+		r = newRing(s.capacity)
+		s.series[key] = r
+	}
+	r.add(p)
+}
+
+// prune drops series that have not been sampled within the retention window, e.g. deleted pods.
+func (s *Sampler) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, r := range s.series {
+		if last, ok := r.last(); !ok || now.Sub(last.Time) > s.retention {
+			delete(s.series, key)
+		}
+	}
+}
+
+// History returns the samples of a series within window, downsampled by averaging into at most
+// maxPoints buckets. name is a node name, or "namespace/name" for pods. Returns false if the series is unknown.
+func (s *Sampler) History(kind, name string, window time.Duration, maxPoints int) ([]Point, bool) {
+	s.mu.RLock()
+	r, ok := s.series[seriesKey(kind, name)]
+	var points []Point
+	if ok {
+		points = r.since(time.Now().Add(-window))
+	}
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+	return downsample(points, maxPoints), true
+}
+
+// downsample averages points into fixed-width time buckets so the result has at most maxPoints entries.
+func downsample(points []Point, maxPoints int) []Point {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+
+	start := points[0].Time
+	// Add a nanosecond so the newest point falls inside the last bucket.
+	bucketWidth := points[len(points)-1].Time.Sub(start)/time.Duration(maxPoints) + 1
+	result := make([]Point, 0, maxPoints)
+
+	var sumCPU, sumMem int64
+	count := int64(0)
+	bucket := 0
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		result = append(result, Point{
+			Time:          start.Add(time.Duration(bucket) * bucketWidth),
+			CPUMillicores: sumCPU / count,
+			MemoryBytes:   sumMem / count,
+		})
+		sumCPU, sumMem, count = 0, 0, 0
+	}
+
+	for _, p := range points {
+		b := int(p.Time.Sub(start) / bucketWidth)
+		if b != bucket {
+			flush()
+			bucket = b
+		}
+		sumCPU += p.CPUMillicores
+		sumMem += p.MemoryBytes
+		count++
+	}
+	flush()
+	return result
+}
+
+func seriesKey(kind, name string) string {
+	return kind + "|" + name
+}
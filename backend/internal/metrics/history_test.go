@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func point(seconds int, cpu int64) Point {
+	return Point{Time: epoch.Add(time.Duration(seconds) * time.Second), CPUMillicores: cpu, MemoryBytes: cpu * 10}
+}
+
+func TestRing(t *testing.T) {
+	r := newRing(3)
+	if _, ok := r.last(); ok {
+		t.Fatal("empty ring has a last point")
+	}
+	if got := r.since(epoch); len(got) != 0 {
+		t.Fatalf("since() on empty ring = %v", got)
+	}
+
+	for i := 1; i <= 5; i++ {
+		r.add(point(i, int64(i)))
+	}
+	// Capacity 3 keeps the newest three points, oldest first.
+	if got, want := r.since(epoch), []Point{point(3, 3), point(4, 4), point(5, 5)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("since(epoch) = %v, want %v", got, want)
+	}
+	if got, want := r.since(epoch.Add(4*time.Second)), []Point{point(4, 4), point(5, 5)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("since(4s) = %v, want %v", got, want)
+	}
+	if last, ok := r.last(); !ok || last != point(5, 5) {
+		t.Errorf("last() = %v, %v", last, ok)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	points := []Point{point(0, 10), point(1, 20), point(2, 30), point(3, 40), point(4, 50), point(5, 60)}
+
+	tests := []struct {
+		name      string
+		points    []Point
+		maxPoints int
+		want      []Point
+	}{
+		{"unlimited", points, 0, points},
+		{"fits", points, 6, points},
+		{"empty", nil, 3, nil},
+		{
+			name:      "halved",
+			points:    points,
+			maxPoints: 3,
+			want: []Point{
+				{Time: epoch, CPUMillicores: 15, MemoryBytes: 150},
+				{Time: epoch.Add(5*time.Second/3 + 1), CPUMillicores: 35, MemoryBytes: 350},
+				{Time: epoch.Add(2 * (5*time.Second/3 + 1)), CPUMillicores: 55, MemoryBytes: 550},
+			},
+		},
+		{
+			name:      "single bucket",
+			points:    points,
+			maxPoints: 1,
+			want:      []Point{{Time: epoch, CPUMillicores: 35, MemoryBytes: 350}},
+		},
+		{
+			// Empty buckets are skipped rather than reported as zero.
+			name:      "gap",
+			points:    []Point{point(0, 10), point(1, 20), point(9, 90), point(10, 100)},
+			maxPoints: 2,
+			want: []Point{
+				{Time: epoch, CPUMillicores: 15, MemoryBytes: 150},
+				{Time: epoch.Add(5*time.Second + 1), CPUMillicores: 95, MemoryBytes: 950},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := downsample(tt.points, tt.maxPoints)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("downsample() = %v, want %v", got, tt.want)
+			}
+			if tt.maxPoints > 0 && len(got) > tt.maxPoints {
+				t.Errorf("downsample() returned %d points, more than %d", len(got), tt.maxPoints)
+			}
+		})
+	}
+}
+
+func TestNewSampler(t *testing.T) {
+	logger := logrus.New()
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		retention time.Duration
+		capacity  int
+		wantErr   bool
+	}{
+		{"default", 30 * time.Second, time.Hour, 120, false},
+		{"retention shorter than interval", time.Minute, time.Second, 1, false},
+		{"zero interval", 0, time.Hour, 0, true},
+		{"negative interval", -time.Second, time.Hour, 0, true},
+		{"zero retention", time.Second, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSampler(logger, nil, tt.interval, tt.retention)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSampler() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && s.capacity != tt.capacity {
+				t.Errorf("capacity = %d, want %d", s.capacity, tt.capacity)
+			}
+		})
+	}
+}
+
+func TestSamplerHistoryAndPrune(t *testing.T) {
+	s, err := NewSampler(logrus.New(), nil, time.Second, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	s.record(seriesKey(KindPod, "default/web"), Point{Time: now.Add(-10 * time.Second), CPUMillicores: 5})
+	s.record(seriesKey(KindPod, "default/web"), Point{Time: now, CPUMillicores: 7})
+	s.record(seriesKey(KindNode, "gone"), Point{Time: now.Add(-2 * time.Minute), CPUMillicores: 1})
+
+	s.prune(now)
+	if _, ok := s.History(KindNode, "gone", time.Hour, 0); ok {
+		t.Error("stale series was not pruned")
+	}
+	points, ok := s.History(KindPod, "default/web", 5*time.Second, 0)
+	if !ok || len(points) != 1 || points[0].CPUMillicores != 7 {
+		t.Errorf("History() = %v, %v, want the newest point only", points, ok)
+	}
+}
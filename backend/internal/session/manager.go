@@ -15,8 +15,9 @@ type Session struct {
 	CreatedAt time.Time
 	LastUsed  time.Time
 	UserID    string
-	Active    bool // Whether there's an active WebSocket connection.
-	ExecLock  bool // Whether an exec is currently running.
+	Profile   string // Terminal profile the pod was started with, empty when profiles are not used.
+	Active    bool   // Whether there's an active WebSocket connection.
+	ExecLock  bool   // Whether an exec is currently running.
 }
 
 // Manager handles session tracking and management.
@@ -35,7 +36,7 @@ func NewManager(timeout time.Duration) *Manager {
 }
 
 // CreateSession creates a new session.
-func (m *Manager) CreateSession(podName, userID string) *Session {
+func (m *Manager) CreateSession(podName, userID, profile string) *Session {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -45,6 +46,7 @@ func (m *Manager) CreateSession(podName, userID string) *Session {
 		CreatedAt: time.Now(),
 		LastUsed:  time.Now(),
 		UserID:    userID,
+		Profile:   profile,
 		Active:    false,
 		ExecLock:  false,
 	}